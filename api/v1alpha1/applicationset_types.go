@@ -31,6 +31,7 @@ type SecretRef struct {
 // ApplicationSet is a set of Application resources
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:path=applicationsets,shortName=appset;appsets
+// +kubebuilder:subresource:status
 type ApplicationSet struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
@@ -39,11 +40,73 @@ type ApplicationSet struct {
 	Status ApplicationSetStatus `json:"status,omitempty"`
 }
 
+// AnnotationApplicationSetRefresh is set on an ApplicationSet, e.g. by the Git webhook receiver, to request its
+// immediate reconciliation. Its value identifies the request, e.g. the time of the webhook event, so that the controller
+// only removes it once the ApplicationSet has been reconciled after the latest request.
+const AnnotationApplicationSetRefresh = "argocd.argoproj.io/application-set-refresh"
+
+// RefreshRequired returns whether the immediate reconciliation of the ApplicationSet has been requested.
+func (a *ApplicationSet) RefreshRequired() bool {
+	_, found := a.Annotations[AnnotationApplicationSetRefresh]
+	return found
+}
+
 // ApplicationSetSpec represents a class of application set state.
 type ApplicationSetSpec struct {
+	// GoTemplate enables rendering the template with Go text/template, instead of the default literal
+	// substitution of '{{param}}' placeholders.
+	GoTemplate bool                      `json:"goTemplate,omitempty"`
 	Generators []ApplicationSetGenerator `json:"generators"`
 	Template   ApplicationSetTemplate    `json:"template"`
 	SyncPolicy *ApplicationSetSyncPolicy `json:"syncPolicy,omitempty"`
+	// GoTemplateOptions are the options passed to text/template when GoTemplate is enabled, e.g.
+	// 'missingkey=error' to fail the generation of Applications that reference a missing param.
+	GoTemplateOptions []string `json:"goTemplateOptions,omitempty"`
+	// TemplatePatch is a YAML or JSON patch rendered with the params, and applied to each generated Application as a
+	// strategic merge patch. It allows params to be rendered into fields of the template that are not strings.
+	TemplatePatch *string `json:"templatePatch,omitempty"`
+	// Strategy configures how changes to the generated Applications are rolled out. By default, all Applications are
+	// created and updated at once.
+	Strategy *ApplicationSetStrategy `json:"strategy,omitempty"`
+	// IgnoreApplicationDifferences selects fields of the generated Applications whose live values are kept when the
+	// Applications are updated, e.g. to allow toggling auto-sync of an Application in the Argo CD UI.
+	IgnoreApplicationDifferences []ApplicationSetIgnoreDifferences `json:"ignoreApplicationDifferences,omitempty"`
+}
+
+// ApplicationSetIgnoreDifferences selects fields of the generated Applications, by JSON pointers or jq path
+// expressions evaluated against the whole Application, e.g. '/spec/syncPolicy' or '.spec.source.targetRevision'.
+type ApplicationSetIgnoreDifferences struct {
+	// Name restricts the ignored differences to the Application with this name. Defaults to all the Applications.
+	Name              string   `json:"name,omitempty"`
+	JSONPointers      []string `json:"jsonPointers,omitempty"`
+	JQPathExpressions []string `json:"jqPathExpressions,omitempty"`
+}
+
+// ApplicationSetStrategy configures how changes to the generated Applications are rolled out.
+type ApplicationSetStrategy struct {
+	// Type is the type of the strategy, one of 'AllAtOnce' (default) or 'RollingSync'.
+	Type string `json:"type,omitempty"`
+	// RollingSync configures the steps of the 'RollingSync' strategy.
+	RollingSync *ApplicationSetRolloutStrategy `json:"rollingSync,omitempty"`
+}
+
+const (
+	// ApplicationSetStrategyAllAtOnce creates and updates all the generated Applications at once
+	ApplicationSetStrategyAllAtOnce = "AllAtOnce"
+	// ApplicationSetStrategyRollingSync creates and updates the generated Applications step by step
+	ApplicationSetStrategyRollingSync = "RollingSync"
+)
+
+// ApplicationSetRolloutStrategy contains the ordered steps of a rolling sync. The Applications of a step are only
+// created or updated once the Applications of all the previous steps are Healthy and Synced.
+type ApplicationSetRolloutStrategy struct {
+	Steps []ApplicationSetRolloutStep `json:"steps,omitempty"`
+}
+
+// ApplicationSetRolloutStep selects the Applications of a step of a rolling sync, by their labels. An Application
+// belongs to the first step it matches. Applications that match no step are rolled out with the last step.
+type ApplicationSetRolloutStep struct {
+	MatchExpressions []metav1.LabelSelectorRequirement `json:"matchExpressions,omitempty"`
 }
 
 // ApplicationSetSyncPolicy configures how generated Applications will relate to their
@@ -51,6 +114,10 @@ type ApplicationSetSpec struct {
 type ApplicationSetSyncPolicy struct {
 	// PreserveResourcesOnDeletion will preserve resources on deletion. If PreserveResourcesOnDeletion is set to true, these Applications will not be deleted.
 	PreserveResourcesOnDeletion bool `json:"preserveResourcesOnDeletion,omitempty"`
+	// Policy defines how the generated Applications are synced with the cluster, one of 'sync' (create, update and
+	// delete), 'create-update' (no deletion) or 'create-only'. The policy of the controller is an upper bound: an
+	// ApplicationSet may restrict it, but cannot allow more than it. Defaults to the policy of the controller.
+	Policy string `json:"policy,omitempty"`
 }
 
 // ApplicationSetTemplate represents argocd ApplicationSpec
@@ -75,8 +142,10 @@ type ApplicationSetGenerator struct {
 	Clusters                *ClusterGenerator     `json:"clusters,omitempty"`
 	Git                     *GitGenerator         `json:"git,omitempty"`
 	Matrix                  *MatrixGenerator      `json:"matrix,omitempty"`
+	Merge                   *MergeGenerator       `json:"merge,omitempty"`
 	SCMProvider             *SCMProviderGenerator `json:"scmProvider,omitempty"`
 	ClusterDecisionResource *DuckTypeGenerator    `json:"clusterDecisionResource,omitempty"`
+	PullRequest             *PullRequestGenerator `json:"pullRequest,omitempty"`
 }
 
 // ApplicationSetBaseGenerator include list item info
@@ -88,6 +157,7 @@ type ApplicationSetBaseGenerator struct {
 	Git                     *GitGenerator         `json:"git,omitempty"`
 	SCMProvider             *SCMProviderGenerator `json:"scmProvider,omitempty"`
 	ClusterDecisionResource *DuckTypeGenerator    `json:"clusterDecisionResource,omitempty"`
+	PullRequest             *PullRequestGenerator `json:"pullRequest,omitempty"`
 }
 
 // ListGenerator include items info
@@ -102,6 +172,15 @@ type MatrixGenerator struct {
 	Template   ApplicationSetTemplate        `json:"template,omitempty"`
 }
 
+// MergeGenerator merges the output of two or more generators. The parameters of the first generator are the base;
+// the parameter sets of the following generators override the base parameter sets that have the same values for
+// all the MergeKeys. Parameter sets that don't match a base parameter set are ignored.
+type MergeGenerator struct {
+	Generators []ApplicationSetBaseGenerator `json:"generators"`
+	MergeKeys  []string                      `json:"mergeKeys"`
+	Template   ApplicationSetTemplate        `json:"template,omitempty"`
+}
+
 // ClusterGenerator defines a generator to match against clusters registered with ArgoCD.
 type ClusterGenerator struct {
 	// Selector defines a label selector to match against all clusters registered with ArgoCD.
@@ -112,6 +191,12 @@ type ClusterGenerator struct {
 
 	// Values contains key/value pairs which are passed directly as parameters to the template
 	Values map[string]string `json:"values,omitempty"`
+
+	// ServerVersion restricts the clusters to those whose API server version matches the constraint, such as '>=1.21'
+	// or '>=1.19, <1.22'. The clusters whose version is unknown are excluded.
+	ServerVersion string `json:"serverVersion,omitempty"`
+	// ExcludeFailedClusters excludes the clusters whose connection state is Failed
+	ExcludeFailedClusters bool `json:"excludeFailedClusters,omitempty"`
 }
 
 // DuckType defines a generator to match against clusters registered with ArgoCD.
@@ -137,9 +222,16 @@ type GitGenerator struct {
 	Revision            string                      `json:"revision"`
 	RequeueAfterSeconds *int64                      `json:"requeueAfterSeconds,omitempty"`
 	Template            ApplicationSetTemplate      `json:"template,omitempty"`
+	// LeafDirectoriesOnly restricts the directories matched by Directories to those without subdirectories
+	LeafDirectoriesOnly bool `json:"leafDirectoriesOnly,omitempty"`
+	// MarkerFiles restricts the directories matched by Directories to those containing at least one of these files,
+	// such as kustomization.yaml or Chart.yaml. The file names may contain path.Match wildcards.
+	MarkerFiles []string `json:"markerFiles,omitempty"`
 }
 
 type GitDirectoryGeneratorItem struct {
+	// Path is a path.Match pattern of the directories to include, or to exclude, in which '**' path segments match any
+	// number of directories, including none
 	Path    string `json:"path"`
 	Exclude bool   `json:"exclude,omitempty"`
 }
@@ -151,8 +243,11 @@ type GitFileGeneratorItem struct {
 // SCMProviderGenerator defines a generator that scrapes a SCMaaS API to find candidate repos.
 type SCMProviderGenerator struct {
 	// Which provider to use and config for it.
-	Github *SCMProviderGeneratorGithub `json:"github,omitempty"`
-	Gitlab *SCMProviderGeneratorGitlab `json:"gitlab,omitempty"`
+	Github          *SCMProviderGeneratorGithub          `json:"github,omitempty"`
+	Gitlab          *SCMProviderGeneratorGitlab          `json:"gitlab,omitempty"`
+	BitbucketServer *SCMProviderGeneratorBitbucketServer `json:"bitbucketServer,omitempty"`
+	Gitea           *SCMProviderGeneratorGitea           `json:"gitea,omitempty"`
+	AzureDevOps     *SCMProviderGeneratorAzureDevOps     `json:"azureDevOps,omitempty"`
 	// Filters for which repos should be considered.
 	Filters []SCMProviderGeneratorFilter `json:"filters,omitempty"`
 	// Which protocol to use for the SCM URL. Default is provider-specific but ssh if possible. Not all providers
@@ -189,6 +284,62 @@ type SCMProviderGeneratorGitlab struct {
 	AllBranches bool `json:"allBranches,omitempty"`
 }
 
+// SCMProviderGeneratorGitea defines a connection info specific to Gitea.
+type SCMProviderGeneratorGitea struct {
+	// Gitea organization to scan. Required.
+	Owner string `json:"owner"`
+	// The Gitea URL to talk to. For example https://gitea.mydomain.com/. Required.
+	API string `json:"api"`
+	// Authentication token reference.
+	TokenRef *SecretRef `json:"tokenRef,omitempty"`
+	// Scan all branches instead of just the default branch.
+	AllBranches bool `json:"allBranches,omitempty"`
+	// Allow self-signed TLS certificates. Defaults to false.
+	Insecure bool `json:"insecure,omitempty"`
+}
+
+// SCMProviderGeneratorAzureDevOps defines a connection info specific to Azure DevOps.
+type SCMProviderGeneratorAzureDevOps struct {
+	// Azure DevOps organization. Required. E.g. "my-organization".
+	Organization string `json:"organization"`
+	// The URL to Azure DevOps. If blank, use https://dev.azure.com.
+	API string `json:"api,omitempty"`
+	// Azure DevOps team project. Required. E.g. "my-team".
+	TeamProject string `json:"teamProject"`
+	// The Personal Access Token (PAT) to use when connecting. Required.
+	AccessTokenRef *SecretRef `json:"accessTokenRef"`
+	// Scan all branches instead of just the default branch.
+	AllBranches bool `json:"allBranches,omitempty"`
+}
+
+// SCMProviderGeneratorBitbucketServer defines a connection info specific to Bitbucket Server (Data Center).
+type SCMProviderGeneratorBitbucketServer struct {
+	// Project to scan. Required.
+	Project string `json:"project"`
+	// The Bitbucket Server URL to talk to, without the REST API path. Required.
+	API string `json:"api"`
+	// Credentials for Basic auth.
+	BasicAuth *BasicAuthBitbucketServer `json:"basicAuth,omitempty"`
+	// Credentials for Bearer auth, with an HTTP access token. Ignored if basicAuth is set.
+	BearerToken *BearerTokenBitbucketServer `json:"bearerToken,omitempty"`
+	// Scan all branches instead of just the default branch.
+	AllBranches bool `json:"allBranches,omitempty"`
+}
+
+// BasicAuthBitbucketServer defines the username/(password or personal access token) for Basic auth.
+type BasicAuthBitbucketServer struct {
+	// Username for Basic auth.
+	Username string `json:"username"`
+	// Password (or personal access token) reference.
+	PasswordRef *SecretRef `json:"passwordRef"`
+}
+
+// BearerTokenBitbucketServer defines the HTTP access token for Bearer auth.
+type BearerTokenBitbucketServer struct {
+	// HTTP access token reference.
+	TokenRef *SecretRef `json:"tokenRef"`
+}
+
 // SCMProviderGeneratorFilter is a single repository filter.
 // If multiple filter types are set on a single struct, they will be AND'd together. All filters must
 // pass for a repo to be included.
@@ -203,10 +354,179 @@ type SCMProviderGeneratorFilter struct {
 	BranchMatch *string `json:"branchMatch,omitempty"`
 }
 
+// PullRequestGenerator defines a generator that scrapes a PullRequest API to find candidate pull requests.
+type PullRequestGenerator struct {
+	// Which provider to use and config for it.
+	Github *PullRequestGeneratorGithub `json:"github,omitempty"`
+	Gitlab *PullRequestGeneratorGitlab `json:"gitlab,omitempty"`
+	// Standard parameters.
+	RequeueAfterSeconds *int64                 `json:"requeueAfterSeconds,omitempty"`
+	Template            ApplicationSetTemplate `json:"template,omitempty"`
+}
+
+// PullRequestGeneratorGithub defines a connection info specific to GitHub.
+type PullRequestGeneratorGithub struct {
+	// GitHub org or user to scan. Required.
+	Owner string `json:"owner"`
+	// GitHub repo name to scan. Required.
+	Repo string `json:"repo"`
+	// The GitHub API URL to talk to. If blank, use https://api.github.com/.
+	API string `json:"api,omitempty"`
+	// Authentication token reference.
+	TokenRef *SecretRef `json:"tokenRef,omitempty"`
+	// Labels is used to filter the PRs that you want to target. All labels must be present on a PR.
+	Labels []string `json:"labels,omitempty"`
+}
+
+// PullRequestGeneratorGitlab defines a connection info specific to Gitlab.
+type PullRequestGeneratorGitlab struct {
+	// GitLab project to scan. Required.  You can use either the project id (recommended) or the full namespaced path.
+	Project string `json:"project"`
+	// The GitLab API URL to talk to. If blank, uses https://gitlab.com/.
+	API string `json:"api,omitempty"`
+	// Authentication token reference.
+	TokenRef *SecretRef `json:"tokenRef,omitempty"`
+	// Labels is used to filter the MRs that you want to target. All labels must be present on a MR.
+	Labels []string `json:"labels,omitempty"`
+}
+
 // ApplicationSetStatus defines the observed state of ApplicationSet
 type ApplicationSetStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// ObservedGeneration is the most recent generation of the ApplicationSet spec that was reconciled.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions reports the outcome of the most recent reconciliation.
+	Conditions []ApplicationSetCondition `json:"conditions,omitempty"`
+	// Applications contains the Applications generated by the ApplicationSet, and the last action taken on each.
+	Applications []ApplicationSetApplicationStatus `json:"applications,omitempty"`
+	// RolloutSteps contains the progress of each step of the rolling sync strategy.
+	RolloutSteps []ApplicationSetRolloutStepStatus `json:"rolloutSteps,omitempty"`
+}
+
+// ApplicationSetCondition contains details about an ApplicationSet condition
+type ApplicationSetCondition struct {
+	// Type is an ApplicationSet condition type
+	Type ApplicationSetConditionType `json:"type"`
+	// Status of the condition, one of True, False or Unknown
+	Status ApplicationSetConditionStatus `json:"status"`
+	// Reason is a short, machine readable explanation of the condition
+	Reason string `json:"reason"`
+	// Message contains human-readable message indicating details about the condition
+	Message string `json:"message,omitempty"`
+	// LastTransitionTime is the time the condition last transitioned from one status to another
+	LastTransitionTime *metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// ApplicationSetConditionType represents the type of an ApplicationSet condition
+type ApplicationSetConditionType string
+
+const (
+	// ApplicationSetConditionErrorOccurred indicates that the last reconciliation of the ApplicationSet failed
+	ApplicationSetConditionErrorOccurred ApplicationSetConditionType = "ErrorOccurred"
+	// ApplicationSetConditionParametersGenerated indicates whether the generators successfully produced parameters
+	ApplicationSetConditionParametersGenerated ApplicationSetConditionType = "ParametersGenerated"
+	// ApplicationSetConditionResourcesUpToDate indicates whether the generated Applications match the ApplicationSet
+	ApplicationSetConditionResourcesUpToDate ApplicationSetConditionType = "ResourcesUpToDate"
+)
+
+// ApplicationSetConditionStatus is the status of an ApplicationSet condition
+type ApplicationSetConditionStatus string
+
+const (
+	ApplicationSetConditionStatusTrue    ApplicationSetConditionStatus = "True"
+	ApplicationSetConditionStatusFalse   ApplicationSetConditionStatus = "False"
+	ApplicationSetConditionStatusUnknown ApplicationSetConditionStatus = "Unknown"
+)
+
+// Reasons used for the ApplicationSet conditions
+const (
+	ApplicationSetReasonErrorOccurred                        = "ErrorOccurred"
+	ApplicationSetReasonParametersGenerated                  = "ParametersGenerated"
+	ApplicationSetReasonApplicationSetUpToDate               = "ApplicationSetUpToDate"
+	ApplicationSetReasonApplicationGenerationFromParamsError = "ApplicationGenerationFromParamsError"
+	ApplicationSetReasonApplicationValidationError           = "ApplicationValidationError"
+	ApplicationSetReasonUpdateApplicationError               = "UpdateApplicationError"
+	ApplicationSetReasonDeleteApplicationError               = "DeleteApplicationError"
+	ApplicationSetReasonRolloutProgressing                   = "RolloutProgressing"
+	ApplicationSetReasonRolloutStalled                       = "RolloutStalled"
+)
+
+// ApplicationSetApplicationStatus contains the status of a single Application generated by the ApplicationSet
+type ApplicationSetApplicationStatus struct {
+	// Application is the name of the generated Application
+	Application string `json:"application"`
+	// Action is the last action the controller took on the Application: created, updated or unchanged, or waiting
+	// when the Application is held back by a step of the rolling sync strategy
+	Action string `json:"action"`
+	// Message contains details about the last action, such as the error that prevented it
+	Message string `json:"message,omitempty"`
+	// LastTransitionTime is the time the Action last changed
+	LastTransitionTime *metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// ApplicationSetApplicationActionWaiting is the action of the Applications that are held back, neither created nor
+// updated, until the previous steps of the rolling sync strategy are completed
+const ApplicationSetApplicationActionWaiting = "waiting"
+
+// ApplicationSetRolloutStepStatus contains the progress of a single step of the rolling sync strategy
+type ApplicationSetRolloutStepStatus struct {
+	// Step is the position of the step in the rolling sync strategy, starting at 1
+	Step int `json:"step"`
+	// Applications are the names of the generated Applications that belong to the step
+	Applications []string `json:"applications,omitempty"`
+	// Status of the step, one of Waiting, Progressing, Stalled or Completed
+	Status ApplicationSetRolloutStepStatusCode `json:"status"`
+	// Message contains human-readable message indicating details about the step
+	Message string `json:"message,omitempty"`
+	// LastTransitionTime is the time the Status last changed
+	LastTransitionTime *metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// ApplicationSetRolloutStepStatusCode is the status of a step of the rolling sync strategy
+type ApplicationSetRolloutStepStatusCode string
+
+const (
+	// ApplicationSetRolloutStepWaiting indicates that the step waits for the previous steps to complete
+	ApplicationSetRolloutStepWaiting ApplicationSetRolloutStepStatusCode = "Waiting"
+	// ApplicationSetRolloutStepProgressing indicates that the Applications of the step are being rolled out
+	ApplicationSetRolloutStepProgressing ApplicationSetRolloutStepStatusCode = "Progressing"
+	// ApplicationSetRolloutStepStalled indicates that Applications of the step without an automated sync policy are
+	// not Synced, so that the step cannot complete until they are synced
+	ApplicationSetRolloutStepStalled ApplicationSetRolloutStepStatusCode = "Stalled"
+	// ApplicationSetRolloutStepCompleted indicates that all the Applications of the step are Healthy and Synced
+	ApplicationSetRolloutStepCompleted ApplicationSetRolloutStepStatusCode = "Completed"
+)
+
+// SetCondition adds or replaces the condition of the same type. The LastTransitionTime is only
+// updated when the status of the condition changes.
+func (status *ApplicationSetStatus) SetCondition(condition ApplicationSetCondition) {
+	now := metav1.Now()
+	for i := range status.Conditions {
+		existing := &status.Conditions[i]
+		if existing.Type != condition.Type {
+			continue
+		}
+		if existing.Status != condition.Status || existing.LastTransitionTime == nil {
+			existing.LastTransitionTime = &now
+		}
+		existing.Status = condition.Status
+		existing.Reason = condition.Reason
+		existing.Message = condition.Message
+		return
+	}
+	if condition.LastTransitionTime == nil {
+		condition.LastTransitionTime = &now
+	}
+	status.Conditions = append(status.Conditions, condition)
+}
+
+// GetCondition returns the condition of the given type, or nil if it is not set
+func (status *ApplicationSetStatus) GetCondition(conditionType ApplicationSetConditionType) *ApplicationSetCondition {
+	for i := range status.Conditions {
+		if status.Conditions[i].Type == conditionType {
+			return &status.Conditions[i]
+		}
+	}
+	return nil
 }
 
 // ApplicationSetList contains a list of ApplicationSet
@@ -0,0 +1,110 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The appset command runs the ApplicationSet generators and templates locally, without a cluster.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const usage = `Usage: appset <command> [flags]
+
+Commands:
+  render    Render ApplicationSets into the Applications the controller would generate
+`
+
+// stringSliceFlag is a flag that may be repeated, or given a comma-separated list of values.
+type stringSliceFlag []string
+
+func (s *stringSliceFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringSliceFlag) Set(value string) error {
+	*s = append(*s, strings.Split(value, ",")...)
+	return nil
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "render":
+		if err := runRender(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(os.Stdout, usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+}
+
+func runRender(args []string) error {
+	var opts renderOptions
+	var checkouts stringSliceFlag
+	var logLevel string
+
+	flags := flag.NewFlagSet("render", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), "Usage: appset render -f <applicationset.yaml> [flags]\n\n")
+		flags.PrintDefaults()
+	}
+	flags.Var((*stringSliceFlag)(&opts.appSetFiles), "f", "ApplicationSet manifest to render, may be repeated. Each file may contain several YAML documents. Use '-' to read from stdin")
+	flags.Var((*stringSliceFlag)(&opts.clusterSecretPaths), "cluster-secrets", "Argo CD cluster Secret manifests, or directories of them, used by the cluster generator in place of the cluster, may be repeated")
+	flags.Var(&checkouts, "git-checkout", "Local checkout of a repository used by the git generator in place of the repo server, as <repoURL>=<directory>, may be repeated")
+	flags.StringVar(&opts.namespace, "namespace", "argocd", "Argo CD namespace, used for the cluster Secrets and the ApplicationSets which do not set one")
+	flags.StringVar(&logLevel, "loglevel", "warn", "Set the logging level. One of: debug|info|warn|error")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if len(opts.appSetFiles) == 0 {
+		flags.Usage()
+		return fmt.Errorf("at least one ApplicationSet manifest must be specified with -f")
+	}
+
+	level, err := log.ParseLevel(logLevel)
+	if err != nil {
+		return fmt.Errorf("unable to parse loglevel %q: %v", logLevel, err)
+	}
+	// Logs are written to stderr, so that stdout only contains the rendered Applications
+	log.SetOutput(os.Stderr)
+	log.SetLevel(level)
+
+	opts.checkouts = map[string]string{}
+	for _, checkout := range checkouts {
+		parts := strings.SplitN(checkout, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("invalid git checkout %q, expected <repoURL>=<directory>", checkout)
+		}
+		opts.checkouts[parts[0]] = parts[1]
+	}
+
+	return render(context.Background(), opts, os.Stdout)
+}
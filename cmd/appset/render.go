@@ -0,0 +1,314 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	argov1alpha1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+
+	argoprojiov1alpha1 "github.com/argoproj-labs/applicationset/api/v1alpha1"
+	"github.com/argoproj-labs/applicationset/pkg/controllers"
+	"github.com/argoproj-labs/applicationset/pkg/generators"
+	"github.com/argoproj-labs/applicationset/pkg/services"
+	"github.com/argoproj-labs/applicationset/pkg/utils"
+	"github.com/argoproj-labs/applicationset/pkg/validation"
+)
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	_ = clientgoscheme.AddToScheme(scheme)
+
+	_ = argoprojiov1alpha1.AddToScheme(scheme)
+
+	_ = argov1alpha1.AddToScheme(scheme)
+}
+
+type renderOptions struct {
+	// appSetFiles are the files containing the ApplicationSets to render, '-' being stdin
+	appSetFiles []string
+	// clusterSecretPaths are the files, or directories of files, containing the Argo CD cluster Secrets
+	clusterSecretPaths []string
+	// checkouts maps repository URLs to the directories they are checked out in
+	checkouts map[string]string
+	// namespace is the Argo CD namespace
+	namespace string
+}
+
+// render renders the ApplicationSets of the options into Applications, and writes them to out as YAML documents. The
+// cluster generator is backed by the cluster Secrets of the options, and the git generator by the local checkouts;
+// the generators which require access to other services are not supported.
+func render(ctx context.Context, opts renderOptions, out io.Writer) error {
+	appSets, err := loadApplicationSets(opts.appSetFiles, opts.namespace)
+	if err != nil {
+		return err
+	}
+
+	clusterSecrets, err := loadClusterSecrets(opts.clusterSecretPaths, opts.namespace)
+	if err != nil {
+		return err
+	}
+
+	allGenerators, err := newGenerators(ctx, opts, clusterSecrets)
+	if err != nil {
+		return err
+	}
+
+	applications := []argov1alpha1.Application{}
+	for _, appSet := range appSets {
+		if errs := validation.ValidateApplicationSet(&appSet); len(errs) > 0 {
+			return fmt.Errorf("ApplicationSet %s is invalid: %v", appSet.Name, errs.ToAggregate())
+		}
+
+		if err := checkSupportedGenerators(appSet); err != nil {
+			return fmt.Errorf("unable to render ApplicationSet %s: %v", appSet.Name, err)
+		}
+
+		generated, err := controllers.GenerateApplications(appSet, allGenerators, &utils.Render{})
+		if err != nil {
+			return fmt.Errorf("unable to render ApplicationSet %s: %v", appSet.Name, err)
+		}
+
+		// As with the controller, the Applications are created in the namespace of their ApplicationSet
+		for i := range generated {
+			generated[i].Namespace = appSet.Namespace
+		}
+		applications = append(applications, generated...)
+	}
+
+	sort.SliceStable(applications, func(i, j int) bool {
+		if applications[i].Namespace != applications[j].Namespace {
+			return applications[i].Namespace < applications[j].Namespace
+		}
+		return applications[i].Name < applications[j].Name
+	})
+
+	return writeApplications(out, applications)
+}
+
+func newGenerators(ctx context.Context, opts renderOptions, clusterSecrets []corev1.Secret) (map[string]generators.Generator, error) {
+	var clientObjects []client.Object
+	var clientsetObjects []runtime.Object
+	for i := range clusterSecrets {
+		clientObjects = append(clientObjects, &clusterSecrets[i])
+		clientsetObjects = append(clientsetObjects, &clusterSecrets[i])
+	}
+
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(clientObjects...).Build()
+	clientset := kubefake.NewSimpleClientset(clientsetObjects...)
+
+	// The clusters are not probed offline: their server versions and connection states are unknown
+	baseGenerators := map[string]generators.Generator{
+		"List":     generators.NewListGenerator(),
+		"Clusters": generators.NewClusterGenerator(k8sClient, ctx, clientset, opts.namespace, nil),
+		"Git":      generators.NewGitGenerator(services.NewLocalRepos(opts.checkouts)),
+	}
+
+	combineGenerators := map[string]generators.Generator{
+		"Matrix": generators.NewMatrixGenerator(baseGenerators),
+		"Merge":  generators.NewMergeGenerator(baseGenerators),
+	}
+
+	return generators.CombineMaps(baseGenerators, combineGenerators)
+}
+
+// checkSupportedGenerators returns an error if the ApplicationSet uses a generator which cannot run offline. The
+// controller ignores the generators it does not know, so without this check they would silently produce nothing.
+func checkSupportedGenerators(appSet argoprojiov1alpha1.ApplicationSet) error {
+	for _, generator := range appSet.Spec.Generators {
+		children := []argoprojiov1alpha1.ApplicationSetBaseGenerator{{
+			SCMProvider:             generator.SCMProvider,
+			ClusterDecisionResource: generator.ClusterDecisionResource,
+			PullRequest:             generator.PullRequest,
+		}}
+		if generator.Matrix != nil {
+			children = append(children, generator.Matrix.Generators...)
+		}
+		if generator.Merge != nil {
+			children = append(children, generator.Merge.Generators...)
+		}
+
+		for _, child := range children {
+			switch {
+			case child.SCMProvider != nil:
+				return fmt.Errorf("the scmProvider generator is not supported offline")
+			case child.ClusterDecisionResource != nil:
+				return fmt.Errorf("the clusterDecisionResource generator is not supported offline")
+			case child.PullRequest != nil:
+				return fmt.Errorf("the pullRequest generator is not supported offline")
+			}
+		}
+	}
+
+	return nil
+}
+
+func loadApplicationSets(paths []string, namespace string) ([]argoprojiov1alpha1.ApplicationSet, error) {
+	var appSets []argoprojiov1alpha1.ApplicationSet
+
+	for _, path := range paths {
+		docs, err := readYAMLDocuments(path)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, doc := range docs {
+			appSet := argoprojiov1alpha1.ApplicationSet{}
+			if err := yaml.UnmarshalStrict(doc, &appSet); err != nil {
+				return nil, fmt.Errorf("unable to parse ApplicationSet in %s: %v", path, err)
+			}
+			if appSet.Kind != "ApplicationSet" {
+				return nil, fmt.Errorf("unexpected kind %q in %s, only ApplicationSets can be rendered", appSet.Kind, path)
+			}
+			if appSet.Namespace == "" {
+				appSet.Namespace = namespace
+			}
+			appSets = append(appSets, appSet)
+		}
+	}
+
+	return appSets, nil
+}
+
+// loadClusterSecrets loads the Secrets of the files, and of the files of the directories, of the paths into the Argo CD
+// namespace. As the API server would, it merges the stringData of the Secrets into their data.
+func loadClusterSecrets(paths []string, namespace string) ([]corev1.Secret, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if ext := filepath.Ext(entry.Name()); !entry.IsDir() && (ext == ".yaml" || ext == ".yml" || ext == ".json") {
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+		}
+	}
+
+	var secrets []corev1.Secret
+	for _, file := range files {
+		docs, err := readYAMLDocuments(file)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, doc := range docs {
+			secret := corev1.Secret{}
+			if err := yaml.Unmarshal(doc, &secret); err != nil {
+				return nil, fmt.Errorf("unable to parse Secret in %s: %v", file, err)
+			}
+			if secret.Kind != "Secret" {
+				return nil, fmt.Errorf("unexpected kind %q in %s, only Secrets can be used as cluster secrets", secret.Kind, file)
+			}
+
+			if secret.Data == nil {
+				secret.Data = map[string][]byte{}
+			}
+			for key, value := range secret.StringData {
+				secret.Data[key] = []byte(value)
+			}
+			secret.StringData = nil
+			secret.Namespace = namespace
+
+			secrets = append(secrets, secret)
+		}
+	}
+
+	return secrets, nil
+}
+
+// readYAMLDocuments returns the non-empty YAML documents of the file, '-' being stdin.
+func readYAMLDocuments(path string) ([][]byte, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var docs [][]byte
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %s: %v", path, err)
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+		docs = append(docs, doc)
+	}
+
+	return docs, nil
+}
+
+// writeApplications writes the Applications as YAML documents, leaving out the fields that are set by the API server or
+// by Argo CD, so that the output only changes along with the generated Applications.
+func writeApplications(out io.Writer, applications []argov1alpha1.Application) error {
+	for i, app := range applications {
+		app.TypeMeta.Kind = "Application"
+		app.TypeMeta.APIVersion = "argoproj.io/v1alpha1"
+
+		data, err := json.Marshal(app)
+		if err != nil {
+			return err
+		}
+
+		obj := map[string]interface{}{}
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return err
+		}
+		delete(obj, "status")
+		if metadata, ok := obj["metadata"].(map[string]interface{}); ok {
+			delete(metadata, "creationTimestamp")
+		}
+
+		data, err = yaml.Marshal(obj)
+		if err != nil {
+			return err
+		}
+
+		if i > 0 {
+			if _, err := io.WriteString(out, "---\n"); err != nil {
+				return err
+			}
+		}
+		if _, err := out.Write(data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
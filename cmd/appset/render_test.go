@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender(t *testing.T) {
+	opts := renderOptions{
+		appSetFiles:        []string{filepath.Join("testdata", "appset.yaml")},
+		clusterSecretPaths: []string{filepath.Join("testdata", "clusters")},
+		checkouts:          map[string]string{"https://github.com/argoproj/argocd-example-apps.git": filepath.Join("testdata", "repo")},
+		namespace:          "argocd",
+	}
+
+	out := &bytes.Buffer{}
+	err := render(context.Background(), opts, out)
+	assert.NoError(t, err)
+
+	expected := `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  finalizers:
+  - resources-finalizer.argocd.argoproj.io
+  labels:
+    cluster: engineering-dev
+  name: engineering-dev-guestbook
+  namespace: apps
+spec:
+  destination:
+    namespace: guestbook
+    server: https://1.2.3.4
+  project: default
+  source:
+    path: guestbook
+    repoURL: https://github.com/argoproj/argocd-example-apps.git
+    targetRevision: HEAD
+---
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  finalizers:
+  - resources-finalizer.argocd.argoproj.io
+  name: guestbook-staging
+  namespace: argocd
+spec:
+  destination:
+    namespace: guestbook
+    server: https://staging.example.com
+  project: default
+  source:
+    path: apps/guestbook
+    repoURL: https://github.com/argoproj/argocd-example-apps.git
+    targetRevision: HEAD
+---
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  finalizers:
+  - resources-finalizer.argocd.argoproj.io
+  name: helm-guestbook-staging
+  namespace: argocd
+spec:
+  destination:
+    namespace: helm-guestbook
+    server: https://staging.example.com
+  project: default
+  source:
+    path: apps/helm-guestbook
+    repoURL: https://github.com/argoproj/argocd-example-apps.git
+    targetRevision: HEAD
+`
+	assert.Equal(t, expected, out.String())
+}
+
+func TestRenderErrors(t *testing.T) {
+	for _, c := range []struct {
+		name          string
+		appSet        string
+		expectedError string
+	}{
+		{
+			name: "unsupported generator",
+			appSet: `apiVersion: argoproj.io/v1alpha1
+kind: ApplicationSet
+metadata:
+  name: scm-apps
+spec:
+  generators:
+  - matrix:
+      generators:
+      - list:
+          elements:
+          - cluster: engineering-dev
+      - scmProvider:
+          github:
+            organization: argoproj
+  template:
+    metadata:
+      name: '{{repository}}'
+    spec:
+      project: default
+      source:
+        repoURL: '{{url}}'
+      destination:
+        server: https://kubernetes.default.svc
+`,
+			expectedError: "unable to render ApplicationSet scm-apps: the scmProvider generator is not supported offline",
+		},
+		{
+			name: "missing checkout",
+			appSet: `apiVersion: argoproj.io/v1alpha1
+kind: ApplicationSet
+metadata:
+  name: git-apps
+spec:
+  generators:
+  - git:
+      repoURL: https://github.com/argoproj/other.git
+      revision: HEAD
+      directories:
+      - path: '*'
+  template:
+    metadata:
+      name: '{{path.basename}}'
+    spec:
+      project: default
+      source:
+        repoURL: https://github.com/argoproj/other.git
+        path: '{{path}}'
+      destination:
+        server: https://kubernetes.default.svc
+`,
+			expectedError: "unable to render ApplicationSet git-apps: no local checkout was provided for repository https://github.com/argoproj/other.git",
+		},
+		{
+			name: "not an ApplicationSet",
+			appSet: `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: guestbook
+`,
+			expectedError: `unexpected kind "Application"`,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			appSetFile := filepath.Join(t.TempDir(), "appset.yaml")
+			err := os.WriteFile(appSetFile, []byte(c.appSet), 0644)
+			assert.NoError(t, err)
+
+			err = render(context.Background(), renderOptions{appSetFiles: []string{appSetFile}, namespace: "argocd"}, &bytes.Buffer{})
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), c.expectedError)
+			}
+		})
+	}
+}
@@ -3,6 +3,8 @@ module github.com/argoproj-labs/applicationset
 go 1.16
 
 require (
+	github.com/BurntSushi/toml v0.3.1
+	github.com/Masterminds/semver v1.5.0
 	github.com/argoproj/argo-cd/v2 v2.0.3
 	github.com/argoproj/gitops-engine v0.3.2
 	github.com/argoproj/pkg v0.2.0
@@ -10,7 +12,7 @@ require (
 	github.com/golang/mock v1.5.0 // indirect
 	github.com/google/go-github/v35 v35.0.0
 	github.com/imdario/mergo v0.3.12
-	github.com/jeremywohl/flatten v1.0.1
+	github.com/itchyny/gojq v0.12.7
 	github.com/pkg/errors v0.9.1
 	github.com/sirupsen/logrus v1.7.0
 	github.com/spf13/cobra v1.1.3 // indirect
@@ -19,6 +21,7 @@ require (
 	github.com/xanzy/go-gitlab v0.50.0
 	golang.org/x/oauth2 v0.0.0-20210413134643-5e61552d6c78
 	golang.org/x/sync v0.0.0-20210220032951-036812b2e83c // indirect
+	golang.org/x/text v0.3.6
 	k8s.io/api v0.21.1
 	k8s.io/apiextensions-apiserver v0.21.1
 	k8s.io/apimachinery v0.21.1
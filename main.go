@@ -30,6 +30,8 @@ import (
 	"github.com/argoproj-labs/applicationset/pkg/generators"
 	"github.com/argoproj-labs/applicationset/pkg/services"
 	"github.com/argoproj-labs/applicationset/pkg/utils"
+	"github.com/argoproj-labs/applicationset/pkg/validation"
+	"github.com/argoproj-labs/applicationset/pkg/webhook"
 
 	"github.com/argoproj-labs/applicationset/common"
 	argov1alpha1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
@@ -69,10 +71,15 @@ func main() {
 	var enableLeaderElection bool
 	var namespace string
 	var argocdRepoServer string
+	var useRepoServer bool
 	var policy string
 	var debugLog bool
 	var dryRun bool
 	var logLevel string
+	var enableWebhook bool
+	var webhookCertDir string
+	var gitWebhookAddr string
+	var clusterInfoRefreshSeconds int
 
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeBindAddr, "probe-addr", ":8081", "The address the probe endpoint binds to.")
@@ -81,10 +88,15 @@ func main() {
 			"Enabling this will ensure there is only one active controller manager.")
 	flag.StringVar(&namespace, "namespace", "", "Argo CD repo namespace (default: argocd)")
 	flag.StringVar(&argocdRepoServer, "argocd-repo-server", "argocd-repo-server:8081", "Argo CD repo server address")
-	flag.StringVar(&policy, "policy", "sync", "Modify how application is synced between the generator and the cluster. Default is 'sync' (create & update & delete), options: 'create-only', 'create-update' (no deletion)")
+	flag.BoolVar(&useRepoServer, "use-argocd-repo-server", false, "Read the repositories of the Git generators with the Argo CD repo server, where it returns the same results as local checkouts. The repo server of the supported Argo CD version lists neither all the directories nor the files of repositories, so they are still read from local checkouts")
+	flag.StringVar(&policy, "policy", "sync", "Modify how application is synced between the generator and the cluster. Default is 'sync' (create & update & delete), options: 'create-only', 'create-update' (no deletion). ApplicationSets may set a more restrictive policy in their syncPolicy, but cannot exceed this one")
 	flag.BoolVar(&debugLog, "debug", false, "Print debug logs. Takes precedence over loglevel")
 	flag.StringVar(&logLevel, "loglevel", "info", "Set the logging level. One of: debug|info|warn|error")
 	flag.BoolVar(&dryRun, "dry-run", false, "Enable dry run mode")
+	flag.BoolVar(&enableWebhook, "enable-webhook", false, "Serve the validating admission webhook for ApplicationSets")
+	flag.StringVar(&webhookCertDir, "webhook-cert-dir", "", "Directory containing the tls.crt and tls.key files of the webhook server (default: <temp-dir>/k8s-webhook-server/serving-certs)")
+	flag.StringVar(&gitWebhookAddr, "git-webhook-addr", "", "The address the Git webhook receiver binds to, e.g. ':7000', which refreshes ApplicationSets on push and pull request events. It requires a webhook secret in the argocd-secret Secret, and is disabled by default")
+	flag.IntVar(&clusterInfoRefreshSeconds, "cluster-info-refresh-seconds", 180, "How often the Cluster generator probes the API servers of clusters for their version and connection state, in seconds")
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
@@ -123,6 +135,7 @@ func main() {
 		NewCache:               cache.MultiNamespacedCacheBuilder([]string{namespace}),
 		HealthProbeBindAddress: probeBindAddr,
 		Port:                   9443,
+		CertDir:                webhookCertDir,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "58ac56fa.applicationsets.argoproj.io",
 		DryRunClient:           dryRun,
@@ -139,16 +152,25 @@ func main() {
 
 	argoCDDB := db.NewDB(namespace, argoSettingsMgr, k8s)
 
+	repos := services.NewArgoCDService(argoCDDB, argocdRepoServer)
+	if useRepoServer {
+		repos = services.NewRepoServerService(argoCDDB)
+	}
+
+	clusterInfo := utils.NewClusterInfoCache(time.Duration(clusterInfoRefreshSeconds)*time.Second, utils.NewServerVersionProbe(k8s))
+
 	baseGenerators := map[string]generators.Generator{
 		"List":                    generators.NewListGenerator(),
-		"Clusters":                generators.NewClusterGenerator(mgr.GetClient(), context.Background(), k8s, namespace),
-		"Git":                     generators.NewGitGenerator(services.NewArgoCDService(argoCDDB, argocdRepoServer)),
+		"Clusters":                generators.NewClusterGenerator(mgr.GetClient(), context.Background(), k8s, namespace, clusterInfo),
+		"Git":                     generators.NewGitGenerator(repos),
 		"SCMProvider":             generators.NewSCMProviderGenerator(mgr.GetClient()),
 		"ClusterDecisionResource": generators.NewDuckTypeGenerator(context.Background(), dynClient, k8s, namespace),
+		"PullRequest":             generators.NewPullRequestGenerator(mgr.GetClient()),
 	}
 
 	combineGenerators := map[string]generators.Generator{
 		"Matrix": generators.NewMatrixGenerator(baseGenerators),
+		"Merge":  generators.NewMergeGenerator(baseGenerators),
 	}
 
 	all, err := generators.CombineMaps(baseGenerators, combineGenerators)
@@ -168,11 +190,36 @@ func main() {
 		ArgoAppClientset: appSetConfig,
 		KubeClientset:    k8s,
 		ArgoDB:           argoCDDB,
+		ClusterInfo:      clusterInfo,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ApplicationSet")
 		os.Exit(1)
 	}
 
+	if enableWebhook {
+		validation.SetupWebhookWithManager(mgr)
+	}
+
+	if gitWebhookAddr != "" {
+		// Without a secret, anyone reaching the receiver could trigger the refresh of every ApplicationSet
+		settings, err := argoSettingsMgr.GetSettings()
+		if err != nil {
+			setupLog.Error(err, "unable to read the webhook secrets of the Argo CD settings")
+			os.Exit(1)
+		}
+		if !webhook.SecretsConfigured(settings) {
+			setupLog.Error(fmt.Errorf("no webhook secret is configured in the argocd-secret Secret"), "refusing to start the Git webhook server")
+			os.Exit(1)
+		}
+		if err := mgr.Add(&webhook.Server{
+			Addr:    gitWebhookAddr,
+			Handler: webhook.NewWebhookHandler(namespace, argoSettingsMgr, mgr.GetClient()),
+		}); err != nil {
+			setupLog.Error(err, "unable to add the Git webhook server")
+			os.Exit(1)
+		}
+	}
+
 	stats.StartStatsTicker(10 * time.Minute)
 
 	// +kubebuilder:scaffold:builder
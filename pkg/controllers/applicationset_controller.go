@@ -17,6 +17,7 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/argoproj-labs/applicationset/pkg/generators"
@@ -26,11 +27,15 @@ import (
 	"github.com/argoproj/argo-cd/v2/util/db"
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	log "github.com/sirupsen/logrus"
@@ -62,6 +67,9 @@ type ApplicationSetReconciler struct {
 	ArgoDB           db.ArgoDB
 	ArgoAppClientset appclientset.Interface
 	KubeClientset    kubernetes.Interface
+	// ClusterInfo is the cache of the server versions and connection states of the clusters used by the cluster
+	// generators, if any
+	ClusterInfo *utils.ClusterInfoCache
 	utils.Policy
 	utils.Renderer
 }
@@ -69,7 +77,7 @@ type ApplicationSetReconciler struct {
 // +kubebuilder:rbac:groups=argoproj.io,resources=applicationsets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=argoproj.io,resources=applicationsets/status,verbs=get;update;patch
 
-func (r *ApplicationSetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *ApplicationSetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, retErr error) {
 	_ = r.Log.WithValues("applicationset", req.NamespacedName)
 	_ = log.WithField("applicationset", req.NamespacedName)
 
@@ -86,12 +94,29 @@ func (r *ApplicationSetReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		return ctrl.Result{}, nil
 	}
 
+	// The refresh requested when the reconciliation started, which is the only one that may be cleared once it ends.
+	// It is cleared whether the reconciliation succeeds or not, so that an ApplicationSet in error does not keep it
+	// forever: failed reconciliations are retried anyway.
+	if refreshToken, found := applicationSetInfo.Annotations[argoprojiov1alpha1.AnnotationApplicationSetRefresh]; found {
+		defer func() {
+			if err := r.clearRefreshAnnotation(ctx, applicationSetInfo.DeepCopy(), refreshToken); err != nil {
+				log.WithError(err).WithField("appSet", applicationSetInfo.Name).Error("unable to clear the refresh annotation")
+				if retErr == nil {
+					result, retErr = ctrl.Result{}, err
+				}
+			}
+		}()
+	}
+
 	// Log a warning if there are unrecognized generators
 	utils.CheckInvalidGenerators(&applicationSetInfo)
 
 	// desiredApplications is the main list of all expected Applications from all generators in this appset.
 	desiredApplications, err := r.generateApplications(applicationSetInfo)
 	if err != nil {
+		if statusErr := r.setApplicationSetStatusError(ctx, &applicationSetInfo, argoprojiov1alpha1.ApplicationSetReasonApplicationGenerationFromParamsError, err, false); statusErr != nil {
+			log.WithError(statusErr).Error("unable to update ApplicationSet status")
+		}
 		return ctrl.Result{}, err
 	}
 
@@ -108,28 +133,116 @@ func (r *ApplicationSetReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		// successfully reconciled (which is true... it was reconciled to an
 		// error condition).
 		log.Errorf("%s", validateError.Error())
+		if statusErr := r.setApplicationSetStatusError(ctx, &applicationSetInfo, argoprojiov1alpha1.ApplicationSetReasonApplicationValidationError, validateError, true); statusErr != nil {
+			return ctrl.Result{}, statusErr
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// The ignored differences are compiled once per reconciliation, and the desired Applications keep the live values
+	// of their ignored fields before they are rolled out, created or updated
+	ignoreDifferences, err := utils.CompileIgnoreDifferences(applicationSetInfo.Spec.IgnoreApplicationDifferences)
+	if err != nil {
+		// As with validation errors, retrying will not fix invalid ignored differences
+		log.Errorf("%s", err.Error())
+		if statusErr := r.setApplicationSetStatusError(ctx, &applicationSetInfo, argoprojiov1alpha1.ApplicationSetReasonApplicationValidationError, err, true); statusErr != nil {
+			return ctrl.Result{}, statusErr
+		}
+		return ctrl.Result{}, nil
+	}
+	if err := r.keepIgnoredLiveValues(ctx, applicationSetInfo, ignoreDifferences, desiredApplications); err != nil {
+		if statusErr := r.setApplicationSetStatusError(ctx, &applicationSetInfo, argoprojiov1alpha1.ApplicationSetReasonUpdateApplicationError, err, true); statusErr != nil {
+			log.WithError(statusErr).Error("unable to update ApplicationSet status")
+		}
+		return ctrl.Result{}, err
+	}
+
+	var rolloutSteps [][]argov1alpha1.Application
+	policy, err := r.getPolicy(applicationSetInfo)
+	if err == nil {
+		rolloutSteps, err = getRolloutSteps(applicationSetInfo, desiredApplications)
+	}
+	if err != nil {
+		// As with validation errors, retrying will not fix an invalid policy or strategy
+		log.Errorf("%s", err.Error())
+		if statusErr := r.setApplicationSetStatusError(ctx, &applicationSetInfo, argoprojiov1alpha1.ApplicationSetReasonApplicationValidationError, err, true); statusErr != nil {
+			return ctrl.Result{}, statusErr
+		}
 		return ctrl.Result{}, nil
 	}
 
-	if r.Policy.Update() {
-		err = r.createOrUpdateInCluster(ctx, applicationSetInfo, desiredApplications)
+	// With the rolling sync strategy, only the Applications of the steps that are ready to be rolled out are created
+	// or updated. An empty list of step statuses clears the steps of a previous rolling sync.
+	applicationsToSync := desiredApplications
+	var heldAppStatuses []argoprojiov1alpha1.ApplicationSetApplicationStatus
+	stepStatuses := []argoprojiov1alpha1.ApplicationSetRolloutStepStatus{}
+	if rolloutSteps != nil {
+		applicationsToSync, heldAppStatuses, stepStatuses, err = r.rollout(ctx, applicationSetInfo, rolloutSteps, policy.Update())
 		if err != nil {
+			if statusErr := r.setApplicationSetStatusError(ctx, &applicationSetInfo, argoprojiov1alpha1.ApplicationSetReasonUpdateApplicationError, err, true); statusErr != nil {
+				log.WithError(statusErr).Error("unable to update ApplicationSet status")
+			}
 			return ctrl.Result{}, err
 		}
+	}
+
+	var appStatuses []argoprojiov1alpha1.ApplicationSetApplicationStatus
+	if policy.Update() {
+		appStatuses, err = r.createOrUpdateInCluster(ctx, applicationSetInfo, applicationsToSync)
 	} else {
-		err = r.createInCluster(ctx, applicationSetInfo, desiredApplications)
-		if err != nil {
-			return ctrl.Result{}, err
+		appStatuses, err = r.createInCluster(ctx, applicationSetInfo, applicationsToSync)
+	}
+	appStatuses = append(appStatuses, heldAppStatuses...)
+	if err != nil {
+		if statusErr := r.setApplicationSetStatus(ctx, &applicationSetInfo, appStatuses, stepStatuses, errorConditions(argoprojiov1alpha1.ApplicationSetReasonUpdateApplicationError, err, true)...); statusErr != nil {
+			log.WithError(statusErr).Error("unable to update ApplicationSet status")
 		}
+		return ctrl.Result{}, err
 	}
 
-	if r.Policy.Delete() {
+	if policy.Delete() {
 		err = r.deleteInCluster(ctx, applicationSetInfo, desiredApplications)
 		if err != nil {
+			if statusErr := r.setApplicationSetStatus(ctx, &applicationSetInfo, appStatuses, stepStatuses, errorConditions(argoprojiov1alpha1.ApplicationSetReasonDeleteApplicationError, err, true)...); statusErr != nil {
+				log.WithError(statusErr).Error("unable to update ApplicationSet status")
+			}
 			return ctrl.Result{}, err
 		}
 	}
 
+	upToDateCondition := argoprojiov1alpha1.ApplicationSetCondition{
+		Type:    argoprojiov1alpha1.ApplicationSetConditionResourcesUpToDate,
+		Status:  argoprojiov1alpha1.ApplicationSetConditionStatusTrue,
+		Reason:  argoprojiov1alpha1.ApplicationSetReasonApplicationSetUpToDate,
+		Message: "All applications have been generated successfully",
+	}
+	if !isRolloutCompleted(stepStatuses) {
+		upToDateCondition.Status = argoprojiov1alpha1.ApplicationSetConditionStatusFalse
+		upToDateCondition.Reason = argoprojiov1alpha1.ApplicationSetReasonRolloutProgressing
+		upToDateCondition.Message = "Rolling sync is in progress, see the status of the rollout steps"
+		if stalled := stalledRolloutStep(stepStatuses); stalled != nil {
+			upToDateCondition.Reason = argoprojiov1alpha1.ApplicationSetReasonRolloutStalled
+			upToDateCondition.Message = fmt.Sprintf("Rolling sync is stalled at step %d: %s", stalled.Step, stalled.Message)
+		}
+	}
+
+	if err := r.setApplicationSetStatus(ctx, &applicationSetInfo, appStatuses, stepStatuses,
+		argoprojiov1alpha1.ApplicationSetCondition{
+			Type:    argoprojiov1alpha1.ApplicationSetConditionErrorOccurred,
+			Status:  argoprojiov1alpha1.ApplicationSetConditionStatusFalse,
+			Reason:  argoprojiov1alpha1.ApplicationSetReasonApplicationSetUpToDate,
+			Message: "All applications have been generated successfully",
+		},
+		argoprojiov1alpha1.ApplicationSetCondition{
+			Type:    argoprojiov1alpha1.ApplicationSetConditionParametersGenerated,
+			Status:  argoprojiov1alpha1.ApplicationSetConditionStatusTrue,
+			Reason:  argoprojiov1alpha1.ApplicationSetReasonParametersGenerated,
+			Message: "Successfully generated parameters for all Applications",
+		},
+		upToDateCondition); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	requeueAfter := r.getMinRequeueAfter(&applicationSetInfo)
 	log.WithField("requeueAfter", requeueAfter).Info("end reconcile")
 
@@ -138,6 +251,124 @@ func (r *ApplicationSetReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 	}, nil
 }
 
+// clearRefreshAnnotation removes the annotation requesting the refresh of the ApplicationSet, now that it has been
+// reconciled, unless a new refresh, identified by a different token, has been requested in the meantime. The patch
+// fails with a conflict if the ApplicationSet is modified concurrently, so that a refresh is never lost.
+func (r *ApplicationSetReconciler) clearRefreshAnnotation(ctx context.Context, applicationSet *argoprojiov1alpha1.ApplicationSet, refreshToken string) error {
+	if err := r.Get(ctx, client.ObjectKeyFromObject(applicationSet), applicationSet); err != nil {
+		return fmt.Errorf("unable to get the ApplicationSet to remove the refresh annotation: %v", err)
+	}
+	token, found := applicationSet.Annotations[argoprojiov1alpha1.AnnotationApplicationSetRefresh]
+	if !found {
+		return nil
+	}
+	if token != refreshToken {
+		log.WithField("appSet", applicationSet.Name).Info("a new refresh was requested during the reconciliation")
+		return nil
+	}
+
+	patch := client.MergeFromWithOptions(applicationSet.DeepCopy(), client.MergeFromWithOptimisticLock{})
+	delete(applicationSet.Annotations, argoprojiov1alpha1.AnnotationApplicationSetRefresh)
+	if err := r.Client.Patch(ctx, applicationSet, patch); err != nil {
+		return fmt.Errorf("unable to remove the refresh annotation: %v", err)
+	}
+	log.WithField("appSet", applicationSet.Name).Info("refreshed ApplicationSet")
+	return nil
+}
+
+// errorConditions returns the conditions describing a failed reconciliation. parametersGenerated indicates whether
+// the failure happened after the generators successfully produced their parameters.
+func errorConditions(reason string, err error, parametersGenerated bool) []argoprojiov1alpha1.ApplicationSetCondition {
+	paramsCondition := argoprojiov1alpha1.ApplicationSetCondition{
+		Type:    argoprojiov1alpha1.ApplicationSetConditionParametersGenerated,
+		Status:  argoprojiov1alpha1.ApplicationSetConditionStatusTrue,
+		Reason:  argoprojiov1alpha1.ApplicationSetReasonParametersGenerated,
+		Message: "Successfully generated parameters for all Applications",
+	}
+	if !parametersGenerated {
+		paramsCondition.Status = argoprojiov1alpha1.ApplicationSetConditionStatusFalse
+		paramsCondition.Reason = reason
+		paramsCondition.Message = err.Error()
+	}
+
+	return []argoprojiov1alpha1.ApplicationSetCondition{
+		{
+			Type:    argoprojiov1alpha1.ApplicationSetConditionErrorOccurred,
+			Status:  argoprojiov1alpha1.ApplicationSetConditionStatusTrue,
+			Reason:  reason,
+			Message: err.Error(),
+		},
+		paramsCondition,
+		{
+			Type:    argoprojiov1alpha1.ApplicationSetConditionResourcesUpToDate,
+			Status:  argoprojiov1alpha1.ApplicationSetConditionStatusFalse,
+			Reason:  reason,
+			Message: err.Error(),
+		},
+	}
+}
+
+// setApplicationSetStatusError records err in the ApplicationSet status, leaving the list of Applications untouched.
+func (r *ApplicationSetReconciler) setApplicationSetStatusError(ctx context.Context, applicationSet *argoprojiov1alpha1.ApplicationSet, reason string, err error, parametersGenerated bool) error {
+	return r.setApplicationSetStatus(ctx, applicationSet, nil, nil, errorConditions(reason, err, parametersGenerated)...)
+}
+
+// setApplicationSetStatus sets the given conditions, Application statuses and rollout step statuses on the
+// ApplicationSet, and writes the status back to the cluster if it has changed. A nil appStatuses or stepStatuses leaves
+// the existing Application or rollout step statuses as is.
+func (r *ApplicationSetReconciler) setApplicationSetStatus(ctx context.Context, applicationSet *argoprojiov1alpha1.ApplicationSet, appStatuses []argoprojiov1alpha1.ApplicationSetApplicationStatus, stepStatuses []argoprojiov1alpha1.ApplicationSetRolloutStepStatus, conditions ...argoprojiov1alpha1.ApplicationSetCondition) error {
+	original := applicationSet.Status.DeepCopy()
+
+	applicationSet.Status.ObservedGeneration = applicationSet.Generation
+	for _, condition := range conditions {
+		applicationSet.Status.SetCondition(condition)
+	}
+	if appStatuses != nil {
+		applicationSet.Status.Applications = mergeApplicationStatuses(original.Applications, appStatuses)
+	}
+	if stepStatuses != nil {
+		applicationSet.Status.RolloutSteps = mergeRolloutStepStatuses(original.RolloutSteps, stepStatuses)
+	}
+
+	if apiequality.Semantic.DeepEqual(original, &applicationSet.Status) {
+		return nil
+	}
+
+	return r.Client.Status().Update(ctx, applicationSet)
+}
+
+// mergeApplicationStatuses returns the statuses of the currently generated Applications. When no action was
+// taken on an Application in this reconciliation, or it is still held back by the same rolling sync step, the
+// previously recorded action is kept.
+func mergeApplicationStatuses(previous []argoprojiov1alpha1.ApplicationSetApplicationStatus, current []argoprojiov1alpha1.ApplicationSetApplicationStatus) []argoprojiov1alpha1.ApplicationSetApplicationStatus {
+	previousByName := map[string]argoprojiov1alpha1.ApplicationSetApplicationStatus{}
+	for _, appStatus := range previous {
+		previousByName[appStatus.Application] = appStatus
+	}
+
+	res := make([]argoprojiov1alpha1.ApplicationSetApplicationStatus, 0, len(current))
+	for _, appStatus := range current {
+		if existing, ok := previousByName[appStatus.Application]; ok &&
+			appStatus.Action == string(controllerutil.OperationResultNone) && appStatus.Message == "" && existing.Message == "" {
+			res = append(res, existing)
+			continue
+		}
+		if existing, ok := previousByName[appStatus.Application]; ok &&
+			appStatus.Action == argoprojiov1alpha1.ApplicationSetApplicationActionWaiting &&
+			existing.Action == appStatus.Action && existing.Message == appStatus.Message {
+			res = append(res, existing)
+			continue
+		}
+		res = append(res, appStatus)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Application < res[j].Application
+	})
+
+	return res
+}
+
 // validateGeneratedApplications uses the Argo CD validation functions to verify the correctness of the
 // generated applications.
 func (r *ApplicationSetReconciler) validateGeneratedApplications(ctx context.Context, desiredApplications []argov1alpha1.Application, applicationSetInfo argoprojiov1alpha1.ApplicationSet, namespace string) (err error) {
@@ -191,7 +422,7 @@ func (r *ApplicationSetReconciler) getMinRequeueAfter(applicationSetInfo *argopr
 		relevantGenerators := generators.GetRelevantGenerators(&requestedGenerator, r.Generators)
 
 		for _, g := range relevantGenerators {
-			t := g.GetRequeueAfter(&requestedGenerator)
+			t := g.GetRequeueAfter(&requestedGenerator, applicationSetInfo)
 
 			if res == 0 {
 				res = t
@@ -204,6 +435,16 @@ func (r *ApplicationSetReconciler) getMinRequeueAfter(applicationSetInfo *argopr
 	return res
 }
 
+// getPolicy returns the policy of the ApplicationSet, bounded by the policy of the controller.
+func (r *ApplicationSetReconciler) getPolicy(applicationSetInfo argoprojiov1alpha1.ApplicationSet) (utils.Policy, error) {
+	var name string
+	if applicationSetInfo.Spec.SyncPolicy != nil {
+		name = applicationSetInfo.Spec.SyncPolicy.Policy
+	}
+
+	return utils.GetBoundedPolicy(name, r.Policy)
+}
+
 func getTempApplication(applicationSetTemplate argoprojiov1alpha1.ApplicationSetTemplate) *argov1alpha1.Application {
 	var tmplApplication argov1alpha1.Application
 	tmplApplication.Annotations = applicationSetTemplate.Annotations
@@ -217,11 +458,17 @@ func getTempApplication(applicationSetTemplate argoprojiov1alpha1.ApplicationSet
 }
 
 func (r *ApplicationSetReconciler) generateApplications(applicationSetInfo argoprojiov1alpha1.ApplicationSet) ([]argov1alpha1.Application, error) {
+	return GenerateApplications(applicationSetInfo, r.Generators, r.Renderer)
+}
+
+// GenerateApplications generates the Applications of the ApplicationSet, by rendering its template with the params of
+// each of its generators. It is shared by the controller and the offline 'appset render' command.
+func GenerateApplications(applicationSetInfo argoprojiov1alpha1.ApplicationSet, allGenerators map[string]generators.Generator, renderer utils.Renderer) ([]argov1alpha1.Application, error) {
 	var res []argov1alpha1.Application
 
 	var firstError error
 	for _, requestedGenerator := range applicationSetInfo.Spec.Generators {
-		t, err := generators.Transform(requestedGenerator, r.Generators, applicationSetInfo.Spec.Template, &applicationSetInfo)
+		t, err := generators.Transform(requestedGenerator, allGenerators, applicationSetInfo.Spec.Template, &applicationSetInfo)
 		if err != nil {
 			log.WithError(err).WithField("generator", requestedGenerator).
 				Error("error generating application from params")
@@ -235,7 +482,10 @@ func (r *ApplicationSetReconciler) generateApplications(applicationSetInfo argop
 			tmplApplication := getTempApplication(a.Template)
 
 			for _, p := range a.Params {
-				app, err := r.Renderer.RenderTemplateParams(tmplApplication, applicationSetInfo.Spec.SyncPolicy, p)
+				app, err := renderer.RenderTemplateParams(tmplApplication, applicationSetInfo.Spec.SyncPolicy, p, applicationSetInfo.Spec.GoTemplate, applicationSetInfo.Spec.GoTemplateOptions)
+				if err == nil && applicationSetInfo.Spec.TemplatePatch != nil {
+					app, err = renderer.RenderTemplatePatch(app, *applicationSetInfo.Spec.TemplatePatch, p, applicationSetInfo.Spec.GoTemplate, applicationSetInfo.Spec.GoTemplateOptions)
+				}
 				if err != nil {
 					log.WithError(err).WithField("params", a.Params).WithField("generator", requestedGenerator).
 						Error("error generating application from params")
@@ -277,23 +527,129 @@ func (r *ApplicationSetReconciler) SetupWithManager(mgr ctrl.Manager) error {
 
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&argoprojiov1alpha1.ApplicationSet{}).
-		Owns(&argov1alpha1.Application{}).
+		Owns(&argov1alpha1.Application{}, builder.WithPredicates(ownedApplicationPredicate(mgr.GetClient()))).
 		Watches(
 			&source.Kind{Type: &corev1.Secret{}},
 			&clusterSecretEventHandler{
-				Client: mgr.GetClient(),
-				Log:    log.WithField("type", "createSecretEventHandler"),
+				Client:      mgr.GetClient(),
+				Log:         log.WithField("type", "createSecretEventHandler"),
+				ClusterInfo: r.ClusterInfo,
 			}).
-		// TODO: also watch Applications and respond on changes if we own them.
 		Complete(r)
 }
 
+// ignoredApplicationAnnotations are the annotations set on Applications by Argo CD and its notifications controller,
+// whose changes do not require the ApplicationSet to be reconciled.
+var ignoredApplicationAnnotations = []string{
+	common.AnnotationKeyRefresh,
+	NotifiedAnnotationKey,
+}
+
+// ownedApplicationPredicate filters the events of the owned Applications, so that the ApplicationSet is reconciled
+// when an Application is created, deleted, or drifts from the generated one, but not when Argo CD updates its status,
+// unless the ApplicationSet rolls out its Applications step by step according to their health and sync statuses.
+func ownedApplicationPredicate(c client.Client) predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldApp, isApp := e.ObjectOld.(*argov1alpha1.Application)
+			if !isApp {
+				return true
+			}
+			newApp, isApp := e.ObjectNew.(*argov1alpha1.Application)
+			if !isApp {
+				return true
+			}
+			if applicationChanged(oldApp, newApp) {
+				return true
+			}
+			return rolloutStatusChanged(oldApp, newApp) && ownerUsesRollingSync(c, newApp)
+		},
+	}
+}
+
+// rolloutStatusChanged returns true if the health or sync status of the Application, which the rolling sync steps
+// wait for, changed.
+func rolloutStatusChanged(oldApp *argov1alpha1.Application, newApp *argov1alpha1.Application) bool {
+	return oldApp.Status.Health.Status != newApp.Status.Health.Status ||
+		oldApp.Status.Sync.Status != newApp.Status.Sync.Status
+}
+
+// ownerUsesRollingSync returns true if the ApplicationSet owning the Application uses the RollingSync strategy, or if
+// it cannot be read.
+func ownerUsesRollingSync(c client.Client, app *argov1alpha1.Application) bool {
+	owner := metav1.GetControllerOf(app)
+	if owner == nil || owner.Kind != "ApplicationSet" {
+		return false
+	}
+
+	var appSet argoprojiov1alpha1.ApplicationSet
+	if err := c.Get(context.Background(), client.ObjectKey{Namespace: app.Namespace, Name: owner.Name}, &appSet); err != nil {
+		if apierr.IsNotFound(err) {
+			return false
+		}
+		log.WithError(err).WithField("appSet", owner.Name).Warn("unable to get the ApplicationSet owning the Application")
+		return true
+	}
+	return appSet.Spec.Strategy != nil && appSet.Spec.Strategy.Type == argoprojiov1alpha1.ApplicationSetStrategyRollingSync
+}
+
+// applicationChanged returns true if the fields of the Application set by the ApplicationSet controller changed,
+// ignoring the status and the annotations set by Argo CD.
+func applicationChanged(oldApp *argov1alpha1.Application, newApp *argov1alpha1.Application) bool {
+	return !apiequality.Semantic.DeepEqual(oldApp.Spec, newApp.Spec) ||
+		!apiequality.Semantic.DeepEqual(oldApp.Labels, newApp.Labels) ||
+		!apiequality.Semantic.DeepEqual(withoutIgnoredAnnotations(oldApp.Annotations), withoutIgnoredAnnotations(newApp.Annotations)) ||
+		!apiequality.Semantic.DeepEqual(oldApp.Finalizers, newApp.Finalizers) ||
+		!apiequality.Semantic.DeepEqual(oldApp.OwnerReferences, newApp.OwnerReferences)
+}
+
+// withoutIgnoredAnnotations returns a copy of the annotations, without the ignoredApplicationAnnotations.
+func withoutIgnoredAnnotations(annotations map[string]string) map[string]string {
+	res := map[string]string{}
+	for key, value := range annotations {
+		res[key] = value
+	}
+	for _, key := range ignoredApplicationAnnotations {
+		delete(res, key)
+	}
+	return res
+}
+
+// keepIgnoredLiveValues sets the fields of the desired Applications whose differences are ignored to their values in
+// the existing Applications, so that they are rolled out and updated as they are.
+func (r *ApplicationSetReconciler) keepIgnoredLiveValues(ctx context.Context, applicationSet argoprojiov1alpha1.ApplicationSet, ignoreDifferences utils.IgnoreDifferences, desiredApplications []argov1alpha1.Application) error {
+	if len(ignoreDifferences) == 0 {
+		return nil
+	}
+
+	current, err := r.getCurrentApplications(ctx, applicationSet)
+	if err != nil {
+		return err
+	}
+
+	currentByName := make(map[string]argov1alpha1.Application, len(current))
+	for _, app := range current {
+		currentByName[app.Name] = app
+	}
+
+	for i := range desiredApplications {
+		if live, exists := currentByName[desiredApplications[i].Name]; exists {
+			if err := ignoreDifferences.Apply(&live, &desiredApplications[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // createOrUpdateInCluster will create / update application resources in the cluster.
 // - For new applications, it will call create
 // - For existing application, it will call update
 // The function also adds owner reference to all applications, and uses it to delete them.
-func (r *ApplicationSetReconciler) createOrUpdateInCluster(ctx context.Context, applicationSet argoprojiov1alpha1.ApplicationSet, desiredApplications []argov1alpha1.Application) error {
+// It returns the action taken on each of the desired applications.
+func (r *ApplicationSetReconciler) createOrUpdateInCluster(ctx context.Context, applicationSet argoprojiov1alpha1.ApplicationSet, desiredApplications []argov1alpha1.Application) ([]argoprojiov1alpha1.ApplicationSetApplicationStatus, error) {
 
+	appStatuses := make([]argoprojiov1alpha1.ApplicationSetApplicationStatus, 0, len(desiredApplications))
 	var firstError error
 	// Creates or updates the application in appList
 	for _, generatedApp := range desiredApplications {
@@ -330,28 +686,41 @@ func (r *ApplicationSetReconciler) createOrUpdateInCluster(ctx context.Context,
 			return controllerutil.SetControllerReference(&applicationSet, found, r.Scheme)
 		})
 
+		now := metav1.Now()
 		if err != nil {
 			appLog.WithError(err).WithField("action", action).Errorf("failed to %s Application", action)
+			appStatuses = append(appStatuses, argoprojiov1alpha1.ApplicationSetApplicationStatus{
+				Application:        generatedApp.Name,
+				Action:             string(action),
+				Message:            err.Error(),
+				LastTransitionTime: &now,
+			})
 			if firstError == nil {
 				firstError = err
 			}
 			continue
 		}
 
+		appStatuses = append(appStatuses, argoprojiov1alpha1.ApplicationSetApplicationStatus{
+			Application:        generatedApp.Name,
+			Action:             string(action),
+			LastTransitionTime: &now,
+		})
 		r.Recorder.Eventf(&applicationSet, corev1.EventTypeNormal, fmt.Sprint(action), "%s Application %q", action, generatedApp.Name)
 		appLog.Logf(log.InfoLevel, "%s Application", action)
 	}
-	return firstError
+	return appStatuses, firstError
 }
 
 // createInCluster will filter from the desiredApplications only the application that needs to be created
 // Then it will call createOrUpdateInCluster to do the actual create
-func (r *ApplicationSetReconciler) createInCluster(ctx context.Context, applicationSet argoprojiov1alpha1.ApplicationSet, desiredApplications []argov1alpha1.Application) error {
+func (r *ApplicationSetReconciler) createInCluster(ctx context.Context, applicationSet argoprojiov1alpha1.ApplicationSet, desiredApplications []argov1alpha1.Application) ([]argoprojiov1alpha1.ApplicationSetApplicationStatus, error) {
 
 	var createApps []argov1alpha1.Application
+	var appStatuses []argoprojiov1alpha1.ApplicationSetApplicationStatus
 	current, err := r.getCurrentApplications(ctx, applicationSet)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	m := make(map[string]bool) // Will holds the app names that are current in the cluster
@@ -366,10 +735,18 @@ func (r *ApplicationSetReconciler) createInCluster(ctx context.Context, applicat
 
 		if !exists {
 			createApps = append(createApps, app)
+		} else {
+			now := metav1.Now()
+			appStatuses = append(appStatuses, argoprojiov1alpha1.ApplicationSetApplicationStatus{
+				Application:        app.Name,
+				Action:             string(controllerutil.OperationResultNone),
+				LastTransitionTime: &now,
+			})
 		}
 	}
 
-	return r.createOrUpdateInCluster(ctx, applicationSet, createApps)
+	createdStatuses, err := r.createOrUpdateInCluster(ctx, applicationSet, createApps)
+	return append(appStatuses, createdStatuses...), err
 }
 
 func (r *ApplicationSetReconciler) getCurrentApplications(_ context.Context, applicationSet argoprojiov1alpha1.ApplicationSet) ([]argov1alpha1.Application, error) {
@@ -13,16 +13,22 @@ import (
 	"github.com/argoproj-labs/applicationset/pkg/utils"
 	"github.com/argoproj/argo-cd/v2/common"
 	argov1alpha1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+	"github.com/argoproj/gitops-engine/pkg/health"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
 	crtclient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 
 	argoprojiov1alpha1 "github.com/argoproj-labs/applicationset/api/v1alpha1"
 	appclientset "github.com/argoproj/argo-cd/v2/pkg/client/clientset/versioned/fake"
@@ -40,23 +46,23 @@ func (g *generatorMock) GetTemplate(appSetGenerator *argoprojiov1alpha1.Applicat
 	return args.Get(0).(*argoprojiov1alpha1.ApplicationSetTemplate)
 }
 
-func (g *generatorMock) GenerateParams(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator, _ *argoprojiov1alpha1.ApplicationSet) ([]map[string]string, error) {
+func (g *generatorMock) GenerateParams(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator, _ *argoprojiov1alpha1.ApplicationSet) ([]map[string]interface{}, error) {
 	args := g.Called(appSetGenerator)
 
-	return args.Get(0).([]map[string]string), args.Error(1)
+	return args.Get(0).([]map[string]interface{}), args.Error(1)
 }
 
 type rendererMock struct {
 	mock.Mock
 }
 
-func (g *generatorMock) GetRequeueAfter(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator) time.Duration {
+func (g *generatorMock) GetRequeueAfter(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator, appSet *argoprojiov1alpha1.ApplicationSet) time.Duration {
 	args := g.Called(appSetGenerator)
 
 	return args.Get(0).(time.Duration)
 }
 
-func (r *rendererMock) RenderTemplateParams(tmpl *argov1alpha1.Application, syncPolicy *argoprojiov1alpha1.ApplicationSetSyncPolicy, params map[string]string) (*argov1alpha1.Application, error) {
+func (r *rendererMock) RenderTemplateParams(tmpl *argov1alpha1.Application, syncPolicy *argoprojiov1alpha1.ApplicationSetSyncPolicy, params map[string]interface{}, useGoTemplate bool, goTemplateOptions []string) (*argov1alpha1.Application, error) {
 	args := r.Called(tmpl, params)
 
 	if args.Error(1) != nil {
@@ -67,6 +73,17 @@ func (r *rendererMock) RenderTemplateParams(tmpl *argov1alpha1.Application, sync
 
 }
 
+func (r *rendererMock) RenderTemplatePatch(app *argov1alpha1.Application, templatePatch string, params map[string]interface{}, useGoTemplate bool, goTemplateOptions []string) (*argov1alpha1.Application, error) {
+	args := r.Called(app, templatePatch, params)
+
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*argov1alpha1.Application), args.Error(1)
+
+}
+
 func TestExtractApplications(t *testing.T) {
 	scheme := runtime.NewScheme()
 	err := argoprojiov1alpha1.AddToScheme(scheme)
@@ -79,7 +96,7 @@ func TestExtractApplications(t *testing.T) {
 
 	for _, c := range []struct {
 		name                string
-		params              []map[string]string
+		params              []map[string]interface{}
 		template            argoprojiov1alpha1.ApplicationSetTemplate
 		generateParamsError error
 		rendererError       error
@@ -87,7 +104,7 @@ func TestExtractApplications(t *testing.T) {
 	}{
 		{
 			name:   "Generate two applications",
-			params: []map[string]string{{"name": "app1"}, {"name": "app2"}},
+			params: []map[string]interface{}{{"name": "app1"}, {"name": "app2"}},
 			template: argoprojiov1alpha1.ApplicationSetTemplate{
 				ApplicationSetTemplateMeta: argoprojiov1alpha1.ApplicationSetTemplateMeta{
 					Name:      "name",
@@ -104,7 +121,7 @@ func TestExtractApplications(t *testing.T) {
 		},
 		{
 			name:   "Handles error from the render",
-			params: []map[string]string{{"name": "app1"}, {"name": "app2"}},
+			params: []map[string]interface{}{{"name": "app1"}, {"name": "app2"}},
 			template: argoprojiov1alpha1.ApplicationSetTemplate{
 				ApplicationSetTemplateMeta: argoprojiov1alpha1.ApplicationSetTemplateMeta{
 					Name:      "name",
@@ -203,7 +220,7 @@ func TestMergeTemplateApplications(t *testing.T) {
 
 	for _, c := range []struct {
 		name             string
-		params           []map[string]string
+		params           []map[string]interface{}
 		template         argoprojiov1alpha1.ApplicationSetTemplate
 		overrideTemplate argoprojiov1alpha1.ApplicationSetTemplate
 		expectedMerged   argoprojiov1alpha1.ApplicationSetTemplate
@@ -211,7 +228,7 @@ func TestMergeTemplateApplications(t *testing.T) {
 	}{
 		{
 			name:   "Generate app",
-			params: []map[string]string{{"name": "app1"}},
+			params: []map[string]interface{}{{"name": "app1"}},
 			template: argoprojiov1alpha1.ApplicationSetTemplate{
 				ApplicationSetTemplateMeta: argoprojiov1alpha1.ApplicationSetTemplateMeta{
 					Name:      "name",
@@ -823,7 +840,7 @@ func TestCreateOrUpdateInCluster(t *testing.T) {
 				Recorder: record.NewFakeRecorder(len(initObjs) + len(c.expected)),
 			}
 
-			err = r.createOrUpdateInCluster(context.TODO(), c.appSet, c.desiredApps)
+			_, err = r.createOrUpdateInCluster(context.TODO(), c.appSet, c.desiredApps)
 			assert.Nil(t, err)
 
 			for _, obj := range c.expected {
@@ -841,6 +858,82 @@ func TestCreateOrUpdateInCluster(t *testing.T) {
 	}
 }
 
+func TestKeepIgnoredLiveValues(t *testing.T) {
+	scheme := runtime.NewScheme()
+	err := argoprojiov1alpha1.AddToScheme(scheme)
+	assert.Nil(t, err)
+
+	err = argov1alpha1.AddToScheme(scheme)
+	assert.Nil(t, err)
+
+	appSet := argoprojiov1alpha1.ApplicationSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "name",
+			Namespace: "namespace",
+		},
+		Spec: argoprojiov1alpha1.ApplicationSetSpec{
+			IgnoreApplicationDifferences: []argoprojiov1alpha1.ApplicationSetIgnoreDifferences{
+				{
+					JSONPointers:      []string{"/spec/source/targetRevision"},
+					JQPathExpressions: []string{".spec.syncPolicy.automated"},
+				},
+			},
+		},
+	}
+	existingApp := argov1alpha1.Application{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Application",
+			APIVersion: "argoproj.io/v1alpha1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "app1",
+			Namespace:       "namespace",
+			ResourceVersion: "2",
+		},
+		Spec: argov1alpha1.ApplicationSpec{
+			Project: "project",
+			Source:  argov1alpha1.ApplicationSource{Path: "path", TargetRevision: "debug"},
+		},
+	}
+	err = controllerutil.SetControllerReference(&appSet, &existingApp, scheme)
+	assert.Nil(t, err)
+
+	desiredApp := func(name string) argov1alpha1.Application {
+		return argov1alpha1.Application{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+			Spec: argov1alpha1.ApplicationSpec{
+				Project: "project",
+				Source:  argov1alpha1.ApplicationSource{Path: "other-path", TargetRevision: "HEAD"},
+				SyncPolicy: &argov1alpha1.SyncPolicy{
+					Automated:   &argov1alpha1.SyncPolicyAutomated{Prune: true},
+					SyncOptions: argov1alpha1.SyncOptions{"CreateNamespace=true"},
+				},
+			},
+		}
+	}
+
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&appSet, &existingApp).Build()
+	r := ApplicationSetReconciler{
+		Client: client,
+		Scheme: scheme,
+	}
+
+	ignoreDifferences, err := utils.CompileIgnoreDifferences(appSet.Spec.IgnoreApplicationDifferences)
+	assert.Nil(t, err)
+
+	desiredApps := []argov1alpha1.Application{desiredApp("app1"), desiredApp("app2")}
+	err = r.keepIgnoredLiveValues(context.TODO(), appSet, ignoreDifferences, desiredApps)
+	assert.Nil(t, err)
+
+	// The existing Application keeps its live values, while the new one is created with the values of the template
+	expectedApp := desiredApp("app1")
+	expectedApp.Spec.Source.TargetRevision = "debug"
+	expectedApp.Spec.SyncPolicy.Automated = nil
+	assert.Equal(t, []argov1alpha1.Application{expectedApp, desiredApp("app2")}, desiredApps)
+}
+
 func TestRemoveFinalizerOnInvalidDestination_FinalizerTypes(t *testing.T) {
 
 	scheme := runtime.NewScheme()
@@ -1294,7 +1387,7 @@ func TestCreateApplications(t *testing.T) {
 			Recorder: record.NewFakeRecorder(len(initObjs) + len(c.expected)),
 		}
 
-		err = r.createInCluster(context.TODO(), c.appSet, c.apps)
+		_, err = r.createInCluster(context.TODO(), c.appSet, c.apps)
 		assert.Nil(t, err)
 
 		for _, obj := range c.expected {
@@ -1514,6 +1607,72 @@ func TestGetMinRequeueAfter(t *testing.T) {
 
 	assert.Equal(t, time.Duration(1)*time.Second, got)
 }
+
+func TestGetPolicy(t *testing.T) {
+	for _, c := range []struct {
+		name             string
+		controllerPolicy utils.Policy
+		syncPolicy       *argoprojiov1alpha1.ApplicationSetSyncPolicy
+		expectedUpdate   bool
+		expectedDelete   bool
+		expectedErr      string
+	}{
+		{
+			name:             "no sync policy uses the controller policy",
+			controllerPolicy: &utils.CreateUpdatePolicy{},
+			expectedUpdate:   true,
+			expectedDelete:   false,
+		},
+		{
+			name:             "empty policy uses the controller policy",
+			controllerPolicy: &utils.SyncPolicy{},
+			syncPolicy:       &argoprojiov1alpha1.ApplicationSetSyncPolicy{PreserveResourcesOnDeletion: true},
+			expectedUpdate:   true,
+			expectedDelete:   true,
+		},
+		{
+			name:             "applicationset restricts the controller policy",
+			controllerPolicy: &utils.SyncPolicy{},
+			syncPolicy:       &argoprojiov1alpha1.ApplicationSetSyncPolicy{Policy: "create-only"},
+			expectedUpdate:   false,
+			expectedDelete:   false,
+		},
+		{
+			name:             "applicationset cannot exceed the controller policy",
+			controllerPolicy: &utils.CreateUpdatePolicy{},
+			syncPolicy:       &argoprojiov1alpha1.ApplicationSetSyncPolicy{Policy: "sync"},
+			expectedUpdate:   true,
+			expectedDelete:   false,
+		},
+		{
+			name:             "unknown policy",
+			controllerPolicy: &utils.SyncPolicy{},
+			syncPolicy:       &argoprojiov1alpha1.ApplicationSetSyncPolicy{Policy: "update-only"},
+			expectedErr:      `unknown policy "update-only", policy value can be: sync, create-only, create-update`,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			r := ApplicationSetReconciler{
+				Policy: c.controllerPolicy,
+			}
+
+			policy, err := r.getPolicy(argoprojiov1alpha1.ApplicationSet{
+				Spec: argoprojiov1alpha1.ApplicationSetSpec{
+					SyncPolicy: c.syncPolicy,
+				},
+			})
+
+			if c.expectedErr != "" {
+				assert.EqualError(t, err, c.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, c.expectedUpdate, policy.Update())
+				assert.Equal(t, c.expectedDelete, policy.Delete())
+			}
+		})
+	}
+}
+
 func TestHasDuplicateNames(t *testing.T) {
 
 	scheme := runtime.NewScheme()
@@ -1790,3 +1949,514 @@ func TestValidateGeneratedApplications(t *testing.T) {
 		})
 	}
 }
+
+func TestReconcilerValidationErrorBehaviour(t *testing.T) {
+
+	scheme := runtime.NewScheme()
+	err := argoprojiov1alpha1.AddToScheme(scheme)
+	assert.Nil(t, err)
+	err = argov1alpha1.AddToScheme(scheme)
+	assert.Nil(t, err)
+
+	appSet := argoprojiov1alpha1.ApplicationSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "name",
+			Namespace:  "argocd",
+			Generation: 2,
+		},
+		Spec: argoprojiov1alpha1.ApplicationSetSpec{
+			Generators: []argoprojiov1alpha1.ApplicationSetGenerator{
+				{
+					List: &argoprojiov1alpha1.ListGenerator{
+						Elements: []apiextensionsv1.JSON{{Raw: []byte(`{"project": "good"}`)}, {Raw: []byte(`{"project": "bad"}`)}},
+					},
+				},
+			},
+			Template: argoprojiov1alpha1.ApplicationSetTemplate{
+				ApplicationSetTemplateMeta: argoprojiov1alpha1.ApplicationSetTemplateMeta{
+					Name:      "{{project}}",
+					Namespace: "argocd",
+				},
+				Spec: argov1alpha1.ApplicationSpec{
+					Source:      argov1alpha1.ApplicationSource{RepoURL: "https://github.com/argoproj/argocd-example-apps", Path: "guestbook"},
+					Project:     "{{project}}",
+					Destination: argov1alpha1.ApplicationDestination{Server: "https://kubernetes.default.svc"},
+				},
+			},
+		},
+	}
+
+	goodProject := argov1alpha1.AppProject{
+		ObjectMeta: metav1.ObjectMeta{Name: "good", Namespace: "argocd"},
+		Spec: argov1alpha1.AppProjectSpec{
+			SourceRepos:  []string{"*"},
+			Destinations: []argov1alpha1.ApplicationDestination{{Server: "*", Namespace: "*"}},
+		},
+	}
+
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&appSet).Build()
+
+	argoDBMock := dbmocks.ArgoDB{}
+	argoDBMock.On("GetCluster", mock.Anything, "https://kubernetes.default.svc").Return(&argov1alpha1.Cluster{
+		Server: "https://kubernetes.default.svc",
+		Name:   "in-cluster",
+	}, nil)
+
+	r := ApplicationSetReconciler{
+		Client:   client,
+		Scheme:   scheme,
+		Renderer: &utils.Render{},
+		Recorder: record.NewFakeRecorder(1),
+		Generators: map[string]generators.Generator{
+			"List": generators.NewListGenerator(),
+		},
+		ArgoDB:           &argoDBMock,
+		ArgoAppClientset: appclientset.NewSimpleClientset(&goodProject),
+		KubeClientset:    kubefake.NewSimpleClientset(),
+		Policy:           &utils.SyncPolicy{},
+	}
+
+	req := ctrl.Request{
+		NamespacedName: types.NamespacedName{
+			Namespace: "argocd",
+			Name:      "name",
+		},
+	}
+
+	// Verify that on validation error, no error is returned, but the status reflects the error
+	res, err := r.Reconcile(context.Background(), req)
+	assert.Nil(t, err)
+	assert.True(t, res.RequeueAfter == 0)
+
+	var got argoprojiov1alpha1.ApplicationSet
+	err = client.Get(context.Background(), req.NamespacedName, &got)
+	assert.Nil(t, err)
+
+	assert.Equal(t, int64(2), got.Status.ObservedGeneration)
+
+	errorCondition := got.Status.GetCondition(argoprojiov1alpha1.ApplicationSetConditionErrorOccurred)
+	if assert.NotNil(t, errorCondition) {
+		assert.Equal(t, argoprojiov1alpha1.ApplicationSetConditionStatusTrue, errorCondition.Status)
+		assert.Equal(t, argoprojiov1alpha1.ApplicationSetReasonApplicationValidationError, errorCondition.Reason)
+		assert.Contains(t, errorCondition.Message, "application references project bad which does not exist")
+		assert.NotNil(t, errorCondition.LastTransitionTime)
+	}
+
+	paramsCondition := got.Status.GetCondition(argoprojiov1alpha1.ApplicationSetConditionParametersGenerated)
+	if assert.NotNil(t, paramsCondition) {
+		assert.Equal(t, argoprojiov1alpha1.ApplicationSetConditionStatusTrue, paramsCondition.Status)
+	}
+
+	upToDateCondition := got.Status.GetCondition(argoprojiov1alpha1.ApplicationSetConditionResourcesUpToDate)
+	if assert.NotNil(t, upToDateCondition) {
+		assert.Equal(t, argoprojiov1alpha1.ApplicationSetConditionStatusFalse, upToDateCondition.Status)
+	}
+
+	// Verify that the Application with the valid project was not created
+	var app argov1alpha1.Application
+	err = client.Get(context.Background(), crtclient.ObjectKey{Namespace: "argocd", Name: "good"}, &app)
+	assert.True(t, apierr.IsNotFound(err))
+}
+
+func TestReconcileClearsRefreshAnnotationOnError(t *testing.T) {
+	scheme := runtime.NewScheme()
+	err := argoprojiov1alpha1.AddToScheme(scheme)
+	assert.Nil(t, err)
+	err = argov1alpha1.AddToScheme(scheme)
+	assert.Nil(t, err)
+
+	for _, c := range []struct {
+		name          string
+		element       string
+		expectedError bool
+	}{
+		{
+			name:          "the generator fails",
+			element:       `{"values": "not-a-map"}`,
+			expectedError: true,
+		},
+		{
+			name:          "the generated Applications are invalid",
+			element:       `{"project": "missing"}`,
+			expectedError: false,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			appSet := argoprojiov1alpha1.ApplicationSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "name",
+					Namespace: "argocd",
+					Annotations: map[string]string{
+						argoprojiov1alpha1.AnnotationApplicationSetRefresh: "2021-07-01T10:00:00Z",
+					},
+				},
+				Spec: argoprojiov1alpha1.ApplicationSetSpec{
+					Generators: []argoprojiov1alpha1.ApplicationSetGenerator{
+						{
+							List: &argoprojiov1alpha1.ListGenerator{
+								Elements: []apiextensionsv1.JSON{{Raw: []byte(c.element)}},
+							},
+						},
+					},
+					Template: argoprojiov1alpha1.ApplicationSetTemplate{
+						ApplicationSetTemplateMeta: argoprojiov1alpha1.ApplicationSetTemplateMeta{
+							Name:      "app",
+							Namespace: "argocd",
+						},
+						Spec: argov1alpha1.ApplicationSpec{
+							Source:      argov1alpha1.ApplicationSource{RepoURL: "https://github.com/argoproj/argocd-example-apps", Path: "guestbook"},
+							Project:     "{{project}}",
+							Destination: argov1alpha1.ApplicationDestination{Server: "https://kubernetes.default.svc"},
+						},
+					},
+				},
+			}
+
+			client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&appSet).Build()
+			r := ApplicationSetReconciler{
+				Client:   client,
+				Scheme:   scheme,
+				Renderer: &utils.Render{},
+				Recorder: record.NewFakeRecorder(1),
+				Generators: map[string]generators.Generator{
+					"List": generators.NewListGenerator(),
+				},
+				ArgoDB:           &dbmocks.ArgoDB{},
+				ArgoAppClientset: appclientset.NewSimpleClientset(),
+				KubeClientset:    kubefake.NewSimpleClientset(),
+				Policy:           &utils.SyncPolicy{},
+			}
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "argocd", Name: "name"}}
+			_, err := r.Reconcile(context.Background(), req)
+			assert.Equal(t, c.expectedError, err != nil)
+
+			var got argoprojiov1alpha1.ApplicationSet
+			err = client.Get(context.Background(), req.NamespacedName, &got)
+			assert.Nil(t, err)
+			assert.False(t, got.RefreshRequired())
+			assert.NotNil(t, got.Status.GetCondition(argoprojiov1alpha1.ApplicationSetConditionErrorOccurred))
+		})
+	}
+}
+
+func TestSetApplicationSetStatus(t *testing.T) {
+
+	scheme := runtime.NewScheme()
+	err := argoprojiov1alpha1.AddToScheme(scheme)
+	assert.Nil(t, err)
+	err = argov1alpha1.AddToScheme(scheme)
+	assert.Nil(t, err)
+
+	previousTime := metav1.NewTime(time.Now().Add(-time.Hour))
+
+	appSet := argoprojiov1alpha1.ApplicationSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "name",
+			Namespace:  "argocd",
+			Generation: 3,
+		},
+		Status: argoprojiov1alpha1.ApplicationSetStatus{
+			ObservedGeneration: 2,
+			Conditions: []argoprojiov1alpha1.ApplicationSetCondition{
+				{
+					Type:               argoprojiov1alpha1.ApplicationSetConditionErrorOccurred,
+					Status:             argoprojiov1alpha1.ApplicationSetConditionStatusFalse,
+					Reason:             argoprojiov1alpha1.ApplicationSetReasonApplicationSetUpToDate,
+					LastTransitionTime: &previousTime,
+				},
+			},
+			Applications: []argoprojiov1alpha1.ApplicationSetApplicationStatus{
+				{
+					Application:        "app1",
+					Action:             string(controllerutil.OperationResultCreated),
+					LastTransitionTime: &previousTime,
+				},
+				{
+					Application:        "removed",
+					Action:             string(controllerutil.OperationResultCreated),
+					LastTransitionTime: &previousTime,
+				},
+			},
+		},
+	}
+
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&appSet).Build()
+
+	r := ApplicationSetReconciler{
+		Client:   client,
+		Scheme:   scheme,
+		Recorder: record.NewFakeRecorder(1),
+	}
+
+	var current argoprojiov1alpha1.ApplicationSet
+	err = client.Get(context.Background(), crtclient.ObjectKeyFromObject(&appSet), &current)
+	assert.Nil(t, err)
+
+	now := metav1.Now()
+	err = r.setApplicationSetStatus(context.Background(), &current,
+		[]argoprojiov1alpha1.ApplicationSetApplicationStatus{
+			{Application: "app2", Action: string(controllerutil.OperationResultUpdated), LastTransitionTime: &now},
+			{Application: "app1", Action: string(controllerutil.OperationResultNone), LastTransitionTime: &now},
+		},
+		nil,
+		errorConditions(argoprojiov1alpha1.ApplicationSetReasonUpdateApplicationError, errors.New("update failed"), true)...)
+	assert.Nil(t, err)
+
+	var got argoprojiov1alpha1.ApplicationSet
+	err = client.Get(context.Background(), crtclient.ObjectKeyFromObject(&appSet), &got)
+	assert.Nil(t, err)
+
+	assert.Equal(t, int64(3), got.Status.ObservedGeneration)
+	assert.Len(t, got.Status.Conditions, 3)
+
+	errorCondition := got.Status.GetCondition(argoprojiov1alpha1.ApplicationSetConditionErrorOccurred)
+	if assert.NotNil(t, errorCondition) {
+		assert.Equal(t, argoprojiov1alpha1.ApplicationSetConditionStatusTrue, errorCondition.Status)
+		assert.Equal(t, "update failed", errorCondition.Message)
+		assert.True(t, errorCondition.LastTransitionTime.After(previousTime.Time))
+	}
+
+	// app1 was left unchanged, so its previously recorded action is kept; removed is no longer generated
+	if assert.Len(t, got.Status.Applications, 2) {
+		assert.Equal(t, "app1", got.Status.Applications[0].Application)
+		assert.Equal(t, string(controllerutil.OperationResultCreated), got.Status.Applications[0].Action)
+		assert.Equal(t, "app2", got.Status.Applications[1].Application)
+		assert.Equal(t, string(controllerutil.OperationResultUpdated), got.Status.Applications[1].Action)
+	}
+}
+
+func TestMergeApplicationStatusesOfHeldApplications(t *testing.T) {
+	previousTime := metav1.NewTime(time.Now().Add(-time.Hour))
+	now := metav1.Now()
+	waiting := func(step int, transitionTime *metav1.Time) argoprojiov1alpha1.ApplicationSetApplicationStatus {
+		return argoprojiov1alpha1.ApplicationSetApplicationStatus{
+			Application:        "app1",
+			Action:             argoprojiov1alpha1.ApplicationSetApplicationActionWaiting,
+			Message:            fmt.Sprintf("Waiting for step %d to complete", step),
+			LastTransitionTime: transitionTime,
+		}
+	}
+
+	// An Application still held back by the same step keeps the time it was first held back
+	res := mergeApplicationStatuses(
+		[]argoprojiov1alpha1.ApplicationSetApplicationStatus{waiting(1, &previousTime)},
+		[]argoprojiov1alpha1.ApplicationSetApplicationStatus{waiting(1, &now)})
+	assert.Equal(t, []argoprojiov1alpha1.ApplicationSetApplicationStatus{waiting(1, &previousTime)}, res)
+
+	res = mergeApplicationStatuses(
+		[]argoprojiov1alpha1.ApplicationSetApplicationStatus{waiting(1, &previousTime)},
+		[]argoprojiov1alpha1.ApplicationSetApplicationStatus{waiting(2, &now)})
+	assert.Equal(t, []argoprojiov1alpha1.ApplicationSetApplicationStatus{waiting(2, &now)}, res)
+
+	// A held Application is not reported as unchanged once its step is rolled out
+	res = mergeApplicationStatuses(
+		[]argoprojiov1alpha1.ApplicationSetApplicationStatus{waiting(1, &previousTime)},
+		[]argoprojiov1alpha1.ApplicationSetApplicationStatus{{Application: "app1", Action: string(controllerutil.OperationResultCreated), LastTransitionTime: &now}})
+	assert.Equal(t, string(controllerutil.OperationResultCreated), res[0].Action)
+}
+
+func TestClearRefreshAnnotation(t *testing.T) {
+	scheme := runtime.NewScheme()
+	err := argoprojiov1alpha1.AddToScheme(scheme)
+	assert.Nil(t, err)
+
+	for _, c := range []struct {
+		name                string
+		refreshToken        string
+		expectedAnnotations map[string]string
+	}{
+		{
+			name:                "the refresh seen by the reconciliation is removed",
+			refreshToken:        "2021-07-01T10:00:00Z",
+			expectedAnnotations: map[string]string{"other": "annotation"},
+		},
+		{
+			name:         "a refresh requested during the reconciliation is kept",
+			refreshToken: "2021-07-01T09:00:00Z",
+			expectedAnnotations: map[string]string{
+				argoprojiov1alpha1.AnnotationApplicationSetRefresh: "2021-07-01T10:00:00Z",
+				"other": "annotation",
+			},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			appSet := argoprojiov1alpha1.ApplicationSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "name",
+					Namespace: "argocd",
+					Annotations: map[string]string{
+						argoprojiov1alpha1.AnnotationApplicationSetRefresh: "2021-07-01T10:00:00Z",
+						"other": "annotation",
+					},
+				},
+			}
+
+			client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&appSet).Build()
+			r := ApplicationSetReconciler{
+				Client: client,
+				Scheme: scheme,
+			}
+
+			var current argoprojiov1alpha1.ApplicationSet
+			err = client.Get(context.Background(), crtclient.ObjectKeyFromObject(&appSet), &current)
+			assert.Nil(t, err)
+
+			err = r.clearRefreshAnnotation(context.Background(), &current, c.refreshToken)
+			assert.Nil(t, err)
+
+			var got argoprojiov1alpha1.ApplicationSet
+			err = client.Get(context.Background(), crtclient.ObjectKeyFromObject(&appSet), &got)
+			assert.Nil(t, err)
+			assert.Equal(t, c.expectedAnnotations, got.Annotations)
+		})
+	}
+}
+
+func TestOwnedApplicationPredicate(t *testing.T) {
+	scheme := runtime.NewScheme()
+	err := argoprojiov1alpha1.AddToScheme(scheme)
+	assert.Nil(t, err)
+
+	allAtOnce := argoprojiov1alpha1.ApplicationSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "all-at-once", Namespace: "argocd"},
+	}
+	rollingSync := argoprojiov1alpha1.ApplicationSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "rolling-sync", Namespace: "argocd"},
+		Spec: argoprojiov1alpha1.ApplicationSetSpec{
+			Strategy: &argoprojiov1alpha1.ApplicationSetStrategy{Type: argoprojiov1alpha1.ApplicationSetStrategyRollingSync},
+		},
+	}
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&allAtOnce, &rollingSync).Build()
+
+	app := func(owner string) argov1alpha1.Application {
+		isController := true
+		return argov1alpha1.Application{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "app",
+				Namespace:   "argocd",
+				Labels:      map[string]string{"label-key": "label-value"},
+				Annotations: map[string]string{"annotation-key": "annotation-value"},
+				Finalizers:  []string{common.ResourcesFinalizerName},
+				OwnerReferences: []metav1.OwnerReference{
+					{APIVersion: "argoproj.io/v1alpha1", Kind: "ApplicationSet", Name: owner, Controller: &isController},
+				},
+			},
+			Spec: argov1alpha1.ApplicationSpec{
+				Source:      argov1alpha1.ApplicationSource{RepoURL: "https://github.com/argoproj/argocd-example-apps", Path: "guestbook"},
+				Destination: argov1alpha1.ApplicationDestination{Server: "https://kubernetes.default.svc", Namespace: "guestbook"},
+				Project:     "default",
+			},
+		}
+	}
+
+	for _, c := range []struct {
+		name     string
+		owner    string
+		update   func(app *argov1alpha1.Application)
+		expected bool
+	}{
+		{
+			name:  "status is updated",
+			owner: "all-at-once",
+			update: func(app *argov1alpha1.Application) {
+				app.Status.Sync.Status = argov1alpha1.SyncStatusCodeOutOfSync
+				app.Status.ReconciledAt = &metav1.Time{Time: time.Now()}
+			},
+			expected: false,
+		},
+		{
+			name:  "sync status is updated with the rolling sync strategy",
+			owner: "rolling-sync",
+			update: func(app *argov1alpha1.Application) {
+				app.Status.Sync.Status = argov1alpha1.SyncStatusCodeOutOfSync
+			},
+			expected: true,
+		},
+		{
+			name:  "health status is updated with the rolling sync strategy",
+			owner: "rolling-sync",
+			update: func(app *argov1alpha1.Application) {
+				app.Status.Health.Status = health.HealthStatusHealthy
+			},
+			expected: true,
+		},
+		{
+			name:  "other status fields are updated with the rolling sync strategy",
+			owner: "rolling-sync",
+			update: func(app *argov1alpha1.Application) {
+				app.Status.ReconciledAt = &metav1.Time{Time: time.Now()}
+			},
+			expected: false,
+		},
+		{
+			name:  "status is updated and the owner does not exist anymore",
+			owner: "deleted",
+			update: func(app *argov1alpha1.Application) {
+				app.Status.Health.Status = health.HealthStatusHealthy
+			},
+			expected: false,
+		},
+		{
+			name:  "refresh is requested",
+			owner: "all-at-once",
+			update: func(app *argov1alpha1.Application) {
+				app.Annotations[common.AnnotationKeyRefresh] = "normal"
+			},
+			expected: false,
+		},
+		{
+			name:  "notifications are sent",
+			owner: "all-at-once",
+			update: func(app *argov1alpha1.Application) {
+				app.Annotations[NotifiedAnnotationKey] = `{"on-deployed:app-sync-succeeded:slack:my-channel":1617144614}`
+			},
+			expected: false,
+		},
+		{
+			name:  "spec is edited",
+			owner: "all-at-once",
+			update: func(app *argov1alpha1.Application) {
+				app.Spec.Source.TargetRevision = "debug"
+			},
+			expected: true,
+		},
+		{
+			name:  "label is edited",
+			owner: "all-at-once",
+			update: func(app *argov1alpha1.Application) {
+				app.Labels["label-key"] = "other-value"
+			},
+			expected: true,
+		},
+		{
+			name:  "annotation is removed",
+			owner: "all-at-once",
+			update: func(app *argov1alpha1.Application) {
+				delete(app.Annotations, "annotation-key")
+			},
+			expected: true,
+		},
+		{
+			name:  "finalizer is removed",
+			owner: "all-at-once",
+			update: func(app *argov1alpha1.Application) {
+				app.Finalizers = nil
+			},
+			expected: true,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			oldApp := app(c.owner)
+			newApp := oldApp.DeepCopy()
+			c.update(newApp)
+
+			got := ownedApplicationPredicate(client).Update(event.UpdateEvent{ObjectOld: &oldApp, ObjectNew: newApp})
+			assert.Equal(t, c.expected, got)
+		})
+	}
+
+	created := app("all-at-once")
+	assert.True(t, ownedApplicationPredicate(client).Create(event.CreateEvent{Object: &created}))
+	assert.True(t, ownedApplicationPredicate(client).Delete(event.DeleteEvent{Object: &created}))
+}
@@ -1,10 +1,15 @@
 package controllers
 
 import (
+	"bytes"
 	"context"
+	"reflect"
 
 	log "github.com/sirupsen/logrus"
 
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -13,6 +18,7 @@ import (
 
 	argoprojiov1alpha1 "github.com/argoproj-labs/applicationset/api/v1alpha1"
 	"github.com/argoproj-labs/applicationset/pkg/generators"
+	"github.com/argoproj-labs/applicationset/pkg/utils"
 )
 
 // clusterSecretEventHandler is used when watching Secrets to check if they are ArgoCD Cluster Secrets, and if so
@@ -21,22 +27,25 @@ type clusterSecretEventHandler struct {
 	//handler.EnqueueRequestForOwner
 	Log    log.FieldLogger
 	Client client.Client
+	// ClusterInfo is the cache of the server versions and connection states of the clusters, whose entries are evicted
+	// when the clusters are removed
+	ClusterInfo *utils.ClusterInfoCache
 }
 
 func (h *clusterSecretEventHandler) Create(e event.CreateEvent, q workqueue.RateLimitingInterface) {
-	h.queueRelatedAppGenerators(q, e.Object)
+	h.queueRelatedAppGenerators(q, nil, e.Object)
 }
 
 func (h *clusterSecretEventHandler) Update(e event.UpdateEvent, q workqueue.RateLimitingInterface) {
-	h.queueRelatedAppGenerators(q, e.ObjectNew)
+	h.queueRelatedAppGenerators(q, e.ObjectOld, e.ObjectNew)
 }
 
 func (h *clusterSecretEventHandler) Delete(e event.DeleteEvent, q workqueue.RateLimitingInterface) {
-	h.queueRelatedAppGenerators(q, e.Object)
+	h.queueRelatedAppGenerators(q, e.Object, nil)
 }
 
 func (h *clusterSecretEventHandler) Generic(e event.GenericEvent, q workqueue.RateLimitingInterface) {
-	h.queueRelatedAppGenerators(q, e.Object)
+	h.queueRelatedAppGenerators(q, nil, e.Object)
 }
 
 // addRateLimitingInterface defines the Add method of workqueue.RateLimitingInterface, allow us to easily mock
@@ -45,18 +54,29 @@ type addRateLimitingInterface interface {
 	Add(item interface{})
 }
 
-func (h *clusterSecretEventHandler) queueRelatedAppGenerators(q addRateLimitingInterface, object client.Object) {
+// queueRelatedAppGenerators queues the ApplicationSets whose cluster generators are affected by the change of a
+// Secret from oldObject to newObject, either of which is nil when the Secret did not exist before or after the change.
+func (h *clusterSecretEventHandler) queueRelatedAppGenerators(q addRateLimitingInterface, oldObject client.Object, newObject client.Object) {
 
-	// Check for label, lookup all ApplicationSets that might match the cluster, queue them all
-	if object.GetLabels()[generators.ArgoCDSecretTypeLabel] != generators.ArgoCDSecretTypeCluster {
+	change := clusterSecretChange{old: asClusterSecret(oldObject), new: asClusterSecret(newObject)}
+	if change.old == nil && change.new == nil {
 		return
 	}
 
+	object := newObject
+	if object == nil {
+		object = oldObject
+	}
 	h.Log.WithFields(log.Fields{
 		"namespace": object.GetNamespace(),
 		"name":      object.GetName(),
 	}).Info("processing event for cluster secret")
 
+	// The info of a cluster is probed again once the cluster is removed, or its credentials change
+	if secret, isSecret := change.old.(*corev1.Secret); isSecret && (change.dataChanged("server") || change.dataChanged("config")) {
+		h.ClusterInfo.Evict(string(secret.Data["server"]))
+	}
+
 	appSetList := &argoprojiov1alpha1.ApplicationSetList{}
 	err := h.Client.List(context.Background(), appSetList)
 	if err != nil {
@@ -67,18 +87,114 @@ func (h *clusterSecretEventHandler) queueRelatedAppGenerators(q addRateLimitingI
 	h.Log.WithField("count", len(appSetList.Items)).Info("listed ApplicationSets")
 	for _, appSet := range appSetList.Items {
 
-		foundClusterGenerator := false
-		for _, generator := range appSet.Spec.Generators {
-			if generator.Clusters != nil {
-				foundClusterGenerator = true
+		affected := false
+		for _, generator := range clusterGenerators(&appSet) {
+			if change.affects(generator, &appSet, h.Log) {
+				affected = true
 				break
 			}
 		}
-		if foundClusterGenerator {
-
-			// TODO: only queue the AppGenerator if the labels match this cluster
+		if affected {
 			req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: appSet.Namespace, Name: appSet.Name}}
 			q.Add(req)
 		}
 	}
 }
+
+// asClusterSecret returns the object if it is an Argo CD cluster Secret, nil otherwise.
+func asClusterSecret(object client.Object) client.Object {
+	if object == nil || object.GetLabels()[generators.ArgoCDSecretTypeLabel] != generators.ArgoCDSecretTypeCluster {
+		return nil
+	}
+	return object
+}
+
+// clusterGenerators returns the cluster generators of the ApplicationSet, including the child generators of its
+// Matrix and Merge generators.
+func clusterGenerators(appSet *argoprojiov1alpha1.ApplicationSet) []*argoprojiov1alpha1.ClusterGenerator {
+	var res []*argoprojiov1alpha1.ClusterGenerator
+	for _, generator := range appSet.Spec.Generators {
+		if generator.Clusters != nil {
+			res = append(res, generator.Clusters)
+		}
+
+		var children []argoprojiov1alpha1.ApplicationSetBaseGenerator
+		if generator.Matrix != nil {
+			children = append(children, generator.Matrix.Generators...)
+		}
+		if generator.Merge != nil {
+			children = append(children, generator.Merge.Generators...)
+		}
+		for _, child := range children {
+			if child.Clusters != nil {
+				res = append(res, child.Clusters)
+			}
+		}
+	}
+	return res
+}
+
+// clusterSecretChange is the change of a cluster Secret, from old to new, either of which is nil when it is not a
+// cluster Secret before or after the change.
+type clusterSecretChange struct {
+	old client.Object
+	new client.Object
+}
+
+// affects returns whether the change adds the cluster to the clusters of the generator, removes it from them, or
+// changes its params, including its server version and connection state when the ApplicationSet uses them.
+func (c clusterSecretChange) affects(generator *argoprojiov1alpha1.ClusterGenerator, appSet *argoprojiov1alpha1.ApplicationSet, logger log.FieldLogger) bool {
+	selector, err := metav1.LabelSelectorAsSelector(&generator.Selector)
+	if err != nil {
+		// The generator fails on invalid selectors: requeue the ApplicationSet to report the error
+		logger.WithError(err).Warn("unable to parse the label selector of a cluster generator")
+		return true
+	}
+
+	oldMatch := c.old != nil && selector.Matches(labels.Set(c.old.GetLabels()))
+	newMatch := c.new != nil && selector.Matches(labels.Set(c.new.GetLabels()))
+	if oldMatch != newMatch {
+		return true
+	}
+	if !newMatch {
+		return false
+	}
+	if c.paramsChanged() {
+		return true
+	}
+
+	// New credentials may change the server version and connection state of the cluster
+	if c.dataChanged("config") {
+		usesClusterInfo, err := generators.UsesClusterInfo(&argoprojiov1alpha1.ApplicationSetGenerator{Clusters: generator}, appSet)
+		return err != nil || usesClusterInfo
+	}
+	return false
+}
+
+// paramsChanged returns whether the cluster generator params of the Secret changed.
+func (c clusterSecretChange) paramsChanged() bool {
+	if !reflect.DeepEqual(c.old.GetLabels(), c.new.GetLabels()) ||
+		!reflect.DeepEqual(c.old.GetAnnotations(), c.new.GetAnnotations()) {
+		return true
+	}
+
+	// The fields the params of the cluster are read from; the other fields, such as the credentials of the cluster,
+	// do not change the params
+	for _, key := range []string{"name", "server", "namespaces", "shard"} {
+		if c.dataChanged(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// dataChanged returns whether the value of the key in the data of the Secret changed, which is the case when the
+// Secret was created or deleted.
+func (c clusterSecretChange) dataChanged(key string) bool {
+	oldSecret, oldOK := c.old.(*corev1.Secret)
+	newSecret, newOK := c.new.(*corev1.Secret)
+	if !oldOK || !newOK {
+		return true
+	}
+	return !bytes.Equal(oldSecret.Data[key], newSecret.Data[key])
+}
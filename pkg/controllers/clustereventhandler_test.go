@@ -2,9 +2,11 @@ package controllers
 
 import (
 	"testing"
+	"time"
 
 	argoprojiov1alpha1 "github.com/argoproj-labs/applicationset/api/v1alpha1"
 	"github.com/argoproj-labs/applicationset/pkg/generators"
+	"github.com/argoproj-labs/applicationset/pkg/utils"
 	argov1alpha1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
@@ -13,6 +15,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
@@ -208,7 +211,7 @@ func TestClusterEventHandler(t *testing.T) {
 
 			mockAddRateLimitingInterface := mockAddRateLimitingInterface{}
 
-			handler.queueRelatedAppGenerators(&mockAddRateLimitingInterface, &test.secret)
+			handler.queueRelatedAppGenerators(&mockAddRateLimitingInterface, nil, &test.secret)
 
 			assert.False(t, mockAddRateLimitingInterface.errorOccurred)
 			assert.ElementsMatch(t, mockAddRateLimitingInterface.addedItems, test.expectedRequests)
@@ -218,6 +221,212 @@ func TestClusterEventHandler(t *testing.T) {
 
 }
 
+func TestClusterEventHandlerChanges(t *testing.T) {
+
+	scheme := runtime.NewScheme()
+	err := argoprojiov1alpha1.AddToScheme(scheme)
+	assert.Nil(t, err)
+
+	clusterSelector := func(environment string) *argoprojiov1alpha1.ClusterGenerator {
+		return &argoprojiov1alpha1.ClusterGenerator{
+			Selector: v1.LabelSelector{MatchLabels: map[string]string{"environment": environment}},
+		}
+	}
+	appSet := func(name string, generator argoprojiov1alpha1.ApplicationSetGenerator) argoprojiov1alpha1.ApplicationSet {
+		return argoprojiov1alpha1.ApplicationSet{
+			ObjectMeta: v1.ObjectMeta{Name: name, Namespace: "argocd"},
+			Spec: argoprojiov1alpha1.ApplicationSetSpec{
+				Generators: []argoprojiov1alpha1.ApplicationSetGenerator{generator},
+			},
+		}
+	}
+	appSets := []argoprojiov1alpha1.ApplicationSet{
+		appSet("all-clusters", argoprojiov1alpha1.ApplicationSetGenerator{Clusters: &argoprojiov1alpha1.ClusterGenerator{}}),
+		appSet("production", argoprojiov1alpha1.ApplicationSetGenerator{Clusters: clusterSelector("production")}),
+		appSet("production-1.21", argoprojiov1alpha1.ApplicationSetGenerator{Clusters: &argoprojiov1alpha1.ClusterGenerator{
+			Selector:      v1.LabelSelector{MatchLabels: map[string]string{"environment": "production"}},
+			ServerVersion: ">=1.21",
+		}}),
+		appSet("matrix-staging", argoprojiov1alpha1.ApplicationSetGenerator{Matrix: &argoprojiov1alpha1.MatrixGenerator{
+			Generators: []argoprojiov1alpha1.ApplicationSetBaseGenerator{
+				{List: &argoprojiov1alpha1.ListGenerator{}},
+				{Clusters: clusterSelector("staging")},
+			},
+		}}),
+		appSet("merge-staging", argoprojiov1alpha1.ApplicationSetGenerator{Merge: &argoprojiov1alpha1.MergeGenerator{
+			Generators: []argoprojiov1alpha1.ApplicationSetBaseGenerator{
+				{Clusters: clusterSelector("staging")},
+				{List: &argoprojiov1alpha1.ListGenerator{}},
+			},
+		}}),
+		appSet("git", argoprojiov1alpha1.ApplicationSetGenerator{Git: &argoprojiov1alpha1.GitGenerator{}}),
+	}
+
+	secret := func(labels map[string]string, annotations map[string]string, data map[string]string) *corev1.Secret {
+		secret := &corev1.Secret{
+			ObjectMeta: v1.ObjectMeta{
+				Namespace:   "argocd",
+				Name:        "my-secret",
+				Labels:      labels,
+				Annotations: annotations,
+			},
+			Data: map[string][]byte{},
+		}
+		for key, value := range data {
+			secret.Data[key] = []byte(value)
+		}
+		return secret
+	}
+	clusterLabels := func(environment string) map[string]string {
+		return map[string]string{
+			generators.ArgoCDSecretTypeLabel: generators.ArgoCDSecretTypeCluster,
+			"environment":                    environment,
+		}
+	}
+	clusterData := map[string]string{"name": "my-cluster", "server": "https://my-cluster.example.com", "config": "{}"}
+
+	tests := []struct {
+		name             string
+		oldSecret        *corev1.Secret
+		newSecret        *corev1.Secret
+		expectedRequests []string
+	}{
+		{
+			name:             "a created cluster is matched by the selectors of child generators",
+			newSecret:        secret(clusterLabels("staging"), nil, clusterData),
+			expectedRequests: []string{"all-clusters", "matrix-staging", "merge-staging"},
+		},
+		{
+			name:             "a deleted cluster is matched by the selectors of the generators",
+			oldSecret:        secret(clusterLabels("production"), nil, clusterData),
+			expectedRequests: []string{"all-clusters", "production", "production-1.21"},
+		},
+		{
+			name:             "a relabeled cluster affects the generators matching it before or after",
+			oldSecret:        secret(clusterLabels("staging"), nil, clusterData),
+			newSecret:        secret(clusterLabels("production"), nil, clusterData),
+			expectedRequests: []string{"all-clusters", "production", "production-1.21", "matrix-staging", "merge-staging"},
+		},
+		{
+			name:             "a new annotation changes the params of the generators matching the cluster",
+			oldSecret:        secret(clusterLabels("staging"), nil, clusterData),
+			newSecret:        secret(clusterLabels("staging"), map[string]string{"owner": "team-a"}, clusterData),
+			expectedRequests: []string{"all-clusters", "matrix-staging", "merge-staging"},
+		},
+		{
+			name:             "a new server changes the params of the generators matching the cluster",
+			oldSecret:        secret(clusterLabels("production"), nil, clusterData),
+			newSecret:        secret(clusterLabels("production"), nil, map[string]string{"name": "my-cluster", "server": "https://other.example.com", "config": "{}"}),
+			expectedRequests: []string{"all-clusters", "production", "production-1.21"},
+		},
+		{
+			name:             "new credentials only affect the generators using the server versions or connection states",
+			oldSecret:        secret(clusterLabels("production"), nil, clusterData),
+			newSecret:        secret(clusterLabels("production"), nil, map[string]string{"name": "my-cluster", "server": "https://my-cluster.example.com", "config": `{"bearerToken": "token"}`}),
+			expectedRequests: []string{"production-1.21"},
+		},
+		{
+			name:             "a cluster which is no longer a cluster secret is removed from the generators matching it",
+			oldSecret:        secret(clusterLabels("production"), nil, clusterData),
+			newSecret:        secret(map[string]string{"environment": "production"}, nil, clusterData),
+			expectedRequests: []string{"all-clusters", "production", "production-1.21"},
+		},
+		{
+			name:             "other secrets do not affect the generators",
+			oldSecret:        secret(map[string]string{"environment": "production"}, nil, nil),
+			newSecret:        secret(map[string]string{"environment": "staging"}, nil, nil),
+			expectedRequests: []string{},
+		},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+
+			appSetList := argoprojiov1alpha1.ApplicationSetList{
+				Items: appSets,
+			}
+
+			fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithLists(&appSetList).Build()
+
+			handler := &clusterSecretEventHandler{
+				Client: fakeClient,
+				Log:    log.WithField("type", "createSecretEventHandler"),
+			}
+
+			mockAddRateLimitingInterface := mockAddRateLimitingInterface{}
+
+			var oldObject, newObject client.Object
+			if test.oldSecret != nil {
+				oldObject = test.oldSecret
+			}
+			if test.newSecret != nil {
+				newObject = test.newSecret
+			}
+			handler.queueRelatedAppGenerators(&mockAddRateLimitingInterface, oldObject, newObject)
+
+			expectedRequests := []ctrl.Request{}
+			for _, name := range test.expectedRequests {
+				expectedRequests = append(expectedRequests, ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "argocd", Name: name}})
+			}
+			assert.False(t, mockAddRateLimitingInterface.errorOccurred)
+			assert.ElementsMatch(t, expectedRequests, mockAddRateLimitingInterface.addedItems)
+		})
+	}
+}
+
+func TestClusterEventHandlerEvictsClusterInfo(t *testing.T) {
+	scheme := runtime.NewScheme()
+	err := argoprojiov1alpha1.AddToScheme(scheme)
+	assert.Nil(t, err)
+
+	probes := 0
+	clusterInfo := utils.NewClusterInfoCache(time.Hour, func(cluster *argov1alpha1.Cluster) (string, error) {
+		probes++
+		return "1.21", nil
+	})
+	probe := func() {
+		clusterInfo.SetClusterInfo([]*argov1alpha1.Cluster{{Name: "my-cluster", Server: "https://my-cluster.example.com"}})
+	}
+
+	handler := &clusterSecretEventHandler{
+		Client:      fake.NewClientBuilder().WithScheme(scheme).Build(),
+		Log:         log.WithField("type", "createSecretEventHandler"),
+		ClusterInfo: clusterInfo,
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{
+			Namespace: "argocd",
+			Name:      "my-secret",
+			Labels:    map[string]string{generators.ArgoCDSecretTypeLabel: generators.ArgoCDSecretTypeCluster},
+		},
+		Data: map[string][]byte{"name": []byte("my-cluster"), "server": []byte("https://my-cluster.example.com"), "config": []byte("{}")},
+	}
+
+	probe()
+	probe()
+	assert.Equal(t, 1, probes)
+
+	// The info of a cluster whose credentials changed is forgotten
+	updated := secret.DeepCopy()
+	updated.Data["config"] = []byte(`{"bearerToken": "token"}`)
+	handler.queueRelatedAppGenerators(&mockAddRateLimitingInterface{}, secret, updated)
+	probe()
+	assert.Equal(t, 2, probes)
+
+	// As well as the info of a deleted cluster
+	handler.queueRelatedAppGenerators(&mockAddRateLimitingInterface{}, updated, nil)
+	probe()
+	assert.Equal(t, 3, probes)
+
+	// Other changes keep the info
+	relabeled := updated.DeepCopy()
+	relabeled.Labels["environment"] = "production"
+	handler.queueRelatedAppGenerators(&mockAddRateLimitingInterface{}, updated, relabeled)
+	probe()
+	assert.Equal(t, 3, probes)
+}
+
 // Add checks the type, and adds it to the internal list of received additions
 func (obj *mockAddRateLimitingInterface) Add(item interface{}) {
 	if req, ok := item.(ctrl.Request); ok {
@@ -0,0 +1,214 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	argov1alpha1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+	"github.com/argoproj/gitops-engine/pkg/health"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	argoprojiov1alpha1 "github.com/argoproj-labs/applicationset/api/v1alpha1"
+)
+
+// getRolloutSteps groups the desired Applications by the step of the rolling sync strategy they belong to. An
+// Application belongs to the first step it matches, and to the last step if it matches none. It returns nil if the
+// ApplicationSet does not use the rolling sync strategy.
+func getRolloutSteps(applicationSet argoprojiov1alpha1.ApplicationSet, desiredApplications []argov1alpha1.Application) ([][]argov1alpha1.Application, error) {
+	strategy := applicationSet.Spec.Strategy
+	if strategy == nil || strategy.Type == "" || strategy.Type == argoprojiov1alpha1.ApplicationSetStrategyAllAtOnce {
+		return nil, nil
+	}
+
+	if strategy.Type != argoprojiov1alpha1.ApplicationSetStrategyRollingSync {
+		return nil, fmt.Errorf("unknown strategy type %q, strategy type can be: %s, %s", strategy.Type,
+			argoprojiov1alpha1.ApplicationSetStrategyAllAtOnce, argoprojiov1alpha1.ApplicationSetStrategyRollingSync)
+	}
+
+	if strategy.RollingSync == nil || len(strategy.RollingSync.Steps) == 0 {
+		return nil, fmt.Errorf("the %s strategy requires at least one step", argoprojiov1alpha1.ApplicationSetStrategyRollingSync)
+	}
+
+	selectors := make([]labels.Selector, 0, len(strategy.RollingSync.Steps))
+	for i, step := range strategy.RollingSync.Steps {
+		selector, err := metav1.LabelSelectorAsSelector(&metav1.LabelSelector{MatchExpressions: step.MatchExpressions})
+		if err != nil {
+			return nil, fmt.Errorf("invalid match expressions in step %d of the %s strategy: %v", i+1, argoprojiov1alpha1.ApplicationSetStrategyRollingSync, err)
+		}
+		selectors = append(selectors, selector)
+	}
+
+	steps := make([][]argov1alpha1.Application, len(selectors))
+	for _, app := range desiredApplications {
+		step := len(selectors) - 1
+		for i, selector := range selectors {
+			if selector.Matches(labels.Set(app.Labels)) {
+				step = i
+				break
+			}
+		}
+		steps[step] = append(steps[step], app)
+	}
+
+	return steps, nil
+}
+
+// rollout returns the desired Applications that may be created or updated in this reconciliation according to the
+// rolling sync steps, the statuses of the Applications that are held back, and the status of each step.
+// updateAllowed indicates whether the policy of the ApplicationSet allows existing Applications to be updated.
+func (r *ApplicationSetReconciler) rollout(ctx context.Context, applicationSet argoprojiov1alpha1.ApplicationSet, steps [][]argov1alpha1.Application, updateAllowed bool) ([]argov1alpha1.Application, []argoprojiov1alpha1.ApplicationSetApplicationStatus, []argoprojiov1alpha1.ApplicationSetRolloutStepStatus, error) {
+	current, err := r.getCurrentApplications(ctx, applicationSet)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	currentByName := make(map[string]argov1alpha1.Application, len(current))
+	for _, app := range current {
+		currentByName[app.Name] = app
+	}
+
+	allowed, held, stepStatuses := planRollout(steps, currentByName, updateAllowed)
+	return allowed, held, stepStatuses, nil
+}
+
+// planRollout walks through the rolling sync steps in order: the Applications of a step are allowed to be created or
+// updated once all the Applications of the previous steps are rolled out, i.e. Healthy and Synced to their desired
+// spec. The Applications of the remaining steps are held back.
+func planRollout(steps [][]argov1alpha1.Application, currentByName map[string]argov1alpha1.Application, updateAllowed bool) ([]argov1alpha1.Application, []argoprojiov1alpha1.ApplicationSetApplicationStatus, []argoprojiov1alpha1.ApplicationSetRolloutStepStatus) {
+	var allowed []argov1alpha1.Application
+	var held []argoprojiov1alpha1.ApplicationSetApplicationStatus
+	stepStatuses := make([]argoprojiov1alpha1.ApplicationSetRolloutStepStatus, 0, len(steps))
+
+	previousStepsCompleted := true
+	for i, apps := range steps {
+		stepStatus := argoprojiov1alpha1.ApplicationSetRolloutStepStatus{
+			Step:         i + 1,
+			Applications: make([]string, 0, len(apps)),
+		}
+		for _, app := range apps {
+			stepStatus.Applications = append(stepStatus.Applications, app.Name)
+		}
+
+		if !previousStepsCompleted {
+			stepStatus.Status = argoprojiov1alpha1.ApplicationSetRolloutStepWaiting
+			stepStatus.Message = fmt.Sprintf("Waiting for step %d to complete", i)
+			now := metav1.Now()
+			for _, app := range apps {
+				held = append(held, argoprojiov1alpha1.ApplicationSetApplicationStatus{
+					Application:        app.Name,
+					Action:             argoprojiov1alpha1.ApplicationSetApplicationActionWaiting,
+					Message:            stepStatus.Message,
+					LastTransitionTime: &now,
+				})
+			}
+			stepStatuses = append(stepStatuses, stepStatus)
+			continue
+		}
+
+		allowed = append(allowed, apps...)
+
+		var pending, unsynced []string
+		for _, app := range apps {
+			live, exists := currentByName[app.Name]
+			// When updates are not allowed, the live spec is the one being rolled out
+			target := app.Spec
+			if exists && !updateAllowed {
+				target = live.Spec
+			}
+			if exists && isApplicationRolledOut(live, target) {
+				continue
+			}
+			pending = append(pending, app.Name)
+			// Nothing syncs the Applications without an automated sync policy, e.g. when it is disabled by hand
+			if !hasAutomatedSync(target) && (!exists || !isApplicationSynced(live, target)) {
+				unsynced = append(unsynced, app.Name)
+			}
+		}
+
+		switch {
+		case len(pending) == 0:
+			stepStatus.Status = argoprojiov1alpha1.ApplicationSetRolloutStepCompleted
+			stepStatus.Message = "All Applications are Healthy and Synced"
+		case len(unsynced) > 0:
+			stepStatus.Status = argoprojiov1alpha1.ApplicationSetRolloutStepStalled
+			stepStatus.Message = fmt.Sprintf("Applications without an automated sync policy must be synced: %s", strings.Join(unsynced, ", "))
+			previousStepsCompleted = false
+		default:
+			stepStatus.Status = argoprojiov1alpha1.ApplicationSetRolloutStepProgressing
+			stepStatus.Message = fmt.Sprintf("Waiting for Applications to be Healthy and Synced: %s", strings.Join(pending, ", "))
+			previousStepsCompleted = false
+		}
+		stepStatuses = append(stepStatuses, stepStatus)
+	}
+
+	return allowed, held, stepStatuses
+}
+
+// isApplicationRolledOut returns true if Argo CD reports the Application as Healthy, and Synced to the given spec.
+func isApplicationRolledOut(app argov1alpha1.Application, spec argov1alpha1.ApplicationSpec) bool {
+	return app.Status.Health.Status == health.HealthStatusHealthy && isApplicationSynced(app, spec)
+}
+
+// isApplicationSynced returns true if Argo CD reports the Application as Synced to the given spec. The comparison with
+// the spec ensures that an Application is not considered synced based on a status that predates its last update.
+func isApplicationSynced(app argov1alpha1.Application, spec argov1alpha1.ApplicationSpec) bool {
+	if app.Status.Sync.Status != argov1alpha1.SyncStatusCodeSynced {
+		return false
+	}
+
+	comparedTo := app.Status.Sync.ComparedTo
+	return apiequality.Semantic.DeepEqual(comparedTo.Source, spec.Source) &&
+		comparedTo.Destination.Server == spec.Destination.Server &&
+		comparedTo.Destination.Name == spec.Destination.Name &&
+		comparedTo.Destination.Namespace == spec.Destination.Namespace
+}
+
+// hasAutomatedSync returns true if Argo CD syncs the Application with the spec on its own.
+func hasAutomatedSync(spec argov1alpha1.ApplicationSpec) bool {
+	return spec.SyncPolicy != nil && spec.SyncPolicy.Automated != nil
+}
+
+// stalledRolloutStep returns the status of the step of the rolling sync which is stalled, if any.
+func stalledRolloutStep(stepStatuses []argoprojiov1alpha1.ApplicationSetRolloutStepStatus) *argoprojiov1alpha1.ApplicationSetRolloutStepStatus {
+	for i := range stepStatuses {
+		if stepStatuses[i].Status == argoprojiov1alpha1.ApplicationSetRolloutStepStalled {
+			return &stepStatuses[i]
+		}
+	}
+	return nil
+}
+
+// isRolloutCompleted returns true if all the steps of the rolling sync are completed.
+func isRolloutCompleted(stepStatuses []argoprojiov1alpha1.ApplicationSetRolloutStepStatus) bool {
+	for _, stepStatus := range stepStatuses {
+		if stepStatus.Status != argoprojiov1alpha1.ApplicationSetRolloutStepCompleted {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeRolloutStepStatuses returns the current step statuses, keeping the previous LastTransitionTime of the steps
+// whose status has not changed.
+func mergeRolloutStepStatuses(previous []argoprojiov1alpha1.ApplicationSetRolloutStepStatus, current []argoprojiov1alpha1.ApplicationSetRolloutStepStatus) []argoprojiov1alpha1.ApplicationSetRolloutStepStatus {
+	previousByStep := map[int]argoprojiov1alpha1.ApplicationSetRolloutStepStatus{}
+	for _, stepStatus := range previous {
+		previousByStep[stepStatus.Step] = stepStatus
+	}
+
+	now := metav1.Now()
+	res := make([]argoprojiov1alpha1.ApplicationSetRolloutStepStatus, 0, len(current))
+	for _, stepStatus := range current {
+		if existing, ok := previousByStep[stepStatus.Step]; ok && existing.Status == stepStatus.Status && existing.LastTransitionTime != nil {
+			stepStatus.LastTransitionTime = existing.LastTransitionTime
+		} else {
+			stepStatus.LastTransitionTime = &now
+		}
+		res = append(res, stepStatus)
+	}
+
+	return res
+}
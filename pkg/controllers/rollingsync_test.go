@@ -0,0 +1,348 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	argov1alpha1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+	"github.com/argoproj/gitops-engine/pkg/health"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	argoprojiov1alpha1 "github.com/argoproj-labs/applicationset/api/v1alpha1"
+)
+
+func rolloutTestApp(name, env, path string) argov1alpha1.Application {
+	return argov1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{"env": env},
+		},
+		Spec: argov1alpha1.ApplicationSpec{
+			Source:      argov1alpha1.ApplicationSource{RepoURL: "https://github.com/argoproj/argocd-example-apps", Path: path},
+			Destination: argov1alpha1.ApplicationDestination{Server: "https://kubernetes.default.svc", Namespace: "guestbook"},
+			SyncPolicy:  &argov1alpha1.SyncPolicy{Automated: &argov1alpha1.SyncPolicyAutomated{}},
+		},
+	}
+}
+
+// rolledOut returns the live version of app, as reported by Argo CD after syncing it
+func rolledOut(app argov1alpha1.Application, healthStatus health.HealthStatusCode, syncStatus argov1alpha1.SyncStatusCode) argov1alpha1.Application {
+	app.Status.Health.Status = healthStatus
+	app.Status.Sync.Status = syncStatus
+	app.Status.Sync.ComparedTo = argov1alpha1.ComparedTo{
+		Source:      app.Spec.Source,
+		Destination: app.Spec.Destination,
+	}
+	return app
+}
+
+func appNames(apps []argov1alpha1.Application) []string {
+	res := []string{}
+	for _, app := range apps {
+		res = append(res, app.Name)
+	}
+	return res
+}
+
+func TestGetRolloutSteps(t *testing.T) {
+	envSteps := &argoprojiov1alpha1.ApplicationSetRolloutStrategy{
+		Steps: []argoprojiov1alpha1.ApplicationSetRolloutStep{
+			{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "env", Operator: metav1.LabelSelectorOpIn, Values: []string{"dev"}}}},
+			{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "env", Operator: metav1.LabelSelectorOpIn, Values: []string{"staging"}}}},
+			{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "env", Operator: metav1.LabelSelectorOpIn, Values: []string{"prod"}}}},
+		},
+	}
+
+	apps := []argov1alpha1.Application{
+		rolloutTestApp("prod", "prod", "guestbook"),
+		rolloutTestApp("dev", "dev", "guestbook"),
+		rolloutTestApp("other", "other", "guestbook"),
+		rolloutTestApp("staging", "staging", "guestbook"),
+	}
+
+	for _, c := range []struct {
+		name          string
+		strategy      *argoprojiov1alpha1.ApplicationSetStrategy
+		expectedSteps [][]string
+		expectedErr   string
+	}{
+		{
+			name:          "no strategy",
+			strategy:      nil,
+			expectedSteps: nil,
+		},
+		{
+			name:          "all at once",
+			strategy:      &argoprojiov1alpha1.ApplicationSetStrategy{Type: argoprojiov1alpha1.ApplicationSetStrategyAllAtOnce},
+			expectedSteps: nil,
+		},
+		{
+			name: "rolling sync, applications that match no step are rolled out with the last step",
+			strategy: &argoprojiov1alpha1.ApplicationSetStrategy{
+				Type:        argoprojiov1alpha1.ApplicationSetStrategyRollingSync,
+				RollingSync: envSteps,
+			},
+			expectedSteps: [][]string{{"dev"}, {"staging"}, {"prod", "other"}},
+		},
+		{
+			name: "applications belong to the first step they match",
+			strategy: &argoprojiov1alpha1.ApplicationSetStrategy{
+				Type: argoprojiov1alpha1.ApplicationSetStrategyRollingSync,
+				RollingSync: &argoprojiov1alpha1.ApplicationSetRolloutStrategy{
+					Steps: []argoprojiov1alpha1.ApplicationSetRolloutStep{
+						{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "env", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"prod"}}}},
+						{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "env", Operator: metav1.LabelSelectorOpExists}}},
+					},
+				},
+			},
+			expectedSteps: [][]string{{"dev", "other", "staging"}, {"prod"}},
+		},
+		{
+			name:        "unknown strategy",
+			strategy:    &argoprojiov1alpha1.ApplicationSetStrategy{Type: "Canary"},
+			expectedErr: `unknown strategy type "Canary", strategy type can be: AllAtOnce, RollingSync`,
+		},
+		{
+			name:        "rolling sync without steps",
+			strategy:    &argoprojiov1alpha1.ApplicationSetStrategy{Type: argoprojiov1alpha1.ApplicationSetStrategyRollingSync},
+			expectedErr: "the RollingSync strategy requires at least one step",
+		},
+		{
+			name: "invalid match expression",
+			strategy: &argoprojiov1alpha1.ApplicationSetStrategy{
+				Type: argoprojiov1alpha1.ApplicationSetStrategyRollingSync,
+				RollingSync: &argoprojiov1alpha1.ApplicationSetRolloutStrategy{
+					Steps: []argoprojiov1alpha1.ApplicationSetRolloutStep{
+						{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "env", Operator: "Equals", Values: []string{"dev"}}}},
+					},
+				},
+			},
+			expectedErr: "invalid match expressions in step 1 of the RollingSync strategy",
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			appSet := argoprojiov1alpha1.ApplicationSet{
+				Spec: argoprojiov1alpha1.ApplicationSetSpec{
+					Strategy: c.strategy,
+				},
+			}
+
+			steps, err := getRolloutSteps(appSet, apps)
+
+			if c.expectedErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), c.expectedErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			if c.expectedSteps == nil {
+				assert.Nil(t, steps)
+				return
+			}
+
+			var stepNames [][]string
+			for _, step := range steps {
+				stepNames = append(stepNames, appNames(step))
+			}
+			assert.Equal(t, c.expectedSteps, stepNames)
+		})
+	}
+}
+
+func TestPlanRollout(t *testing.T) {
+	dev := rolloutTestApp("dev", "dev", "guestbook-v2")
+	staging := rolloutTestApp("staging", "staging", "guestbook-v2")
+	prod := rolloutTestApp("prod", "prod", "guestbook-v2")
+	steps := [][]argov1alpha1.Application{{dev}, {staging}, {prod}}
+
+	for _, c := range []struct {
+		name             string
+		current          []argov1alpha1.Application
+		updateAllowed    bool
+		expectedAllowed  []string
+		expectedHeld     []string
+		expectedStatuses []argoprojiov1alpha1.ApplicationSetRolloutStepStatusCode
+		expectedMessages []string
+	}{
+		{
+			name:             "the first step is rolled out first",
+			current:          nil,
+			updateAllowed:    true,
+			expectedAllowed:  []string{"dev"},
+			expectedHeld:     []string{"staging", "prod"},
+			expectedStatuses: []argoprojiov1alpha1.ApplicationSetRolloutStepStatusCode{argoprojiov1alpha1.ApplicationSetRolloutStepProgressing, argoprojiov1alpha1.ApplicationSetRolloutStepWaiting, argoprojiov1alpha1.ApplicationSetRolloutStepWaiting},
+			expectedMessages: []string{"Waiting for Applications to be Healthy and Synced: dev", "Waiting for step 1 to complete", "Waiting for step 2 to complete"},
+		},
+		{
+			name: "a step waits for the previous step to be synced to the new spec",
+			current: []argov1alpha1.Application{
+				rolledOut(rolloutTestApp("dev", "dev", "guestbook-v1"), health.HealthStatusHealthy, argov1alpha1.SyncStatusCodeSynced),
+				rolledOut(rolloutTestApp("staging", "staging", "guestbook-v1"), health.HealthStatusHealthy, argov1alpha1.SyncStatusCodeSynced),
+			},
+			updateAllowed:    true,
+			expectedAllowed:  []string{"dev"},
+			expectedHeld:     []string{"staging", "prod"},
+			expectedStatuses: []argoprojiov1alpha1.ApplicationSetRolloutStepStatusCode{argoprojiov1alpha1.ApplicationSetRolloutStepProgressing, argoprojiov1alpha1.ApplicationSetRolloutStepWaiting, argoprojiov1alpha1.ApplicationSetRolloutStepWaiting},
+			expectedMessages: []string{"Waiting for Applications to be Healthy and Synced: dev", "Waiting for step 1 to complete", "Waiting for step 2 to complete"},
+		},
+		{
+			name: "a step waits for the previous step to be healthy",
+			current: []argov1alpha1.Application{
+				rolledOut(dev, health.HealthStatusHealthy, argov1alpha1.SyncStatusCodeSynced),
+				rolledOut(staging, health.HealthStatusProgressing, argov1alpha1.SyncStatusCodeSynced),
+			},
+			updateAllowed:    true,
+			expectedAllowed:  []string{"dev", "staging"},
+			expectedHeld:     []string{"prod"},
+			expectedStatuses: []argoprojiov1alpha1.ApplicationSetRolloutStepStatusCode{argoprojiov1alpha1.ApplicationSetRolloutStepCompleted, argoprojiov1alpha1.ApplicationSetRolloutStepProgressing, argoprojiov1alpha1.ApplicationSetRolloutStepWaiting},
+			expectedMessages: []string{"All Applications are Healthy and Synced", "Waiting for Applications to be Healthy and Synced: staging", "Waiting for step 2 to complete"},
+		},
+		{
+			name: "a step waits for the previous step to be synced",
+			current: []argov1alpha1.Application{
+				rolledOut(dev, health.HealthStatusHealthy, argov1alpha1.SyncStatusCodeOutOfSync),
+			},
+			updateAllowed:    true,
+			expectedAllowed:  []string{"dev"},
+			expectedHeld:     []string{"staging", "prod"},
+			expectedStatuses: []argoprojiov1alpha1.ApplicationSetRolloutStepStatusCode{argoprojiov1alpha1.ApplicationSetRolloutStepProgressing, argoprojiov1alpha1.ApplicationSetRolloutStepWaiting, argoprojiov1alpha1.ApplicationSetRolloutStepWaiting},
+			expectedMessages: []string{"Waiting for Applications to be Healthy and Synced: dev", "Waiting for step 1 to complete", "Waiting for step 2 to complete"},
+		},
+		{
+			name: "all steps are completed",
+			current: []argov1alpha1.Application{
+				rolledOut(dev, health.HealthStatusHealthy, argov1alpha1.SyncStatusCodeSynced),
+				rolledOut(staging, health.HealthStatusHealthy, argov1alpha1.SyncStatusCodeSynced),
+				rolledOut(prod, health.HealthStatusHealthy, argov1alpha1.SyncStatusCodeSynced),
+			},
+			updateAllowed:    true,
+			expectedAllowed:  []string{"dev", "staging", "prod"},
+			expectedHeld:     []string{},
+			expectedStatuses: []argoprojiov1alpha1.ApplicationSetRolloutStepStatusCode{argoprojiov1alpha1.ApplicationSetRolloutStepCompleted, argoprojiov1alpha1.ApplicationSetRolloutStepCompleted, argoprojiov1alpha1.ApplicationSetRolloutStepCompleted},
+			expectedMessages: []string{"All Applications are Healthy and Synced", "All Applications are Healthy and Synced", "All Applications are Healthy and Synced"},
+		},
+		{
+			name: "without updates, the live spec is rolled out",
+			current: []argov1alpha1.Application{
+				rolledOut(rolloutTestApp("dev", "dev", "guestbook-v1"), health.HealthStatusHealthy, argov1alpha1.SyncStatusCodeSynced),
+			},
+			updateAllowed:    false,
+			expectedAllowed:  []string{"dev", "staging"},
+			expectedHeld:     []string{"prod"},
+			expectedStatuses: []argoprojiov1alpha1.ApplicationSetRolloutStepStatusCode{argoprojiov1alpha1.ApplicationSetRolloutStepCompleted, argoprojiov1alpha1.ApplicationSetRolloutStepProgressing, argoprojiov1alpha1.ApplicationSetRolloutStepWaiting},
+			expectedMessages: []string{"All Applications are Healthy and Synced", "Waiting for Applications to be Healthy and Synced: staging", "Waiting for step 2 to complete"},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			currentByName := map[string]argov1alpha1.Application{}
+			for _, app := range c.current {
+				currentByName[app.Name] = app
+			}
+
+			allowed, held, stepStatuses := planRollout(steps, currentByName, c.updateAllowed)
+
+			assert.Equal(t, c.expectedAllowed, appNames(allowed))
+
+			heldNames := []string{}
+			for _, appStatus := range held {
+				heldNames = append(heldNames, appStatus.Application)
+				assert.Equal(t, argoprojiov1alpha1.ApplicationSetApplicationActionWaiting, appStatus.Action)
+				assert.Contains(t, appStatus.Message, "Waiting for step")
+			}
+			assert.Equal(t, c.expectedHeld, heldNames)
+
+			if assert.Len(t, stepStatuses, len(steps)) {
+				for i, stepStatus := range stepStatuses {
+					assert.Equal(t, i+1, stepStatus.Step)
+					assert.Equal(t, appNames(steps[i]), stepStatus.Applications)
+					assert.Equal(t, c.expectedStatuses[i], stepStatus.Status)
+					assert.Equal(t, c.expectedMessages[i], stepStatus.Message)
+				}
+			}
+		})
+	}
+}
+
+func TestPlanRolloutWithoutAutomatedSync(t *testing.T) {
+	dev := rolloutTestApp("dev", "dev", "guestbook-v2")
+	dev.Spec.SyncPolicy = nil
+	staging := rolloutTestApp("staging", "staging", "guestbook-v2")
+	steps := [][]argov1alpha1.Application{{dev}, {staging}}
+
+	for _, c := range []struct {
+		name             string
+		current          []argov1alpha1.Application
+		expectedStatuses []argoprojiov1alpha1.ApplicationSetRolloutStepStatusCode
+		expectedMessages []string
+	}{
+		{
+			name:             "a new Application is never synced",
+			current:          nil,
+			expectedStatuses: []argoprojiov1alpha1.ApplicationSetRolloutStepStatusCode{argoprojiov1alpha1.ApplicationSetRolloutStepStalled, argoprojiov1alpha1.ApplicationSetRolloutStepWaiting},
+			expectedMessages: []string{"Applications without an automated sync policy must be synced: dev", "Waiting for step 1 to complete"},
+		},
+		{
+			name: "an updated Application is never synced",
+			current: []argov1alpha1.Application{
+				rolledOut(rolloutTestApp("dev", "dev", "guestbook-v1"), health.HealthStatusHealthy, argov1alpha1.SyncStatusCodeSynced),
+			},
+			expectedStatuses: []argoprojiov1alpha1.ApplicationSetRolloutStepStatusCode{argoprojiov1alpha1.ApplicationSetRolloutStepStalled, argoprojiov1alpha1.ApplicationSetRolloutStepWaiting},
+			expectedMessages: []string{"Applications without an automated sync policy must be synced: dev", "Waiting for step 1 to complete"},
+		},
+		{
+			name: "a manually synced Application is waited for",
+			current: []argov1alpha1.Application{
+				rolledOut(dev, health.HealthStatusProgressing, argov1alpha1.SyncStatusCodeSynced),
+			},
+			expectedStatuses: []argoprojiov1alpha1.ApplicationSetRolloutStepStatusCode{argoprojiov1alpha1.ApplicationSetRolloutStepProgressing, argoprojiov1alpha1.ApplicationSetRolloutStepWaiting},
+			expectedMessages: []string{"Waiting for Applications to be Healthy and Synced: dev", "Waiting for step 1 to complete"},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			currentByName := map[string]argov1alpha1.Application{}
+			for _, app := range c.current {
+				currentByName[app.Name] = app
+			}
+
+			allowed, _, stepStatuses := planRollout(steps, currentByName, true)
+			assert.Equal(t, []string{"dev"}, appNames(allowed))
+
+			if assert.Len(t, stepStatuses, len(steps)) {
+				for i, stepStatus := range stepStatuses {
+					assert.Equal(t, c.expectedStatuses[i], stepStatus.Status)
+					assert.Equal(t, c.expectedMessages[i], stepStatus.Message)
+				}
+			}
+			assert.Equal(t, c.expectedStatuses[0] == argoprojiov1alpha1.ApplicationSetRolloutStepStalled, stalledRolloutStep(stepStatuses) != nil)
+		})
+	}
+}
+
+func TestMergeRolloutStepStatuses(t *testing.T) {
+	previousTime := metav1.NewTime(time.Now().Add(-time.Hour))
+
+	previous := []argoprojiov1alpha1.ApplicationSetRolloutStepStatus{
+		{Step: 1, Status: argoprojiov1alpha1.ApplicationSetRolloutStepCompleted, LastTransitionTime: &previousTime},
+		{Step: 2, Status: argoprojiov1alpha1.ApplicationSetRolloutStepWaiting, LastTransitionTime: &previousTime},
+	}
+	current := []argoprojiov1alpha1.ApplicationSetRolloutStepStatus{
+		{Step: 1, Status: argoprojiov1alpha1.ApplicationSetRolloutStepCompleted},
+		{Step: 2, Status: argoprojiov1alpha1.ApplicationSetRolloutStepProgressing},
+		{Step: 3, Status: argoprojiov1alpha1.ApplicationSetRolloutStepWaiting},
+	}
+
+	res := mergeRolloutStepStatuses(previous, current)
+
+	if assert.Len(t, res, 3) {
+		assert.Equal(t, previousTime, *res[0].LastTransitionTime)
+		assert.True(t, res[1].LastTransitionTime.After(previousTime.Time))
+		assert.Equal(t, argoprojiov1alpha1.ApplicationSetRolloutStepProgressing, res[1].Status)
+		assert.NotNil(t, res[2].LastTransitionTime)
+	}
+
+	assert.False(t, isRolloutCompleted(res))
+	assert.True(t, isRolloutCompleted(res[:1]))
+	assert.True(t, isRolloutCompleted(nil))
+}
@@ -2,11 +2,15 @@ package generators
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/Masterminds/semver"
+	appv1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
 	"github.com/argoproj/argo-cd/v2/util/settings"
 	log "github.com/sirupsen/logrus"
 
@@ -34,9 +38,11 @@ type ClusterGenerator struct {
 	// namespace is the Argo CD namespace
 	namespace       string
 	settingsManager *settings.SettingsManager
+	// clusterInfo fills in the server versions and connection states of the clusters, unless it is nil
+	clusterInfo *utils.ClusterInfoCache
 }
 
-func NewClusterGenerator(c client.Client, ctx context.Context, clientset kubernetes.Interface, namespace string) Generator {
+func NewClusterGenerator(c client.Client, ctx context.Context, clientset kubernetes.Interface, namespace string, clusterInfo *utils.ClusterInfoCache) Generator {
 
 	settingsManager := settings.NewSettingsManager(ctx, clientset, namespace)
 
@@ -46,12 +52,22 @@ func NewClusterGenerator(c client.Client, ctx context.Context, clientset kuberne
 		clientset:       clientset,
 		namespace:       namespace,
 		settingsManager: settingsManager,
+		clusterInfo:     clusterInfo,
 	}
 	return g
 }
 
-func (g *ClusterGenerator) GetRequeueAfter(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator) time.Duration {
-	return NoRequeueAfter
+// GetRequeueAfter returns the refresh interval of the info of the clusters when the ApplicationSet uses it, as it
+// changes without any change of the cluster Secrets.
+func (g *ClusterGenerator) GetRequeueAfter(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator, appSet *argoprojiov1alpha1.ApplicationSet) time.Duration {
+	if g.clusterInfo == nil {
+		return NoRequeueAfter
+	}
+	needsClusterInfo, err := UsesClusterInfo(appSetGenerator, appSet)
+	if err != nil || !needsClusterInfo {
+		return NoRequeueAfter
+	}
+	return g.clusterInfo.RefreshInterval()
 }
 
 func (g *ClusterGenerator) GetTemplate(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator) *argoprojiov1alpha1.ApplicationSetTemplate {
@@ -59,7 +75,7 @@ func (g *ClusterGenerator) GetTemplate(appSetGenerator *argoprojiov1alpha1.Appli
 }
 
 func (g *ClusterGenerator) GenerateParams(
-	appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator, _ *argoprojiov1alpha1.ApplicationSet) ([]map[string]string, error) {
+	appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator, appSet *argoprojiov1alpha1.ApplicationSet) ([]map[string]interface{}, error) {
 
 	if appSetGenerator == nil {
 		return nil, EmptyAppSetGeneratorError
@@ -83,43 +99,68 @@ func (g *ClusterGenerator) GenerateParams(
 		return nil, nil
 	}
 
+	filter, err := newClusterFilter(appSetGenerator.Clusters)
+	if err != nil {
+		return nil, err
+	}
+
 	clusterSecrets, err := g.getSecretsByClusterName(appSetGenerator)
 	if err != nil {
 		return nil, err
 	}
 
-	res := []map[string]string{}
+	res := []map[string]interface{}{}
 
+	localClusters := []*appv1.Cluster{}
+	secretClusters := []*appv1.Cluster{}
 	secretsFound := []corev1.Secret{}
 
-	for _, cluster := range clustersFromArgoCD.Items {
+	for i := range clustersFromArgoCD.Items {
+		cluster := &clustersFromArgoCD.Items[i]
 
 		// If there is a secret for this cluster, then it's a non-local cluster, so it will be
 		// handled by the next step.
 		if secretForCluster, exists := clusterSecrets[cluster.Name]; exists {
+			secretClusters = append(secretClusters, cluster)
 			secretsFound = append(secretsFound, secretForCluster)
 
 		} else if !ignoreLocalClusters {
-			// If there is no secret for the cluster, it's the local cluster, so handle it here.
-			params := map[string]string{}
-			params["name"] = cluster.Name
-			params["server"] = cluster.Server
+			// If there is no secret for the cluster, it's the local cluster
+			localClusters = append(localClusters, cluster)
+		}
+	}
 
-			for key, value := range appSetGenerator.Clusters.Values {
-				params[fmt.Sprintf("values.%s", key)] = value
-			}
+	// Probing the clusters takes time, so it is only done when their info is used
+	needsClusterInfo, err := UsesClusterInfo(appSetGenerator, appSet)
+	if err != nil {
+		return nil, err
+	}
+	if needsClusterInfo {
+		g.clusterInfo.SetClusterInfo(append(append([]*appv1.Cluster{}, localClusters...), secretClusters...))
+	}
+	localClusters, secretClusters, secretsFound = filter.apply(localClusters, secretClusters, secretsFound)
 
-			log.WithField("cluster", "local cluster").Info("matched local cluster")
+	for _, cluster := range localClusters {
+		params := map[string]interface{}{}
+		params["name"] = cluster.Name
+		params["server"] = cluster.Server
+		addClusterInfoParams(params, cluster)
 
-			res = append(res, params)
+		for key, value := range appSetGenerator.Clusters.Values {
+			params[fmt.Sprintf("values.%s", key)] = value
 		}
+
+		log.WithField("cluster", "local cluster").Info("matched local cluster")
+
+		res = append(res, params)
 	}
 
 	// For each matching cluster secret (non-local clusters only)
-	for _, cluster := range secretsFound {
-		params := map[string]string{}
+	for i, cluster := range secretsFound {
+		params := map[string]interface{}{}
 		params["name"] = sanitizeName(string(cluster.Data["name"]))
 		params["server"] = string(cluster.Data["server"])
+		addClusterInfoParams(params, secretClusters[i])
 		for key, value := range cluster.ObjectMeta.Annotations {
 			params[fmt.Sprintf("metadata.annotations.%s", key)] = value
 		}
@@ -137,6 +178,122 @@ func (g *ClusterGenerator) GenerateParams(
 	return res, nil
 }
 
+// clusterInfoParamPattern matches the references to the params which require probing the clusters
+var clusterInfoParamPattern = regexp.MustCompile(`serverVersion|connectionState\.`)
+
+// UsesClusterInfo returns whether the server versions and connection states of the clusters are used, either to filter
+// them or as params referenced by the ApplicationSet, e.g. by its template or by the template of the generator.
+func UsesClusterInfo(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator, appSet *argoprojiov1alpha1.ApplicationSet) (bool, error) {
+	if appSetGenerator.Clusters.ServerVersion != "" || appSetGenerator.Clusters.ExcludeFailedClusters {
+		return true, nil
+	}
+
+	references := []interface{}{appSetGenerator.Clusters.Template}
+	if appSet != nil {
+		references = append(references, appSet.Spec)
+	}
+	for _, reference := range references {
+		data, err := json.Marshal(reference)
+		if err != nil {
+			return false, err
+		}
+		if clusterInfoParamPattern.Match(data) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// addClusterInfoParams adds the params describing the cluster as Argo CD knows it: the list of the namespaces the
+// cluster is restricted to, its shard, the version of its API server and the state of the connection to it. The
+// namespaces are empty and the shard is "" when they are not set.
+func addClusterInfoParams(params map[string]interface{}, cluster *appv1.Cluster) {
+	namespaces := make([]interface{}, 0, len(cluster.Namespaces))
+	for _, namespace := range cluster.Namespaces {
+		namespaces = append(namespaces, namespace)
+	}
+	params["namespaces"] = namespaces
+	params["shard"] = ""
+	if cluster.Shard != nil {
+		params["shard"] = strconv.FormatInt(*cluster.Shard, 10)
+	}
+	params["serverVersion"] = cluster.ServerVersion
+
+	status := cluster.ConnectionState.Status
+	if status == "" {
+		status = appv1.ConnectionStatusUnknown
+	}
+	params["connectionState.status"] = string(status)
+	params["connectionState.message"] = cluster.ConnectionState.Message
+}
+
+// clusterFilter selects clusters by their server version and connection state.
+type clusterFilter struct {
+	serverVersion *semver.Constraints
+	excludeFailed bool
+}
+
+func newClusterFilter(generator *argoprojiov1alpha1.ClusterGenerator) (*clusterFilter, error) {
+	filter := &clusterFilter{excludeFailed: generator.ExcludeFailedClusters}
+	if generator.ServerVersion != "" {
+		constraint, err := semver.NewConstraint(generator.ServerVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid server version constraint '%s': %v", generator.ServerVersion, err)
+		}
+		filter.serverVersion = constraint
+	}
+	return filter, nil
+}
+
+// matches returns whether the cluster is selected by the filter.
+func (f *clusterFilter) matches(cluster *appv1.Cluster) bool {
+	if f.excludeFailed && cluster.ConnectionState.Status == appv1.ConnectionStatusFailed {
+		return false
+	}
+	if f.serverVersion != nil {
+		version, err := parseServerVersion(cluster.ServerVersion)
+		if err != nil {
+			log.WithField("cluster", cluster.Name).WithError(err).Debug("excluding cluster of unknown server version")
+			return false
+		}
+		return f.serverVersion.Check(version)
+	}
+	return true
+}
+
+// apply returns the local clusters, and the clusters of secrets along with their secrets, which match the filter.
+func (f *clusterFilter) apply(localClusters []*appv1.Cluster, secretClusters []*appv1.Cluster, secrets []corev1.Secret) ([]*appv1.Cluster, []*appv1.Cluster, []corev1.Secret) {
+	matchedLocalClusters := []*appv1.Cluster{}
+	for _, cluster := range localClusters {
+		if f.matches(cluster) {
+			matchedLocalClusters = append(matchedLocalClusters, cluster)
+		}
+	}
+
+	matchedSecretClusters := []*appv1.Cluster{}
+	matchedSecrets := []corev1.Secret{}
+	for i, cluster := range secretClusters {
+		if f.matches(cluster) {
+			matchedSecretClusters = append(matchedSecretClusters, cluster)
+			matchedSecrets = append(matchedSecrets, secrets[i])
+		}
+	}
+
+	return matchedLocalClusters, matchedSecretClusters, matchedSecrets
+}
+
+// serverVersionPattern matches the major and minor versions of API servers, which some providers suffix, e.g. '1.21+'
+var serverVersionPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)`)
+
+// parseServerVersion parses the version of an API server, as '<major>.<minor>', into a semantic version.
+func parseServerVersion(serverVersion string) (*semver.Version, error) {
+	match := serverVersionPattern.FindStringSubmatch(serverVersion)
+	if match == nil {
+		return nil, fmt.Errorf("invalid server version '%s'", serverVersion)
+	}
+	return semver.NewVersion(fmt.Sprintf("%s.%s.0", match[1], match[2]))
+}
+
 func (g *ClusterGenerator) getSecretsByClusterName(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator) (map[string]corev1.Secret, error) {
 	// List all Clusters:
 	clusterSecretList := &corev1.SecretList{}
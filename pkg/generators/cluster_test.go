@@ -3,6 +3,9 @@ package generators
 import (
 	"context"
 	"errors"
+	"strings"
+	"sync"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -13,6 +16,8 @@ import (
 	"testing"
 
 	argoprojiov1alpha1 "github.com/argoproj-labs/applicationset/api/v1alpha1"
+	"github.com/argoproj-labs/applicationset/pkg/utils"
+	argoappv1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
 	kubefake "k8s.io/client-go/kubernetes/fake"
 
 	"github.com/stretchr/testify/assert"
@@ -30,6 +35,18 @@ func (p *possiblyErroringFakeCtrlRuntimeClient) List(ctx context.Context, secret
 	return p.Client.List(ctx, secretList, opts...)
 }
 
+// fakeServerVersionProbe fails to connect to the production clusters, and reports version 1.21 for the local cluster
+// and 1.20 for the others.
+func fakeServerVersionProbe(cluster *argoappv1.Cluster) (string, error) {
+	if strings.Contains(cluster.Server, "production") {
+		return "", errors.New("connection refused")
+	}
+	if cluster.Server == "https://kubernetes.default.svc" {
+		return "1.21", nil
+	}
+	return "1.20", nil
+}
+
 func TestGenerateParams(t *testing.T) {
 	clusters := []client.Object{
 		&corev1.Secret{
@@ -74,18 +91,22 @@ func TestGenerateParams(t *testing.T) {
 				},
 			},
 			Data: map[string][]byte{
-				"config": []byte("{}"),
-				"name":   []byte("production-01"),
-				"server": []byte("https://production-01.example.com"),
+				"config":     []byte("{}"),
+				"name":       []byte("production-01"),
+				"server":     []byte("https://production-01.example.com"),
+				"namespaces": []byte("guestbook, default"),
+				"shard":      []byte("1"),
 			},
 			Type: corev1.SecretType("Opaque"),
 		},
 	}
 	testCases := []struct {
-		name     string
-		selector metav1.LabelSelector
-		values   map[string]string
-		expected []map[string]string
+		name                  string
+		selector              metav1.LabelSelector
+		values                map[string]string
+		serverVersion         string
+		excludeFailedClusters bool
+		expected              []map[string]interface{}
 		// clientError is true if a k8s client error should be simulated
 		clientError   bool
 		expectedError error
@@ -94,14 +115,17 @@ func TestGenerateParams(t *testing.T) {
 			name:     "no label selector",
 			selector: metav1.LabelSelector{},
 			values:   nil,
-			expected: []map[string]string{
+			expected: []map[string]interface{}{
 				{"name": "production-01", "server": "https://production-01.example.com", "metadata.labels.environment": "production", "metadata.labels.org": "bar",
-					"metadata.labels.argocd.argoproj.io/secret-type": "cluster", "metadata.annotations.foo.argoproj.io": "production"},
+					"metadata.labels.argocd.argoproj.io/secret-type": "cluster", "metadata.annotations.foo.argoproj.io": "production",
+					"namespaces": []interface{}{"guestbook", "default"}, "shard": "1", "serverVersion": "", "connectionState.status": "Failed", "connectionState.message": "connection refused"},
 
 				{"name": "staging-01", "server": "https://staging-01.example.com", "metadata.labels.environment": "staging", "metadata.labels.org": "foo",
-					"metadata.labels.argocd.argoproj.io/secret-type": "cluster", "metadata.annotations.foo.argoproj.io": "staging"},
+					"metadata.labels.argocd.argoproj.io/secret-type": "cluster", "metadata.annotations.foo.argoproj.io": "staging",
+					"namespaces": []interface{}{}, "shard": "", "serverVersion": "1.20", "connectionState.status": "Successful", "connectionState.message": ""},
 
-				{"name": "in-cluster", "server": "https://kubernetes.default.svc"},
+				{"name": "in-cluster", "server": "https://kubernetes.default.svc",
+					"namespaces": []interface{}{}, "shard": "", "serverVersion": "1.21", "connectionState.status": "Successful", "connectionState.message": ""},
 			},
 			clientError:   false,
 			expectedError: nil,
@@ -114,12 +138,14 @@ func TestGenerateParams(t *testing.T) {
 				},
 			},
 			values: nil,
-			expected: []map[string]string{
+			expected: []map[string]interface{}{
 				{"name": "production-01", "server": "https://production-01.example.com", "metadata.labels.environment": "production", "metadata.labels.org": "bar",
-					"metadata.labels.argocd.argoproj.io/secret-type": "cluster", "metadata.annotations.foo.argoproj.io": "production"},
+					"metadata.labels.argocd.argoproj.io/secret-type": "cluster", "metadata.annotations.foo.argoproj.io": "production",
+					"namespaces": []interface{}{"guestbook", "default"}, "shard": "1", "serverVersion": "", "connectionState.status": "Failed", "connectionState.message": "connection refused"},
 
 				{"name": "staging-01", "server": "https://staging-01.example.com", "metadata.labels.environment": "staging", "metadata.labels.org": "foo",
-					"metadata.labels.argocd.argoproj.io/secret-type": "cluster", "metadata.annotations.foo.argoproj.io": "staging"},
+					"metadata.labels.argocd.argoproj.io/secret-type": "cluster", "metadata.annotations.foo.argoproj.io": "staging",
+					"namespaces": []interface{}{}, "shard": "", "serverVersion": "1.20", "connectionState.status": "Successful", "connectionState.message": ""},
 			},
 			clientError:   false,
 			expectedError: nil,
@@ -134,9 +160,10 @@ func TestGenerateParams(t *testing.T) {
 			values: map[string]string{
 				"foo": "bar",
 			},
-			expected: []map[string]string{
+			expected: []map[string]interface{}{
 				{"values.foo": "bar", "name": "production-01", "server": "https://production-01.example.com", "metadata.labels.environment": "production", "metadata.labels.org": "bar",
-					"metadata.labels.argocd.argoproj.io/secret-type": "cluster", "metadata.annotations.foo.argoproj.io": "production"},
+					"metadata.labels.argocd.argoproj.io/secret-type": "cluster", "metadata.annotations.foo.argoproj.io": "production",
+					"namespaces": []interface{}{"guestbook", "default"}, "shard": "1", "serverVersion": "", "connectionState.status": "Failed", "connectionState.message": "connection refused"},
 			},
 			clientError:   false,
 			expectedError: nil,
@@ -158,11 +185,13 @@ func TestGenerateParams(t *testing.T) {
 			values: map[string]string{
 				"foo": "bar",
 			},
-			expected: []map[string]string{
+			expected: []map[string]interface{}{
 				{"values.foo": "bar", "name": "staging-01", "server": "https://staging-01.example.com", "metadata.labels.environment": "staging", "metadata.labels.org": "foo",
-					"metadata.labels.argocd.argoproj.io/secret-type": "cluster", "metadata.annotations.foo.argoproj.io": "staging"},
+					"metadata.labels.argocd.argoproj.io/secret-type": "cluster", "metadata.annotations.foo.argoproj.io": "staging",
+					"namespaces": []interface{}{}, "shard": "", "serverVersion": "1.20", "connectionState.status": "Successful", "connectionState.message": ""},
 				{"values.foo": "bar", "name": "production-01", "server": "https://production-01.example.com", "metadata.labels.environment": "production", "metadata.labels.org": "bar",
-					"metadata.labels.argocd.argoproj.io/secret-type": "cluster", "metadata.annotations.foo.argoproj.io": "production"},
+					"metadata.labels.argocd.argoproj.io/secret-type": "cluster", "metadata.annotations.foo.argoproj.io": "production",
+					"namespaces": []interface{}{"guestbook", "default"}, "shard": "1", "serverVersion": "", "connectionState.status": "Failed", "connectionState.message": "connection refused"},
 			},
 			clientError:   false,
 			expectedError: nil,
@@ -187,13 +216,48 @@ func TestGenerateParams(t *testing.T) {
 			values: map[string]string{
 				"name": "baz",
 			},
-			expected: []map[string]string{
+			expected: []map[string]interface{}{
 				{"values.name": "baz", "name": "staging-01", "server": "https://staging-01.example.com", "metadata.labels.environment": "staging", "metadata.labels.org": "foo",
-					"metadata.labels.argocd.argoproj.io/secret-type": "cluster", "metadata.annotations.foo.argoproj.io": "staging"},
+					"metadata.labels.argocd.argoproj.io/secret-type": "cluster", "metadata.annotations.foo.argoproj.io": "staging",
+					"namespaces": []interface{}{}, "shard": "", "serverVersion": "1.20", "connectionState.status": "Successful", "connectionState.message": ""},
+			},
+			clientError:   false,
+			expectedError: nil,
+		},
+		{
+			name:          "server version constraint",
+			selector:      metav1.LabelSelector{},
+			serverVersion: ">=1.21",
+			expected: []map[string]interface{}{
+				{"name": "in-cluster", "server": "https://kubernetes.default.svc",
+					"namespaces": []interface{}{}, "shard": "", "serverVersion": "1.21", "connectionState.status": "Successful", "connectionState.message": ""},
+			},
+			clientError:   false,
+			expectedError: nil,
+		},
+		{
+			name:                  "exclude failed clusters",
+			selector:              metav1.LabelSelector{},
+			excludeFailedClusters: true,
+			expected: []map[string]interface{}{
+				{"name": "staging-01", "server": "https://staging-01.example.com", "metadata.labels.environment": "staging", "metadata.labels.org": "foo",
+					"metadata.labels.argocd.argoproj.io/secret-type": "cluster", "metadata.annotations.foo.argoproj.io": "staging",
+					"namespaces": []interface{}{}, "shard": "", "serverVersion": "1.20", "connectionState.status": "Successful", "connectionState.message": ""},
+
+				{"name": "in-cluster", "server": "https://kubernetes.default.svc",
+					"namespaces": []interface{}{}, "shard": "", "serverVersion": "1.21", "connectionState.status": "Successful", "connectionState.message": ""},
 			},
 			clientError:   false,
 			expectedError: nil,
 		},
+		{
+			name:          "invalid server version constraint",
+			selector:      metav1.LabelSelector{},
+			serverVersion: "latest",
+			expected:      nil,
+			clientError:   false,
+			expectedError: errors.New("invalid server version constraint 'latest': improper constraint: latest"),
+		},
 		{
 			name:          "simulate client error",
 			selector:      metav1.LabelSelector{},
@@ -210,6 +274,17 @@ func TestGenerateParams(t *testing.T) {
 		runtimeClusters = append(runtimeClusters, clientCluster)
 	}
 
+	// The ApplicationSet references the info of the clusters, so that they are probed
+	clusterInfoAppSet := &argoprojiov1alpha1.ApplicationSet{
+		Spec: argoprojiov1alpha1.ApplicationSetSpec{
+			Template: argoprojiov1alpha1.ApplicationSetTemplate{
+				ApplicationSetTemplateMeta: argoprojiov1alpha1.ApplicationSetTemplateMeta{
+					Labels: map[string]string{"connection": "{{connectionState.status}}"},
+				},
+			},
+		},
+	}
+
 	for _, testCase := range testCases {
 
 		t.Run(testCase.name, func(t *testing.T) {
@@ -222,14 +297,16 @@ func TestGenerateParams(t *testing.T) {
 				testCase.clientError,
 			}
 
-			var clusterGenerator = NewClusterGenerator(cl, context.Background(), appClientset, "namespace")
+			var clusterGenerator = NewClusterGenerator(cl, context.Background(), appClientset, "namespace", utils.NewClusterInfoCache(time.Minute, fakeServerVersionProbe))
 
 			got, err := clusterGenerator.GenerateParams(&argoprojiov1alpha1.ApplicationSetGenerator{
 				Clusters: &argoprojiov1alpha1.ClusterGenerator{
-					Selector: testCase.selector,
-					Values:   testCase.values,
+					Selector:              testCase.selector,
+					Values:                testCase.values,
+					ServerVersion:         testCase.serverVersion,
+					ExcludeFailedClusters: testCase.excludeFailedClusters,
 				},
-			}, nil)
+			}, clusterInfoAppSet)
 
 			if testCase.expectedError != nil {
 				assert.EqualError(t, err, testCase.expectedError.Error())
@@ -242,6 +319,96 @@ func TestGenerateParams(t *testing.T) {
 	}
 }
 
+func TestGenerateParamsProbesClustersOnlyWhenNeeded(t *testing.T) {
+	cluster := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "staging-01",
+			Namespace: "namespace",
+			Labels:    map[string]string{"argocd.argoproj.io/secret-type": "cluster"},
+		},
+		Data: map[string][]byte{
+			"config": []byte("{}"),
+			"name":   []byte("staging-01"),
+			"server": []byte("https://staging-01.example.com"),
+		},
+	}
+
+	for _, c := range []struct {
+		name           string
+		generator      *argoprojiov1alpha1.ClusterGenerator
+		appSet         *argoprojiov1alpha1.ApplicationSet
+		expectedProbes int
+	}{
+		{
+			name:           "the info of the clusters is not used",
+			generator:      &argoprojiov1alpha1.ClusterGenerator{},
+			appSet:         &argoprojiov1alpha1.ApplicationSet{},
+			expectedProbes: 0,
+		},
+		{
+			name:           "the clusters are filtered by their connection state",
+			generator:      &argoprojiov1alpha1.ClusterGenerator{ExcludeFailedClusters: true},
+			appSet:         &argoprojiov1alpha1.ApplicationSet{},
+			expectedProbes: 2,
+		},
+		{
+			name: "the template of the generator references the server version",
+			generator: &argoprojiov1alpha1.ClusterGenerator{Template: argoprojiov1alpha1.ApplicationSetTemplate{
+				ApplicationSetTemplateMeta: argoprojiov1alpha1.ApplicationSetTemplateMeta{Labels: map[string]string{"version": "{{serverVersion}}"}},
+			}},
+			appSet:         nil,
+			expectedProbes: 2,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			// The local cluster and the cluster of the Secret are probed concurrently
+			var lock sync.Mutex
+			probes := 0
+			probe := func(cluster *argoappv1.Cluster) (string, error) {
+				lock.Lock()
+				defer lock.Unlock()
+				probes++
+				return "1.20", nil
+			}
+
+			clusterGenerator := NewClusterGenerator(fake.NewClientBuilder().WithObjects(cluster).Build(), context.Background(),
+				kubefake.NewSimpleClientset(cluster), "namespace", utils.NewClusterInfoCache(time.Minute, probe))
+			_, err := clusterGenerator.GenerateParams(&argoprojiov1alpha1.ApplicationSetGenerator{Clusters: c.generator}, c.appSet)
+
+			assert.NoError(t, err)
+			assert.Equal(t, c.expectedProbes, probes)
+		})
+	}
+}
+
+func TestClusterGetRequeueAfter(t *testing.T) {
+	clusterInfo := utils.NewClusterInfoCache(2*time.Minute, fakeServerVersionProbe)
+	generator := NewClusterGenerator(fake.NewClientBuilder().Build(), context.Background(), kubefake.NewSimpleClientset(), "namespace", clusterInfo)
+
+	// The clusters are only requeued when their info is used
+	assert.Equal(t, NoRequeueAfter, generator.GetRequeueAfter(&argoprojiov1alpha1.ApplicationSetGenerator{
+		Clusters: &argoprojiov1alpha1.ClusterGenerator{},
+	}, &argoprojiov1alpha1.ApplicationSet{}))
+	assert.Equal(t, 2*time.Minute, generator.GetRequeueAfter(&argoprojiov1alpha1.ApplicationSetGenerator{
+		Clusters: &argoprojiov1alpha1.ClusterGenerator{ServerVersion: ">=1.21"},
+	}, nil))
+	assert.Equal(t, 2*time.Minute, generator.GetRequeueAfter(&argoprojiov1alpha1.ApplicationSetGenerator{
+		Clusters: &argoprojiov1alpha1.ClusterGenerator{},
+	}, &argoprojiov1alpha1.ApplicationSet{
+		Spec: argoprojiov1alpha1.ApplicationSetSpec{
+			Template: argoprojiov1alpha1.ApplicationSetTemplate{
+				ApplicationSetTemplateMeta: argoprojiov1alpha1.ApplicationSetTemplateMeta{Name: "{{name}}-{{serverVersion}}"},
+			},
+		},
+	}))
+
+	// Without a cache, the info of the clusters is never refreshed
+	generator = NewClusterGenerator(fake.NewClientBuilder().Build(), context.Background(), kubefake.NewSimpleClientset(), "namespace", nil)
+	assert.Equal(t, NoRequeueAfter, generator.GetRequeueAfter(&argoprojiov1alpha1.ApplicationSetGenerator{
+		Clusters: &argoprojiov1alpha1.ClusterGenerator{ExcludeFailedClusters: true},
+	}, nil))
+}
+
 func TestSanitizeClusterName(t *testing.T) {
 	t.Run("valid DNS-1123 subdomain name", func(t *testing.T) {
 		assert.Equal(t, "cluster-name", sanitizeName("cluster-name"))
@@ -44,7 +44,7 @@ func NewDuckTypeGenerator(ctx context.Context, dynClient dynamic.Interface, clie
 	return g
 }
 
-func (g *DuckTypeGenerator) GetRequeueAfter(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator) time.Duration {
+func (g *DuckTypeGenerator) GetRequeueAfter(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator, _ *argoprojiov1alpha1.ApplicationSet) time.Duration {
 
 	// Return a requeue default of 3 minutes, if no override is specified.
 
@@ -59,7 +59,7 @@ func (g *DuckTypeGenerator) GetTemplate(appSetGenerator *argoprojiov1alpha1.Appl
 	return &appSetGenerator.ClusterDecisionResource.Template
 }
 
-func (g *DuckTypeGenerator) GenerateParams(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator, _ *argoprojiov1alpha1.ApplicationSet) ([]map[string]string, error) {
+func (g *DuckTypeGenerator) GenerateParams(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator, _ *argoprojiov1alpha1.ApplicationSet) ([]map[string]interface{}, error) {
 
 	if appSetGenerator == nil {
 		return nil, EmptyAppSetGeneratorError
@@ -151,7 +151,7 @@ func (g *DuckTypeGenerator) GenerateParams(appSetGenerator *argoprojiov1alpha1.A
 
 	}
 
-	res := []map[string]string{}
+	res := []map[string]interface{}{}
 	clusterDecisions := []interface{}{}
 
 	// Build the decision slice
@@ -177,7 +177,7 @@ func (g *DuckTypeGenerator) GenerateParams(appSetGenerator *argoprojiov1alpha1.A
 		for _, cluster := range clusterDecisions {
 
 			// generated instance of cluster params
-			params := map[string]string{}
+			params := map[string]interface{}{}
 
 			log.Infof("cluster: %v", cluster)
 			matchValue := cluster.(map[string]interface{})[matchKey]
@@ -210,7 +210,7 @@ func (g *DuckTypeGenerator) GenerateParams(appSetGenerator *argoprojiov1alpha1.A
 			}
 
 			for key, value := range cluster.(map[string]interface{}) {
-				params[key] = value.(string)
+				params[key] = value
 			}
 
 			for key, value := range appSetGenerator.ClusterDecisionResource.Values {
@@ -19,7 +19,13 @@ func GetRelevantGenerators(requestedGenerator *argoprojiov1alpha1.ApplicationSet
 		}
 
 		if !reflect.ValueOf(field.Interface()).IsNil() {
-			res = append(res, generators[v.Type().Field(i).Name])
+			name := v.Type().Field(i).Name
+			g, ok := generators[name]
+			if !ok {
+				log.WithField("generator", name).Warning("generator is not supported in this context, ignoring it")
+				continue
+			}
+			res = append(res, g)
 		}
 	}
 
@@ -27,7 +33,7 @@ func GetRelevantGenerators(requestedGenerator *argoprojiov1alpha1.ApplicationSet
 }
 
 type TransformResult struct {
-	Params   []map[string]string
+	Params   []map[string]interface{}
 	Template argoprojiov1alpha1.ApplicationSetTemplate
 }
 
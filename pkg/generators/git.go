@@ -1,16 +1,23 @@
 package generators
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"path"
+	"regexp"
 	"sort"
+	"strings"
 	"time"
 
+	"github.com/BurntSushi/toml"
 	argoprojiov1alpha1 "github.com/argoproj-labs/applicationset/api/v1alpha1"
 	"github.com/argoproj-labs/applicationset/pkg/services"
-	"github.com/jeremywohl/flatten"
 	log "github.com/sirupsen/logrus"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
 	"sigs.k8s.io/yaml"
 )
 
@@ -31,7 +38,7 @@ func (g *GitGenerator) GetTemplate(appSetGenerator *argoprojiov1alpha1.Applicati
 	return &appSetGenerator.Git.Template
 }
 
-func (g *GitGenerator) GetRequeueAfter(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator) time.Duration {
+func (g *GitGenerator) GetRequeueAfter(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator, _ *argoprojiov1alpha1.ApplicationSet) time.Duration {
 
 	// Return a requeue default of 3 minutes, if no default is specified.
 
@@ -42,7 +49,7 @@ func (g *GitGenerator) GetRequeueAfter(appSetGenerator *argoprojiov1alpha1.Appli
 	return DefaultRequeueAfterSeconds
 }
 
-func (g *GitGenerator) GenerateParams(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator, _ *argoprojiov1alpha1.ApplicationSet) ([]map[string]string, error) {
+func (g *GitGenerator) GenerateParams(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator, appSet *argoprojiov1alpha1.ApplicationSet) ([]map[string]interface{}, error) {
 
 	if appSetGenerator == nil {
 		return nil, EmptyAppSetGeneratorError
@@ -52,12 +59,19 @@ func (g *GitGenerator) GenerateParams(appSetGenerator *argoprojiov1alpha1.Applic
 		return nil, EmptyAppSetGeneratorError
 	}
 
-	var err error
-	var res []map[string]string
+	commitParams, err := usesCommitParams(appSetGenerator, appSet)
+	if err != nil {
+		return nil, err
+	}
+
+	// The revision is resolved once for the whole generation pass, rather than for each read of the repository
+	ctx := services.WithResolvedRevisions(context.Background())
+
+	var res []map[string]interface{}
 	if appSetGenerator.Git.Directories != nil {
-		res, err = g.generateParamsForGitDirectories(appSetGenerator)
+		res, err = g.generateParamsForGitDirectories(ctx, appSetGenerator, commitParams)
 	} else if appSetGenerator.Git.Files != nil {
-		res, err = g.generateParamsForGitFiles(appSetGenerator)
+		res, err = g.generateParamsForGitFiles(ctx, appSetGenerator, commitParams)
 	} else {
 		return nil, EmptyAppSetGeneratorError
 	}
@@ -68,10 +82,34 @@ func (g *GitGenerator) GenerateParams(appSetGenerator *argoprojiov1alpha1.Applic
 	return res, nil
 }
 
-func (g *GitGenerator) generateParamsForGitDirectories(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator) ([]map[string]string, error) {
+// commitParamPattern matches the references to the commit params in templates
+var commitParamPattern = regexp.MustCompile(`commit\.(sha|shortSha)|path\.lastCommit\.`)
+
+// usesCommitParams returns whether the commit params are referenced by the ApplicationSet, e.g. by its template or by
+// the template of the generator, as reading the history of the repository is costly.
+func usesCommitParams(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator, appSet *argoprojiov1alpha1.ApplicationSet) (bool, error) {
+	var references []interface{}
+	references = append(references, appSetGenerator.Git.Template)
+	if appSet != nil {
+		references = append(references, appSet.Spec)
+	}
+
+	for _, reference := range references {
+		data, err := json.Marshal(reference)
+		if err != nil {
+			return false, err
+		}
+		if commitParamPattern.Match(data) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (g *GitGenerator) generateParamsForGitDirectories(ctx context.Context, appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator, commitParams bool) ([]map[string]interface{}, error) {
 
 	// Directories, not files
-	allPaths, err := g.repos.GetDirectories(context.TODO(), appSetGenerator.Git.RepoURL, appSetGenerator.Git.Revision)
+	allPaths, err := g.repos.GetDirectories(ctx, appSetGenerator.Git.RepoURL, appSetGenerator.Git.Revision)
 	if err != nil {
 		return nil, err
 	}
@@ -85,17 +123,38 @@ func (g *GitGenerator) generateParamsForGitDirectories(appSetGenerator *argoproj
 
 	requestedApps := g.filterApps(appSetGenerator.Git.Directories, allPaths)
 
+	if appSetGenerator.Git.LeafDirectoriesOnly {
+		requestedApps = leafDirectories(requestedApps, allPaths)
+	}
+
+	if len(appSetGenerator.Git.MarkerFiles) > 0 {
+		requestedApps, err = g.filterMarkedDirectories(ctx, appSetGenerator.Git, requestedApps)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	res := g.generateParamsFromApps(requestedApps, appSetGenerator)
 
+	if commitParams {
+		revisionInfo, err := g.repos.GetRevisionInfo(ctx, appSetGenerator.Git.RepoURL, appSetGenerator.Git.Revision, requestedApps)
+		if err != nil {
+			return nil, err
+		}
+		for i, appPath := range requestedApps {
+			addCommitParams(res[i], revisionInfo, appPath)
+		}
+	}
+
 	return res, nil
 }
 
-func (g *GitGenerator) generateParamsForGitFiles(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator) ([]map[string]string, error) {
+func (g *GitGenerator) generateParamsForGitFiles(ctx context.Context, appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator, commitParams bool) ([]map[string]interface{}, error) {
 
 	// Get all paths that match the requested path string, removing duplicates
 	allPathsMap := make(map[string]bool)
 	for _, requestedPath := range appSetGenerator.Git.Files {
-		paths, err := g.repos.GetFilePaths(context.TODO(), appSetGenerator.Git.RepoURL, appSetGenerator.Git.Revision, requestedPath.Path)
+		paths, err := g.repos.GetFilePaths(ctx, appSetGenerator.Git.RepoURL, appSetGenerator.Git.Revision, requestedPath.Path)
 		if err != nil {
 			return nil, err
 		}
@@ -112,64 +171,152 @@ func (g *GitGenerator) generateParamsForGitFiles(appSetGenerator *argoprojiov1al
 	}
 	sort.Strings(allPaths)
 
+	var revisionInfo *services.RevisionInfo
+	if commitParams {
+		var err error
+		revisionInfo, err = g.repos.GetRevisionInfo(ctx, appSetGenerator.Git.RepoURL, appSetGenerator.Git.Revision, allPaths)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Generate params from each path, and return
-	res := []map[string]string{}
+	res := []map[string]interface{}{}
 	for _, path := range allPaths {
 
 		// A JSON / YAML file path can contain multiple sets of parameters (ie it is an array)
-		paramsArray, err := g.generateParamsFromGitFile(appSetGenerator, path)
+		paramsArray, err := g.generateParamsFromGitFile(ctx, appSetGenerator, path)
 		if err != nil {
 			return nil, fmt.Errorf("unable to process file '%s': %v", path, err)
 		}
 
 		for index := range paramsArray {
+			addCommitParams(paramsArray[index], revisionInfo, path)
 			res = append(res, paramsArray[index])
 		}
 	}
 	return res, nil
 }
 
-func (g *GitGenerator) generateParamsFromGitFile(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator, filePath string) ([]map[string]string, error) {
+func (g *GitGenerator) generateParamsFromGitFile(ctx context.Context, appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator, filePath string) ([]map[string]interface{}, error) {
 
-	fileContent, err := g.repos.GetFileContent(context.TODO(), appSetGenerator.Git.RepoURL, appSetGenerator.Git.Revision, filePath)
+	fileContent, err := g.repos.GetFileContent(ctx, appSetGenerator.Git.RepoURL, appSetGenerator.Git.Revision, filePath)
 	if err != nil {
 		return nil, err
 	}
 
-	objectsFound := []map[string]interface{}{}
-
-	// First, we attempt to parse as an array
-	err = yaml.Unmarshal(fileContent, &objectsFound)
+	documents, err := parseGitFile(filePath, fileContent)
 	if err != nil {
-		// If unable to parse as an array, attempt to parse as a single object
-		singleObj := make(map[string]interface{})
-		err = yaml.Unmarshal(fileContent, &singleObj)
-		if err != nil {
-			return nil, fmt.Errorf("unable to parse file: %v", err)
+		return nil, fmt.Errorf("unable to parse file: %v", err)
+	}
+
+	res := []map[string]interface{}{}
+
+	// Return all objects found, keeping their structure, along with the path params
+	for documentIndex, objectsFound := range documents {
+		for elementIndex, objectFound := range objectsFound {
+
+			params := map[string]interface{}{}
+			for k, v := range objectFound {
+				params[k] = v
+			}
+			params["path"] = path.Dir(filePath)
+			params["path.basename"] = path.Base(path.Dir(filePath))
+			params["path.filename"] = path.Base(filePath)
+			params["path.documentIndex"] = documentIndex
+			params["path.elementIndex"] = elementIndex
+			res = append(res, params)
 		}
-		objectsFound = append(objectsFound, singleObj)
 	}
 
-	res := []map[string]string{}
+	return res, nil
 
-	// Flatten all objects found, and return them
-	for _, objectFound := range objectsFound {
+}
 
-		flat, err := flatten.Flatten(objectFound, "", flatten.DotStyle)
+// parseGitFile parses the objects of each document of a file, according to its extension: a JSON or TOML file, or a
+// YAML file, which may contain several documents. Files of other extensions are parsed as YAML, which JSON is a subset
+// of. A document may either be an object or a list of objects. Empty documents are skipped.
+func parseGitFile(filePath string, fileContent []byte) ([][]map[string]interface{}, error) {
+	switch strings.ToLower(path.Ext(filePath)) {
+	case ".json":
+		return parseSingleDocument(fileContent)
+	case ".toml":
+		object := map[string]interface{}{}
+		if _, err := toml.Decode(string(fileContent), &object); err != nil {
+			return nil, err
+		}
+		// Convert the TOML values, such as dates and arrays of tables, to the values decoded from JSON and YAML
+		jsonContent, err := json.Marshal(object)
 		if err != nil {
 			return nil, err
 		}
-		params := map[string]string{}
-		for k, v := range flat {
-			params[k] = fmt.Sprintf("%v", v)
+		return parseSingleDocument(jsonContent)
+	default:
+		documents := [][]map[string]interface{}{}
+		reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(fileContent)))
+		for {
+			document, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			jsonContent, err := yaml.YAMLToJSON(document)
+			if err != nil {
+				return nil, err
+			}
+			objects, err := parseJSONDocument(jsonContent)
+			if err != nil {
+				return nil, err
+			}
+			if objects != nil {
+				documents = append(documents, objects)
+			}
 		}
-		params["path"] = path.Dir(filePath)
-		params["path.basename"] = path.Base(params["path"])
-		res = append(res, params)
+		return documents, nil
 	}
+}
 
-	return res, nil
+// parseSingleDocument parses the objects of a file containing a single JSON document.
+func parseSingleDocument(content []byte) ([][]map[string]interface{}, error) {
+	objects, err := parseJSONDocument(content)
+	if err != nil || objects == nil {
+		return nil, err
+	}
+	return [][]map[string]interface{}{objects}, nil
+}
 
+// parseJSONDocument parses a JSON document containing either an object or a list of objects. An empty document
+// contains no objects.
+func parseJSONDocument(content []byte) ([]map[string]interface{}, error) {
+	if len(bytes.TrimSpace(content)) == 0 {
+		return nil, nil
+	}
+
+	var document interface{}
+	if err := json.Unmarshal(content, &document); err != nil {
+		return nil, err
+	}
+
+	switch value := document.(type) {
+	case nil:
+		return nil, nil
+	case map[string]interface{}:
+		return []map[string]interface{}{value}, nil
+	case []interface{}:
+		objects := make([]map[string]interface{}, 0, len(value))
+		for _, element := range value {
+			object, ok := element.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("expected a list of objects, found an element of type %T", element)
+			}
+			objects = append(objects, object)
+		}
+		return objects, nil
+	default:
+		return nil, fmt.Errorf("expected an object or a list of objects, found a value of type %T", document)
+	}
 }
 
 func (g *GitGenerator) filterApps(Directories []argoprojiov1alpha1.GitDirectoryGeneratorItem, allPaths []string) []string {
@@ -179,7 +326,7 @@ func (g *GitGenerator) filterApps(Directories []argoprojiov1alpha1.GitDirectoryG
 		appExclude := false
 		// Iterating over each appPath and check whether directories object has requestedPath that matches the appPath
 		for _, requestedPath := range Directories {
-			match, err := path.Match(requestedPath.Path, appPath)
+			match, err := matchDirectory(requestedPath.Path, appPath)
 			if err != nil {
 				log.WithError(err).WithField("requestedPath", requestedPath).
 					WithField("appPath", appPath).Error("error while matching appPath to requestedPath")
@@ -200,13 +347,92 @@ func (g *GitGenerator) filterApps(Directories []argoprojiov1alpha1.GitDirectoryG
 	return res
 }
 
-func (g *GitGenerator) generateParamsFromApps(requestedApps []string, _ *argoprojiov1alpha1.ApplicationSetGenerator) []map[string]string {
+// matchDirectory returns whether the directory matches the pattern, in which '**' path segments match any number of
+// directories, including none, and the other segments are matched with path.Match. For example, 'apps/**/base' matches
+// both 'apps/base' and 'apps/guestbook/overlays/base'.
+func matchDirectory(pattern string, directory string) (bool, error) {
+	// Report malformed patterns even if the directory does not reach the malformed segment
+	if _, err := path.Match(pattern, ""); err != nil {
+		return false, err
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(directory, "/"))
+}
+
+func matchSegments(patterns []string, segments []string) (bool, error) {
+	if len(patterns) == 0 {
+		return len(segments) == 0, nil
+	}
+
+	if patterns[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			match, err := matchSegments(patterns[1:], segments[i:])
+			if err != nil || match {
+				return match, err
+			}
+		}
+		return false, nil
+	}
+
+	if len(segments) == 0 {
+		return false, nil
+	}
+	match, err := path.Match(patterns[0], segments[0])
+	if err != nil || !match {
+		return false, err
+	}
+	return matchSegments(patterns[1:], segments[1:])
+}
+
+// leafDirectories returns the directories which none of the directories of the repository is a subdirectory of.
+func leafDirectories(directories []string, allPaths []string) []string {
+	parents := map[string]bool{}
+	for _, directory := range allPaths {
+		for parent := path.Dir(directory); parent != "." && parent != "/"; parent = path.Dir(parent) {
+			parents[parent] = true
+		}
+	}
+
+	res := []string{}
+	for _, directory := range directories {
+		if !parents[directory] {
+			res = append(res, directory)
+		}
+	}
+	return res
+}
+
+// filterMarkedDirectories returns the directories which contain at least one of the marker files of the generator.
+func (g *GitGenerator) filterMarkedDirectories(ctx context.Context, gitGenerator *argoprojiov1alpha1.GitGenerator, directories []string) ([]string, error) {
+	markedDirectories := map[string]bool{}
+	for _, markerFile := range gitGenerator.MarkerFiles {
+		// Wildcards of Git pathspecs also match '/', so that the marker files are listed at any depth
+		paths, err := g.repos.GetFilePaths(ctx, gitGenerator.RepoURL, gitGenerator.Revision, "*/"+markerFile)
+		if err != nil {
+			return nil, err
+		}
+		for _, filePath := range paths {
+			if match, _ := path.Match(markerFile, path.Base(filePath)); match {
+				markedDirectories[path.Dir(filePath)] = true
+			}
+		}
+	}
+
+	res := []string{}
+	for _, directory := range directories {
+		if markedDirectories[directory] {
+			res = append(res, directory)
+		}
+	}
+	return res, nil
+}
+
+func (g *GitGenerator) generateParamsFromApps(requestedApps []string, _ *argoprojiov1alpha1.ApplicationSetGenerator) []map[string]interface{} {
 	// TODO: At some point, the appicationSetGenerator param should be used
 
-	res := make([]map[string]string, len(requestedApps))
+	res := make([]map[string]interface{}, len(requestedApps))
 	for i, a := range requestedApps {
 
-		params := make(map[string]string, 2)
+		params := make(map[string]interface{}, 2)
 		params["path"] = a
 		params["path.basename"] = path.Base(a)
 
@@ -215,3 +441,38 @@ func (g *GitGenerator) generateParamsFromApps(requestedApps []string, _ *argopro
 
 	return res
 }
+
+// addCommitParams adds the params describing the commit the revision resolves to, and the last commit that changed the
+// path, if any. No params are added when the repository has no commit history, and the params already set, e.g. from
+// the contents of a file, are kept.
+func addCommitParams(params map[string]interface{}, revisionInfo *services.RevisionInfo, path string) {
+	if revisionInfo == nil {
+		return
+	}
+
+	commitParams := map[string]interface{}{
+		"commit.sha":      revisionInfo.SHA,
+		"commit.shortSha": shortSHA(revisionInfo.SHA),
+	}
+	if lastCommit, found := revisionInfo.LastCommits[path]; found {
+		commitParams["path.lastCommit.sha"] = lastCommit.SHA
+		commitParams["path.lastCommit.shortSha"] = shortSHA(lastCommit.SHA)
+		commitParams["path.lastCommit.author"] = lastCommit.Author
+		commitParams["path.lastCommit.date"] = lastCommit.Date.Format(time.RFC3339)
+		commitParams["path.lastCommit.message"] = lastCommit.Message
+	}
+
+	for key, value := range commitParams {
+		if _, found := params[key]; !found {
+			params[key] = value
+		}
+	}
+}
+
+// shortSHA abbreviates the commit SHA to 7 characters, as Git does by default.
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
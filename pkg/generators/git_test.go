@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	argoprojiov1alpha1 "github.com/argoproj-labs/applicationset/api/v1alpha1"
+	"github.com/argoproj-labs/applicationset/pkg/services"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -46,15 +48,21 @@ func (a argoCDServiceMock) GetDirectories(ctx context.Context, repoURL string, r
 	return args.Get(0).([]string), args.Error(1)
 }
 
+func (a argoCDServiceMock) GetRevisionInfo(ctx context.Context, repoURL string, revision string, paths []string) (*services.RevisionInfo, error) {
+	args := a.mock.Called(ctx, repoURL, revision, paths)
+	return args.Get(0).(*services.RevisionInfo), args.Error(1)
+}
+
 func TestGitGenerateParamsFromDirectories(t *testing.T) {
 
 	cases := []struct {
-		name          string
-		directories   []argoprojiov1alpha1.GitDirectoryGeneratorItem
-		repoApps      []string
-		repoError     error
-		expected      []map[string]string
-		expectedError error
+		name                string
+		directories         []argoprojiov1alpha1.GitDirectoryGeneratorItem
+		leafDirectoriesOnly bool
+		repoApps            []string
+		repoError           error
+		expected            []map[string]interface{}
+		expectedError       error
 	}{
 		{
 			name:        "happy flow - created apps",
@@ -65,7 +73,7 @@ func TestGitGenerateParamsFromDirectories(t *testing.T) {
 				"p1/app3",
 			},
 			repoError: nil,
-			expected: []map[string]string{
+			expected: []map[string]interface{}{
 				{"path": "app1", "path.basename": "app1"},
 				{"path": "app2", "path.basename": "app2"},
 			},
@@ -81,7 +89,7 @@ func TestGitGenerateParamsFromDirectories(t *testing.T) {
 				"p1/p2/p3/app4",
 			},
 			repoError: nil,
-			expected: []map[string]string{
+			expected: []map[string]interface{}{
 				{"path": "p1/app2", "path.basename": "app2"},
 				{"path": "p1/p2/app3", "path.basename": "app3"},
 			},
@@ -98,7 +106,7 @@ func TestGitGenerateParamsFromDirectories(t *testing.T) {
 				"p2/app3",
 			},
 			repoError: nil,
-			expected: []map[string]string{
+			expected: []map[string]interface{}{
 				{"path": "app1", "path.basename": "app1"},
 				{"path": "app2", "path.basename": "app2"},
 				{"path": "p2/app3", "path.basename": "app3"},
@@ -116,19 +124,73 @@ func TestGitGenerateParamsFromDirectories(t *testing.T) {
 				"p2/app3",
 			},
 			repoError: nil,
-			expected: []map[string]string{
+			expected: []map[string]interface{}{
 				{"path": "app1", "path.basename": "app1"},
 				{"path": "app2", "path.basename": "app2"},
 				{"path": "p2/app3", "path.basename": "app3"},
 			},
 			expectedError: nil,
 		},
+		{
+			name:        "It filters application according to recursive paths",
+			directories: []argoprojiov1alpha1.GitDirectoryGeneratorItem{{Path: "p1/**"}, {Path: "**/excluded", Exclude: true}},
+			repoApps: []string{
+				"app1",
+				"p1/app2",
+				"p1/p2/app3",
+				"p1/p2/p3/app4",
+				"p1/p2/p3/app4/excluded",
+				"p2/app5",
+			},
+			repoError: nil,
+			expected: []map[string]interface{}{
+				{"path": "p1/app2", "path.basename": "app2"},
+				{"path": "p1/p2/app3", "path.basename": "app3"},
+				{"path": "p1/p2/p3/app4", "path.basename": "app4"},
+			},
+			expectedError: nil,
+		},
+		{
+			name:        "It excludes recursive paths",
+			directories: []argoprojiov1alpha1.GitDirectoryGeneratorItem{{Path: "**"}, {Path: "p1/**", Exclude: true}},
+			repoApps: []string{
+				"app1",
+				"p1",
+				"p1/app2",
+				"p1/p2/app3",
+				"p2/app4",
+			},
+			repoError: nil,
+			expected: []map[string]interface{}{
+				{"path": "app1", "path.basename": "app1"},
+				{"path": "p2/app4", "path.basename": "app4"},
+			},
+			expectedError: nil,
+		},
+		{
+			name:                "It only includes leaf directories",
+			directories:         []argoprojiov1alpha1.GitDirectoryGeneratorItem{{Path: "**"}, {Path: "p1/p2/*", Exclude: true}},
+			leafDirectoriesOnly: true,
+			repoApps: []string{
+				"app1",
+				"p1",
+				"p1/app2",
+				"p1/p2",
+				"p1/p2/app3",
+			},
+			repoError: nil,
+			expected: []map[string]interface{}{
+				{"path": "app1", "path.basename": "app1"},
+				{"path": "p1/app2", "path.basename": "app2"},
+			},
+			expectedError: nil,
+		},
 		{
 			name:          "handles empty response from repo server",
 			directories:   []argoprojiov1alpha1.GitDirectoryGeneratorItem{{Path: "*"}},
 			repoApps:      []string{},
 			repoError:     nil,
-			expected:      []map[string]string{},
+			expected:      []map[string]interface{}{},
 			expectedError: nil,
 		},
 		{
@@ -136,7 +198,7 @@ func TestGitGenerateParamsFromDirectories(t *testing.T) {
 			directories:   []argoprojiov1alpha1.GitDirectoryGeneratorItem{{Path: "*"}},
 			repoApps:      []string{},
 			repoError:     fmt.Errorf("error"),
-			expected:      []map[string]string{},
+			expected:      []map[string]interface{}{},
 			expectedError: fmt.Errorf("error"),
 		},
 	}
@@ -156,9 +218,10 @@ func TestGitGenerateParamsFromDirectories(t *testing.T) {
 				Spec: argoprojiov1alpha1.ApplicationSetSpec{
 					Generators: []argoprojiov1alpha1.ApplicationSetGenerator{{
 						Git: &argoprojiov1alpha1.GitGenerator{
-							RepoURL:     "RepoURL",
-							Revision:    "Revision",
-							Directories: c.directories,
+							RepoURL:             "RepoURL",
+							Revision:            "Revision",
+							Directories:         c.directories,
+							LeafDirectoriesOnly: c.leafDirectoriesOnly,
 						},
 					}},
 				},
@@ -179,6 +242,33 @@ func TestGitGenerateParamsFromDirectories(t *testing.T) {
 
 }
 
+func TestGitGenerateParamsFromMarkedDirectories(t *testing.T) {
+	argoCDServiceMock := argoCDServiceMock{mock: &mock.Mock{}}
+	argoCDServiceMock.mock.On("GetDirectories", mock.Anything, "RepoURL", "Revision").
+		Return([]string{"apps", "apps/guestbook", "apps/guestbook/templates", "apps/kustomize-guestbook", "apps/docs"}, nil)
+	argoCDServiceMock.mock.On("GetFilePaths", mock.Anything, "RepoURL", "Revision", "*/kustomization.yaml").
+		Return([]string{"apps/kustomize-guestbook/kustomization.yaml", "apps/docs/my-kustomization.yaml"}, nil)
+	argoCDServiceMock.mock.On("GetFilePaths", mock.Anything, "RepoURL", "Revision", "*/Chart.yaml").
+		Return([]string{"apps/guestbook/Chart.yaml", "other/Chart.yaml"}, nil)
+
+	gitGenerator := NewGitGenerator(argoCDServiceMock)
+	got, err := gitGenerator.GenerateParams(&argoprojiov1alpha1.ApplicationSetGenerator{
+		Git: &argoprojiov1alpha1.GitGenerator{
+			RepoURL:     "RepoURL",
+			Revision:    "Revision",
+			Directories: []argoprojiov1alpha1.GitDirectoryGeneratorItem{{Path: "apps/**"}},
+			MarkerFiles: []string{"kustomization.yaml", "Chart.yaml"},
+		},
+	}, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{
+		{"path": "apps/guestbook", "path.basename": "guestbook"},
+		{"path": "apps/kustomize-guestbook", "path.basename": "kustomize-guestbook"},
+	}, got)
+	argoCDServiceMock.mock.AssertExpectations(t)
+}
+
 func TestGitGenerateParamsFromFiles(t *testing.T) {
 
 	cases := []struct {
@@ -193,7 +283,7 @@ func TestGitGenerateParamsFromFiles(t *testing.T) {
 		repoPathsError error
 		// if repoFileContentsErrors contains a path key, the error value will be returned on the call to GetFileContents(...)
 		repoFileContentsErrors map[string]error
-		expected               []map[string]string
+		expected               []map[string]interface{}
 		expectedError          error
 	}{
 		{
@@ -229,24 +319,38 @@ func TestGitGenerateParamsFromFiles(t *testing.T) {
 			},
 			repoPathsError:         nil,
 			repoFileContentsErrors: nil,
-			expected: []map[string]string{
+			expected: []map[string]interface{}{
 				{
-					"cluster.owner":        "john.doe@example.com",
-					"cluster.name":         "production",
-					"cluster.address":      "https://kubernetes.default.svc",
-					"key1":                 "val1",
-					"key2.key2_1":          "val2_1",
-					"key2.key2_2.key2_2_1": "val2_2_1",
-					"key3":                 "123",
-					"path":                 "cluster-config/production",
-					"path.basename":        "production",
+					"cluster": map[string]interface{}{
+						"owner":   "john.doe@example.com",
+						"name":    "production",
+						"address": "https://kubernetes.default.svc",
+					},
+					"key1": "val1",
+					"key2": map[string]interface{}{
+						"key2_1": "val2_1",
+						"key2_2": map[string]interface{}{
+							"key2_2_1": "val2_2_1",
+						},
+					},
+					"key3":               float64(123),
+					"path":               "cluster-config/production",
+					"path.basename":      "production",
+					"path.filename":      "config.json",
+					"path.documentIndex": 0,
+					"path.elementIndex":  0,
 				},
 				{
-					"cluster.owner":   "foo.bar@example.com",
-					"cluster.name":    "staging",
-					"cluster.address": "https://kubernetes.default.svc",
-					"path":            "cluster-config/staging",
-					"path.basename":   "staging",
+					"cluster": map[string]interface{}{
+						"owner":   "foo.bar@example.com",
+						"name":    "staging",
+						"address": "https://kubernetes.default.svc",
+					},
+					"path":               "cluster-config/staging",
+					"path.basename":      "staging",
+					"path.filename":      "config.json",
+					"path.documentIndex": 0,
+					"path.elementIndex":  0,
 				},
 			},
 			expectedError: nil,
@@ -258,7 +362,7 @@ func TestGitGenerateParamsFromFiles(t *testing.T) {
 			repoFileContents:       map[string][]byte{},
 			repoPathsError:         fmt.Errorf("paths error"),
 			repoFileContentsErrors: nil,
-			expected:               []map[string]string{},
+			expected:               []map[string]interface{}{},
 			expectedError:          fmt.Errorf("paths error"),
 		},
 		{
@@ -283,7 +387,7 @@ func TestGitGenerateParamsFromFiles(t *testing.T) {
 				"cluster-config/production/config.json": nil,
 				"cluster-config/staging/config.json":    fmt.Errorf("staging config file get content error"),
 			},
-			expected:      []map[string]string{},
+			expected:      []map[string]interface{}{},
 			expectedError: fmt.Errorf("unable to process file 'cluster-config/staging/config.json': staging config file get content error"),
 		},
 		{
@@ -297,8 +401,8 @@ func TestGitGenerateParamsFromFiles(t *testing.T) {
 			},
 			repoPathsError:         nil,
 			repoFileContentsErrors: map[string]error{},
-			expected:               []map[string]string{},
-			expectedError:          fmt.Errorf("unable to process file 'cluster-config/production/config.json': unable to parse file: error unmarshaling JSON: while decoding JSON: json: cannot unmarshal string into Go value of type map[string]interface {}"),
+			expected:               []map[string]interface{}{},
+			expectedError:          fmt.Errorf("unable to process file 'cluster-config/production/config.json': unable to parse file: invalid character 'i' looking for beginning of value"),
 		},
 		{
 			name:  "test JSON array",
@@ -330,21 +434,31 @@ func TestGitGenerateParamsFromFiles(t *testing.T) {
 			},
 			repoPathsError:         nil,
 			repoFileContentsErrors: map[string]error{},
-			expected: []map[string]string{
+			expected: []map[string]interface{}{
 				{
-					"cluster.owner":     "john.doe@example.com",
-					"cluster.name":      "production",
-					"cluster.address":   "https://kubernetes.default.svc",
-					"cluster.inner.one": "two",
-					"path":              "cluster-config/production",
-					"path.basename":     "production",
+					"cluster": map[string]interface{}{
+						"owner":   "john.doe@example.com",
+						"name":    "production",
+						"address": "https://kubernetes.default.svc",
+						"inner":   map[string]interface{}{"one": "two"},
+					},
+					"path":               "cluster-config/production",
+					"path.basename":      "production",
+					"path.filename":      "config.json",
+					"path.documentIndex": 0,
+					"path.elementIndex":  0,
 				},
 				{
-					"cluster.owner":   "john.doe@example.com",
-					"cluster.name":    "staging",
-					"cluster.address": "https://kubernetes.default.svc",
-					"path":            "cluster-config/production",
-					"path.basename":   "production",
+					"cluster": map[string]interface{}{
+						"owner":   "john.doe@example.com",
+						"name":    "staging",
+						"address": "https://kubernetes.default.svc",
+					},
+					"path":               "cluster-config/production",
+					"path.basename":      "production",
+					"path.filename":      "config.json",
+					"path.documentIndex": 0,
+					"path.elementIndex":  1,
 				},
 			},
 			expectedError: nil,
@@ -377,23 +491,37 @@ cluster:
 			},
 			repoPathsError:         nil,
 			repoFileContentsErrors: nil,
-			expected: []map[string]string{
+			expected: []map[string]interface{}{
 				{
-					"cluster.owner":        "john.doe@example.com",
-					"cluster.name":         "production",
-					"cluster.address":      "https://kubernetes.default.svc",
-					"key1":                 "val1",
-					"key2.key2_1":          "val2_1",
-					"key2.key2_2.key2_2_1": "val2_2_1",
-					"path":                 "cluster-config/production",
-					"path.basename":        "production",
+					"cluster": map[string]interface{}{
+						"owner":   "john.doe@example.com",
+						"name":    "production",
+						"address": "https://kubernetes.default.svc",
+					},
+					"key1": "val1",
+					"key2": map[string]interface{}{
+						"key2_1": "val2_1",
+						"key2_2": map[string]interface{}{
+							"key2_2_1": "val2_2_1",
+						},
+					},
+					"path":               "cluster-config/production",
+					"path.basename":      "production",
+					"path.filename":      "config.yaml",
+					"path.documentIndex": 0,
+					"path.elementIndex":  0,
 				},
 				{
-					"cluster.owner":   "foo.bar@example.com",
-					"cluster.name":    "staging",
-					"cluster.address": "https://kubernetes.default.svc",
-					"path":            "cluster-config/staging",
-					"path.basename":   "staging",
+					"cluster": map[string]interface{}{
+						"owner":   "foo.bar@example.com",
+						"name":    "staging",
+						"address": "https://kubernetes.default.svc",
+					},
+					"path":               "cluster-config/staging",
+					"path.basename":      "staging",
+					"path.filename":      "config.yaml",
+					"path.documentIndex": 0,
+					"path.elementIndex":  0,
 				},
 			},
 			expectedError: nil,
@@ -419,25 +547,160 @@ cluster:
 			},
 			repoPathsError:         nil,
 			repoFileContentsErrors: map[string]error{},
-			expected: []map[string]string{
+			expected: []map[string]interface{}{
 				{
-					"cluster.owner":     "john.doe@example.com",
-					"cluster.name":      "production",
-					"cluster.address":   "https://kubernetes.default.svc",
-					"cluster.inner.one": "two",
-					"path":              "cluster-config/production",
-					"path.basename":     "production",
+					"cluster": map[string]interface{}{
+						"owner":   "john.doe@example.com",
+						"name":    "production",
+						"address": "https://kubernetes.default.svc",
+						"inner":   map[string]interface{}{"one": "two"},
+					},
+					"path":               "cluster-config/production",
+					"path.basename":      "production",
+					"path.filename":      "config.yaml",
+					"path.documentIndex": 0,
+					"path.elementIndex":  0,
 				},
 				{
-					"cluster.owner":   "john.doe@example.com",
-					"cluster.name":    "staging",
-					"cluster.address": "https://kubernetes.default.svc",
-					"path":            "cluster-config/production",
-					"path.basename":   "production",
+					"cluster": map[string]interface{}{
+						"owner":   "john.doe@example.com",
+						"name":    "staging",
+						"address": "https://kubernetes.default.svc",
+					},
+					"path":               "cluster-config/production",
+					"path.basename":      "production",
+					"path.filename":      "config.yaml",
+					"path.documentIndex": 0,
+					"path.elementIndex":  1,
 				},
 			},
 			expectedError: nil,
 		},
+		{
+			name:  "test multiple YAML documents",
+			files: []argoprojiov1alpha1.GitFileGeneratorItem{{Path: "**/clusters.yaml"}},
+			repoPaths: []string{
+				"cluster-config/clusters.yaml",
+			},
+			repoFileContents: map[string][]byte{
+				"cluster-config/clusters.yaml": []byte(`
+---
+# The production cluster
+cluster:
+  name: production
+---
+- cluster:
+    name: staging
+- cluster:
+    name: dev
+---
+`),
+			},
+			repoPathsError:         nil,
+			repoFileContentsErrors: map[string]error{},
+			expected: []map[string]interface{}{
+				{
+					"cluster":            map[string]interface{}{"name": "production"},
+					"path":               "cluster-config",
+					"path.basename":      "cluster-config",
+					"path.filename":      "clusters.yaml",
+					"path.documentIndex": 0,
+					"path.elementIndex":  0,
+				},
+				{
+					"cluster":            map[string]interface{}{"name": "staging"},
+					"path":               "cluster-config",
+					"path.basename":      "cluster-config",
+					"path.filename":      "clusters.yaml",
+					"path.documentIndex": 1,
+					"path.elementIndex":  0,
+				},
+				{
+					"cluster":            map[string]interface{}{"name": "dev"},
+					"path":               "cluster-config",
+					"path.basename":      "cluster-config",
+					"path.filename":      "clusters.yaml",
+					"path.documentIndex": 1,
+					"path.elementIndex":  1,
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			name:  "test TOML file",
+			files: []argoprojiov1alpha1.GitFileGeneratorItem{{Path: "**/config.toml"}},
+			repoPaths: []string{
+				"cluster-config/production/config.toml",
+			},
+			repoFileContents: map[string][]byte{
+				"cluster-config/production/config.toml": []byte(`
+key1 = "val1"
+key3 = 123
+created = 2021-06-18T09:21:33Z
+
+[cluster]
+owner = "john.doe@example.com"
+name = "production"
+
+[[regions]]
+name = "us-east-1"
+
+[[regions]]
+name = "eu-west-1"
+`),
+			},
+			repoPathsError:         nil,
+			repoFileContentsErrors: map[string]error{},
+			expected: []map[string]interface{}{
+				{
+					"cluster": map[string]interface{}{
+						"owner": "john.doe@example.com",
+						"name":  "production",
+					},
+					"key1":    "val1",
+					"key3":    float64(123),
+					"created": "2021-06-18T09:21:33Z",
+					"regions": []interface{}{
+						map[string]interface{}{"name": "us-east-1"},
+						map[string]interface{}{"name": "eu-west-1"},
+					},
+					"path":               "cluster-config/production",
+					"path.basename":      "production",
+					"path.filename":      "config.toml",
+					"path.documentIndex": 0,
+					"path.elementIndex":  0,
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			name:  "test invalid TOML file returns error",
+			files: []argoprojiov1alpha1.GitFileGeneratorItem{{Path: "**/config.toml"}},
+			repoPaths: []string{
+				"cluster-config/production/config.toml",
+			},
+			repoFileContents: map[string][]byte{
+				"cluster-config/production/config.toml": []byte(`key1 = `),
+			},
+			repoPathsError:         nil,
+			repoFileContentsErrors: map[string]error{},
+			expected:               []map[string]interface{}{},
+			expectedError:          fmt.Errorf("unable to process file 'cluster-config/production/config.toml': unable to parse file: Near line 1 (last key parsed 'key1'): expected value but found '\\x00' instead"),
+		},
+		{
+			name:  "test JSON list of values returns error",
+			files: []argoprojiov1alpha1.GitFileGeneratorItem{{Path: "**/config.json"}},
+			repoPaths: []string{
+				"cluster-config/production/config.json",
+			},
+			repoFileContents: map[string][]byte{
+				"cluster-config/production/config.json": []byte(`["production", "staging"]`),
+			},
+			repoPathsError:         nil,
+			repoFileContentsErrors: map[string]error{},
+			expected:               []map[string]interface{}{},
+			expectedError:          fmt.Errorf("unable to process file 'cluster-config/production/config.json': unable to parse file: expected a list of objects, found an element of type string"),
+		},
 	}
 
 	for _, c := range cases {
@@ -484,3 +747,200 @@ cluster:
 	}
 
 }
+
+func TestGitGenerateCommitParams(t *testing.T) {
+	commitDate := time.Date(2021, 6, 18, 9, 21, 33, 0, time.UTC)
+	revisionInfo := &services.RevisionInfo{
+		SHA: "178864a7d521b6f5e720b386b2c2b0ef8563e0dc",
+		LastCommits: map[string]services.CommitInfo{
+			"app1": {
+				SHA:     "ecddabb624f6f5ba43816f5926e580a5f680a932",
+				Author:  "Jane Doe <jane@example.com>",
+				Date:    commitDate,
+				Message: "Bump the image of app1",
+			},
+			"cluster-config/staging/config.json": {
+				SHA:     "0d2ff2ef55b8ec2cd5c8e7e2c95aef36e2a7b3d6",
+				Author:  "John Doe <john@example.com>",
+				Date:    commitDate,
+				Message: "Add the staging cluster",
+			},
+		},
+	}
+	// The commit params are only computed when they are referenced
+	appSet := &argoprojiov1alpha1.ApplicationSet{
+		Spec: argoprojiov1alpha1.ApplicationSetSpec{Template: templateWithAnnotation("{{path.lastCommit.sha}}")},
+	}
+
+	t.Run("directories", func(t *testing.T) {
+		argoCDServiceMock := argoCDServiceMock{mock: &mock.Mock{}}
+		argoCDServiceMock.mock.On("GetDirectories", mock.Anything, "RepoURL", "Revision").Return([]string{"app1", "app2"}, nil)
+		argoCDServiceMock.mock.On("GetRevisionInfo", mock.Anything, "RepoURL", "Revision", []string{"app1", "app2"}).Return(revisionInfo, nil)
+
+		gitGenerator := NewGitGenerator(argoCDServiceMock)
+		got, err := gitGenerator.GenerateParams(&argoprojiov1alpha1.ApplicationSetGenerator{
+			Git: &argoprojiov1alpha1.GitGenerator{
+				RepoURL:     "RepoURL",
+				Revision:    "Revision",
+				Directories: []argoprojiov1alpha1.GitDirectoryGeneratorItem{{Path: "*"}},
+			},
+		}, appSet)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []map[string]interface{}{
+			{
+				"path":                     "app1",
+				"path.basename":            "app1",
+				"commit.sha":               "178864a7d521b6f5e720b386b2c2b0ef8563e0dc",
+				"commit.shortSha":          "178864a",
+				"path.lastCommit.sha":      "ecddabb624f6f5ba43816f5926e580a5f680a932",
+				"path.lastCommit.shortSha": "ecddabb",
+				"path.lastCommit.author":   "Jane Doe <jane@example.com>",
+				"path.lastCommit.date":     "2021-06-18T09:21:33Z",
+				"path.lastCommit.message":  "Bump the image of app1",
+			},
+			{
+				"path":            "app2",
+				"path.basename":   "app2",
+				"commit.sha":      "178864a7d521b6f5e720b386b2c2b0ef8563e0dc",
+				"commit.shortSha": "178864a",
+			},
+		}, got)
+		argoCDServiceMock.mock.AssertExpectations(t)
+	})
+
+	t.Run("files", func(t *testing.T) {
+		argoCDServiceMock := argoCDServiceMock{mock: &mock.Mock{}}
+		argoCDServiceMock.mock.On("GetFilePaths", mock.Anything, "RepoURL", "Revision", "**/config.json").
+			Return([]string{"cluster-config/staging/config.json"}, nil)
+		argoCDServiceMock.mock.On("GetFileContent", mock.Anything, "RepoURL", "Revision", "cluster-config/staging/config.json").
+			Return([]byte(`{"cluster": {"name": "staging"}}`), nil)
+		argoCDServiceMock.mock.On("GetRevisionInfo", mock.Anything, "RepoURL", "Revision", []string{"cluster-config/staging/config.json"}).
+			Return(revisionInfo, nil)
+
+		gitGenerator := NewGitGenerator(argoCDServiceMock)
+		got, err := gitGenerator.GenerateParams(&argoprojiov1alpha1.ApplicationSetGenerator{
+			Git: &argoprojiov1alpha1.GitGenerator{
+				RepoURL:  "RepoURL",
+				Revision: "Revision",
+				Files:    []argoprojiov1alpha1.GitFileGeneratorItem{{Path: "**/config.json"}},
+			},
+		}, appSet)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []map[string]interface{}{
+			{
+				"cluster":                  map[string]interface{}{"name": "staging"},
+				"path":                     "cluster-config/staging",
+				"path.basename":            "staging",
+				"path.filename":            "config.json",
+				"path.documentIndex":       0,
+				"path.elementIndex":        0,
+				"commit.sha":               "178864a7d521b6f5e720b386b2c2b0ef8563e0dc",
+				"commit.shortSha":          "178864a",
+				"path.lastCommit.sha":      "0d2ff2ef55b8ec2cd5c8e7e2c95aef36e2a7b3d6",
+				"path.lastCommit.shortSha": "0d2ff2e",
+				"path.lastCommit.author":   "John Doe <john@example.com>",
+				"path.lastCommit.date":     "2021-06-18T09:21:33Z",
+				"path.lastCommit.message":  "Add the staging cluster",
+			},
+		}, got)
+		argoCDServiceMock.mock.AssertExpectations(t)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		argoCDServiceMock := argoCDServiceMock{mock: &mock.Mock{}}
+		argoCDServiceMock.mock.On("GetDirectories", mock.Anything, "RepoURL", "Revision").Return([]string{"app1"}, nil)
+		argoCDServiceMock.mock.On("GetRevisionInfo", mock.Anything, "RepoURL", "Revision", []string{"app1"}).
+			Return((*services.RevisionInfo)(nil), fmt.Errorf("unable to resolve the revision"))
+
+		gitGenerator := NewGitGenerator(argoCDServiceMock)
+		_, err := gitGenerator.GenerateParams(&argoprojiov1alpha1.ApplicationSetGenerator{
+			Git: &argoprojiov1alpha1.GitGenerator{
+				RepoURL:     "RepoURL",
+				Revision:    "Revision",
+				Directories: []argoprojiov1alpha1.GitDirectoryGeneratorItem{{Path: "*"}},
+			},
+		}, appSet)
+
+		assert.EqualError(t, err, "unable to resolve the revision")
+	})
+}
+
+func TestGitGenerateCommitParamsOnlyWhenReferenced(t *testing.T) {
+	argoCDServiceMock := argoCDServiceMock{mock: &mock.Mock{}}
+	argoCDServiceMock.mock.On("GetDirectories", mock.Anything, "RepoURL", "Revision").Return([]string{"app1"}, nil)
+
+	gitGenerator := NewGitGenerator(argoCDServiceMock)
+	got, err := gitGenerator.GenerateParams(&argoprojiov1alpha1.ApplicationSetGenerator{
+		Git: &argoprojiov1alpha1.GitGenerator{
+			RepoURL:     "RepoURL",
+			Revision:    "Revision",
+			Directories: []argoprojiov1alpha1.GitDirectoryGeneratorItem{{Path: "*"}},
+		},
+	}, &argoprojiov1alpha1.ApplicationSet{
+		Spec: argoprojiov1alpha1.ApplicationSetSpec{
+			Template: argoprojiov1alpha1.ApplicationSetTemplate{
+				ApplicationSetTemplateMeta: argoprojiov1alpha1.ApplicationSetTemplateMeta{Name: "{{path.basename}}"},
+			},
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{{"path": "app1", "path.basename": "app1"}}, got)
+	argoCDServiceMock.mock.AssertNotCalled(t, "GetRevisionInfo", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUsesCommitParams(t *testing.T) {
+	for _, c := range []struct {
+		name     string
+		template argoprojiov1alpha1.ApplicationSetTemplate
+		expected bool
+	}{
+		{
+			name:     "no commit params",
+			template: templateWithAnnotation("{{path}}"),
+			expected: false,
+		},
+		{
+			name:     "commit SHA",
+			template: templateWithAnnotation("{{commit.shortSha}}"),
+			expected: true,
+		},
+		{
+			name:     "last commit of the path",
+			template: templateWithAnnotation(`{{ index . "path.lastCommit.author" }}`),
+			expected: true,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			// Both the template of the generator and the one of the ApplicationSet are considered
+			got, err := usesCommitParams(&argoprojiov1alpha1.ApplicationSetGenerator{Git: &argoprojiov1alpha1.GitGenerator{Template: c.template}}, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, got)
+
+			got, err = usesCommitParams(&argoprojiov1alpha1.ApplicationSetGenerator{Git: &argoprojiov1alpha1.GitGenerator{}},
+				&argoprojiov1alpha1.ApplicationSet{Spec: argoprojiov1alpha1.ApplicationSetSpec{Template: c.template}})
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, got)
+		})
+	}
+}
+
+func templateWithAnnotation(value string) argoprojiov1alpha1.ApplicationSetTemplate {
+	return argoprojiov1alpha1.ApplicationSetTemplate{
+		ApplicationSetTemplateMeta: argoprojiov1alpha1.ApplicationSetTemplateMeta{
+			Annotations: map[string]string{"example.com/annotation": value},
+		},
+	}
+}
+
+func TestAddCommitParamsKeepsExistingParams(t *testing.T) {
+	params := map[string]interface{}{"commit.sha": "from-file"}
+	addCommitParams(params, &services.RevisionInfo{SHA: "178864a7d521b6f5e720b386b2c2b0ef8563e0dc"}, "config.json")
+
+	assert.Equal(t, map[string]interface{}{
+		"commit.sha":      "from-file",
+		"commit.shortSha": "178864a",
+	}, params)
+}
@@ -18,7 +18,7 @@ func NewListGenerator() Generator {
 	return g
 }
 
-func (g *ListGenerator) GetRequeueAfter(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator) time.Duration {
+func (g *ListGenerator) GetRequeueAfter(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator, _ *argoprojiov1alpha1.ApplicationSet) time.Duration {
 	return NoRequeueAfter
 }
 
@@ -26,7 +26,7 @@ func (g *ListGenerator) GetTemplate(appSetGenerator *argoprojiov1alpha1.Applicat
 	return &appSetGenerator.List.Template
 }
 
-func (g *ListGenerator) GenerateParams(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator, _ *argoprojiov1alpha1.ApplicationSet) ([]map[string]string, error) {
+func (g *ListGenerator) GenerateParams(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator, _ *argoprojiov1alpha1.ApplicationSet) ([]map[string]interface{}, error) {
 	if appSetGenerator == nil {
 		return nil, EmptyAppSetGeneratorError
 	}
@@ -35,10 +35,10 @@ func (g *ListGenerator) GenerateParams(appSetGenerator *argoprojiov1alpha1.Appli
 		return nil, nil
 	}
 
-	res := make([]map[string]string, len(appSetGenerator.List.Elements))
+	res := make([]map[string]interface{}, len(appSetGenerator.List.Elements))
 
 	for i, tmpItem := range appSetGenerator.List.Elements {
-		params := map[string]string{}
+		params := map[string]interface{}{}
 		var element map[string]interface{}
 		err := json.Unmarshal(tmpItem.Raw, &element)
 		if err != nil {
@@ -52,18 +52,10 @@ func (g *ListGenerator) GenerateParams(appSetGenerator *argoprojiov1alpha1.Appli
 					return nil, fmt.Errorf("error parsing values map")
 				}
 				for k, v := range values {
-					value, ok := v.(string)
-					if !ok {
-						return nil, fmt.Errorf("error parsing value as string %v", err)
-					}
-					params[fmt.Sprintf("values.%s", k)] = value
+					params[fmt.Sprintf("values.%s", k)] = v
 				}
 			} else {
-				v, ok := value.(string)
-				if !ok {
-					return nil, fmt.Errorf("error parsing value as string %v", err)
-				}
-				params[key] = v
+				params[key] = value
 			}
 		}
 
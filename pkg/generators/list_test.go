@@ -11,14 +11,23 @@ import (
 func TestGenerateListParams(t *testing.T) {
 	testCases := []struct {
 		elements []apiextensionsv1.JSON
-		expected []map[string]string
+		expected []map[string]interface{}
 	}{
 		{
 			elements: []apiextensionsv1.JSON{{Raw: []byte(`{"cluster": "cluster","url": "url"}`)}},
-			expected: []map[string]string{{"cluster": "cluster", "url": "url"}},
+			expected: []map[string]interface{}{{"cluster": "cluster", "url": "url"}},
 		}, {
 			elements: []apiextensionsv1.JSON{{Raw: []byte(`{"cluster": "cluster","url": "url","values":{"foo":"bar"}}`)}},
-			expected: []map[string]string{{"cluster": "cluster", "url": "url", "values.foo": "bar"}},
+			expected: []map[string]interface{}{{"cluster": "cluster", "url": "url", "values.foo": "bar"}},
+		}, {
+			elements: []apiextensionsv1.JSON{{Raw: []byte(`{"cluster": "cluster","replicas": 3,"prune": true,"regions": ["a", "b"],"values":{"foo": {"bar": "baz"}}}`)}},
+			expected: []map[string]interface{}{{
+				"cluster":    "cluster",
+				"replicas":   float64(3),
+				"prune":      true,
+				"regions":    []interface{}{"a", "b"},
+				"values.foo": map[string]interface{}{"bar": "baz"},
+			}},
 		},
 	}
 
@@ -2,6 +2,8 @@ package generators
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	argoprojiov1alpha1 "github.com/argoproj-labs/applicationset/api/v1alpha1"
@@ -10,9 +12,9 @@ import (
 
 var _ Generator = (*MatrixGenerator)(nil)
 
-var MoreThanTwoGenerators = errors.New("found more than two generators, Matrix support only two")
-var LessThanTwoGenerators = errors.New("found less than two generators, Matrix support only two")
+var LessThanTwoGenerators = errors.New("found less than two generators, Matrix requires at least two")
 var MoreThenOneInnerGenerators = errors.New("found more than one generator in matrix.Generators")
+var NoInnerGenerators = errors.New("found no supported generator in a child generator, each child generator must contain exactly one generator")
 
 type MatrixGenerator struct {
 	// The inner generators supported by the matrix generator (cluster, git, list...)
@@ -26,88 +28,41 @@ func NewMatrixGenerator(supportedGenerators map[string]Generator) Generator {
 	return m
 }
 
-func (m *MatrixGenerator) GenerateParams(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator, appSet *argoprojiov1alpha1.ApplicationSet) ([]map[string]string, error) {
+// GenerateParams returns the cartesian product of the parameters of all the child generators.
+func (m *MatrixGenerator) GenerateParams(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator, appSet *argoprojiov1alpha1.ApplicationSet) ([]map[string]interface{}, error) {
 
 	if len(appSetGenerator.Matrix.Generators) < 2 {
 		return nil, LessThanTwoGenerators
 	}
 
-	if len(appSetGenerator.Matrix.Generators) > 2 {
-		return nil, MoreThanTwoGenerators
-	}
-
-	res := []map[string]string{}
+	res := []map[string]interface{}{{}}
 
-	g0, err := m.getParams(appSetGenerator.Matrix.Generators[0], appSet)
-	if err != nil {
-		return nil, err
-	}
-	g1, err := m.getParams(appSetGenerator.Matrix.Generators[1], appSet)
-	if err != nil {
-		return nil, err
-	}
+	for i, baseGenerator := range appSetGenerator.Matrix.Generators {
+		params, err := getChildGeneratorParams(baseGenerator, m.supportedGenerators, appSet)
+		if err != nil {
+			return nil, err
+		}
 
-	for _, a := range g0 {
-		for _, b := range g1 {
-			val, err := utils.CombineStringMaps(a, b)
-			if err != nil {
-				return nil, err
+		combined := []map[string]interface{}{}
+		for _, a := range res {
+			for _, b := range params {
+				val, err := utils.CombineMaps(a, b)
+				if err != nil {
+					return nil, fmt.Errorf("error combining params of matrix child generator %d (%s): %v", i, strings.Join(utils.GeneratorNames(baseGenerator), ","), err)
+				}
+				combined = append(combined, val)
 			}
-			res = append(res, val)
 		}
+		res = combined
 	}
 
 	return res, nil
 }
 
-func (m *MatrixGenerator) getParams(appSetBaseGenerator argoprojiov1alpha1.ApplicationSetBaseGenerator, appSet *argoprojiov1alpha1.ApplicationSet) ([]map[string]string, error) {
-
-	t, _ := Transform(
-		argoprojiov1alpha1.ApplicationSetGenerator{
-			List:     appSetBaseGenerator.List,
-			Clusters: appSetBaseGenerator.Clusters,
-			Git:      appSetBaseGenerator.Git,
-		},
-		m.supportedGenerators,
-		argoprojiov1alpha1.ApplicationSetTemplate{},
-		appSet)
-
-	if len(t) > 1 {
-		return nil, MoreThenOneInnerGenerators
-	}
-
-	return t[0].Params, nil
-}
-
 const maxDuration time.Duration = 1<<63 - 1
 
-func (m *MatrixGenerator) GetRequeueAfter(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator) time.Duration {
-	res := maxDuration
-	var found bool
-
-	for _, r := range appSetGenerator.Matrix.Generators {
-		base := &argoprojiov1alpha1.ApplicationSetGenerator{
-			List:     r.List,
-			Clusters: r.Clusters,
-			Git:      r.Git,
-		}
-		generators := GetRelevantGenerators(base, m.supportedGenerators)
-
-		for _, g := range generators {
-			temp := g.GetRequeueAfter(base)
-			if temp < res && temp != NoRequeueAfter {
-				found = true
-				res = temp
-			}
-		}
-	}
-
-	if found {
-		return res
-	} else {
-		return NoRequeueAfter
-	}
-
+func (m *MatrixGenerator) GetRequeueAfter(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator, appSet *argoprojiov1alpha1.ApplicationSet) time.Duration {
+	return getChildGeneratorsRequeueAfter(appSetGenerator.Matrix.Generators, m.supportedGenerators, appSet)
 }
 
 func (m *MatrixGenerator) GetTemplate(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator) *argoprojiov1alpha1.ApplicationSetTemplate {
@@ -1,10 +1,12 @@
 package generators
 
 import (
+	"errors"
 	"testing"
 	"time"
 
 	argoprojiov1alpha1 "github.com/argoproj-labs/applicationset/api/v1alpha1"
+	"github.com/argoproj-labs/applicationset/pkg/services/scm_provider"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
@@ -22,11 +24,19 @@ func TestMatrixGenerate(t *testing.T) {
 		Elements: []apiextensionsv1.JSON{{Raw: []byte(`{"cluster": "Cluster","url": "Url"}`)}},
 	}
 
+	envListGenerator := &argoprojiov1alpha1.ListGenerator{
+		Elements: []apiextensionsv1.JSON{{Raw: []byte(`{"env": "dev"}`)}, {Raw: []byte(`{"env": "prod"}`)}},
+	}
+
+	conflictingListGenerator := &argoprojiov1alpha1.ListGenerator{
+		Elements: []apiextensionsv1.JSON{{Raw: []byte(`{"cluster": "Other"}`)}},
+	}
+
 	testCases := []struct {
 		name           string
 		baseGenerators []argoprojiov1alpha1.ApplicationSetBaseGenerator
 		expectedErr    error
-		expected       []map[string]string
+		expected       []map[string]interface{}
 	}{
 		{
 			name: "happy flow - generate params",
@@ -38,7 +48,7 @@ func TestMatrixGenerate(t *testing.T) {
 					List: listGenerator,
 				},
 			},
-			expected: []map[string]string{
+			expected: []map[string]interface{}{
 				{"path": "app1", "path.basename": "app1", "cluster": "Cluster", "url": "Url"},
 				{"path": "app2", "path.basename": "app2", "cluster": "Cluster", "url": "Url"},
 			},
@@ -53,19 +63,39 @@ func TestMatrixGenerate(t *testing.T) {
 			expectedErr: LessThanTwoGenerators,
 		},
 		{
-			name: "returns error if there is more than two base generators",
+			name: "happy flow - generate params from three base generators",
 			baseGenerators: []argoprojiov1alpha1.ApplicationSetBaseGenerator{
 				{
-					List: listGenerator,
+					Git: gitGenerator,
 				},
 				{
 					List: listGenerator,
 				},
+				{
+					List: envListGenerator,
+				},
+			},
+			expected: []map[string]interface{}{
+				{"path": "app1", "path.basename": "app1", "cluster": "Cluster", "url": "Url", "env": "dev"},
+				{"path": "app1", "path.basename": "app1", "cluster": "Cluster", "url": "Url", "env": "prod"},
+				{"path": "app2", "path.basename": "app2", "cluster": "Cluster", "url": "Url", "env": "dev"},
+				{"path": "app2", "path.basename": "app2", "cluster": "Cluster", "url": "Url", "env": "prod"},
+			},
+		},
+		{
+			name: "returns error naming the base generator with conflicting params",
+			baseGenerators: []argoprojiov1alpha1.ApplicationSetBaseGenerator{
+				{
+					Git: gitGenerator,
+				},
 				{
 					List: listGenerator,
 				},
+				{
+					List: conflictingListGenerator,
+				},
 			},
-			expectedErr: MoreThanTwoGenerators,
+			expectedErr: errors.New("error combining params of matrix child generator 2 (list): found duplicate key cluster with different value, a: Cluster ,b: Other"),
 		},
 		{
 			name: "returns error if there is more than one inner generator in the first base generator",
@@ -108,7 +138,7 @@ func TestMatrixGenerate(t *testing.T) {
 					Git:  g.Git,
 					List: g.List,
 				}
-				mock.On("GenerateParams", &gitGeneratorSpec, appSet).Return([]map[string]string{
+				mock.On("GenerateParams", &gitGeneratorSpec, appSet).Return([]map[string]interface{}{
 					{
 						"path":          "app1",
 						"path.basename": "app1",
@@ -149,6 +179,114 @@ func TestMatrixGenerate(t *testing.T) {
 	}
 }
 
+func TestMatrixGenerateChildGenerators(t *testing.T) {
+
+	scmProviderGenerator := &argoprojiov1alpha1.SCMProviderGenerator{}
+
+	duckTypeGenerator := &argoprojiov1alpha1.DuckTypeGenerator{ConfigMapRef: "my-configmap"}
+
+	listGenerator := &argoprojiov1alpha1.ListGenerator{
+		Elements: []apiextensionsv1.JSON{{Raw: []byte(`{"env": "dev"}`)}},
+	}
+
+	testCases := []struct {
+		name           string
+		baseGenerators []argoprojiov1alpha1.ApplicationSetBaseGenerator
+		expectedErr    error
+		expected       []map[string]interface{}
+	}{
+		{
+			name: "combines SCMProvider and ClusterDecisionResource generators",
+			baseGenerators: []argoprojiov1alpha1.ApplicationSetBaseGenerator{
+				{
+					SCMProvider: scmProviderGenerator,
+				},
+				{
+					ClusterDecisionResource: duckTypeGenerator,
+				},
+			},
+			expected: []map[string]interface{}{
+				{"organization": "myorg", "repository": "repo1", "url": "git@github.com:myorg/repo1.git", "branch": "main", "labels": "", "name": "cluster1", "server": "https://cluster1"},
+				{"organization": "myorg", "repository": "repo1", "url": "git@github.com:myorg/repo1.git", "branch": "main", "labels": "", "name": "cluster2", "server": "https://cluster2"},
+			},
+		},
+		{
+			name: "returns error if a child generator is empty",
+			baseGenerators: []argoprojiov1alpha1.ApplicationSetBaseGenerator{
+				{
+					List: listGenerator,
+				},
+				{},
+			},
+			expectedErr: NoInnerGenerators,
+		},
+		{
+			name: "returns error if a child generator is not supported",
+			baseGenerators: []argoprojiov1alpha1.ApplicationSetBaseGenerator{
+				{
+					List: listGenerator,
+				},
+				{
+					Git: &argoprojiov1alpha1.GitGenerator{RepoURL: "RepoURL"},
+				},
+			},
+			expectedErr: NoInnerGenerators,
+		},
+	}
+
+	for _, c := range testCases {
+		cc := c
+
+		t.Run(cc.name, func(t *testing.T) {
+			appSet := &argoprojiov1alpha1.ApplicationSet{}
+
+			duckTypeMock := &generatorMock{}
+			duckTypeGeneratorSpec := argoprojiov1alpha1.ApplicationSetGenerator{
+				ClusterDecisionResource: duckTypeGenerator,
+			}
+			duckTypeMock.On("GenerateParams", &duckTypeGeneratorSpec, appSet).Return([]map[string]interface{}{
+				{"name": "cluster1", "server": "https://cluster1"},
+				{"name": "cluster2", "server": "https://cluster2"},
+			}, nil)
+			duckTypeMock.On("GetTemplate", &duckTypeGeneratorSpec).
+				Return(&argoprojiov1alpha1.ApplicationSetTemplate{})
+
+			var matrixGenerator = NewMatrixGenerator(
+				map[string]Generator{
+					"List": &ListGenerator{},
+					"SCMProvider": &SCMProviderGenerator{overrideProvider: &scm_provider.MockProvider{
+						Repos: []*scm_provider.Repository{
+							{
+								Organization: "myorg",
+								Repository:   "repo1",
+								URL:          "git@github.com:myorg/repo1.git",
+								Branch:       "main",
+							},
+						},
+					}},
+					"ClusterDecisionResource": duckTypeMock,
+				},
+			)
+
+			got, err := matrixGenerator.GenerateParams(&argoprojiov1alpha1.ApplicationSetGenerator{
+				Matrix: &argoprojiov1alpha1.MatrixGenerator{
+					Generators: cc.baseGenerators,
+					Template:   argoprojiov1alpha1.ApplicationSetTemplate{},
+				},
+			}, appSet)
+
+			if cc.expectedErr != nil {
+				assert.EqualError(t, err, cc.expectedErr.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, cc.expected, got)
+			}
+
+		})
+
+	}
+}
+
 func TestMatrixGetRequeueAfter(t *testing.T) {
 
 	gitGenerator := &argoprojiov1alpha1.GitGenerator{
@@ -180,6 +318,19 @@ func TestMatrixGetRequeueAfter(t *testing.T) {
 			gitGetRequeueAfter: NoRequeueAfter,
 			expected:           NoRequeueAfter,
 		},
+		{
+			name: "includes the SCMProvider generator requeue time",
+			baseGenerators: []argoprojiov1alpha1.ApplicationSetBaseGenerator{
+				{
+					SCMProvider: &argoprojiov1alpha1.SCMProviderGenerator{},
+				},
+				{
+					List: listGenerator,
+				},
+			},
+			gitGetRequeueAfter: NoRequeueAfter,
+			expected:           DefaultSCMProviderRequeueAfterSeconds,
+		},
 		{
 			name: "returns the minimal time",
 			baseGenerators: []argoprojiov1alpha1.ApplicationSetBaseGenerator{
@@ -211,8 +362,9 @@ func TestMatrixGetRequeueAfter(t *testing.T) {
 
 			var matrixGenerator = NewMatrixGenerator(
 				map[string]Generator{
-					"Git":  mock,
-					"List": &ListGenerator{},
+					"Git":         mock,
+					"List":        &ListGenerator{},
+					"SCMProvider": &SCMProviderGenerator{},
 				},
 			)
 
@@ -221,7 +373,7 @@ func TestMatrixGetRequeueAfter(t *testing.T) {
 					Generators: cc.baseGenerators,
 					Template:   argoprojiov1alpha1.ApplicationSetTemplate{},
 				},
-			})
+			}, nil)
 
 			assert.Equal(t, cc.expected, got)
 
@@ -240,13 +392,13 @@ func (g *generatorMock) GetTemplate(appSetGenerator *argoprojiov1alpha1.Applicat
 	return args.Get(0).(*argoprojiov1alpha1.ApplicationSetTemplate)
 }
 
-func (g *generatorMock) GenerateParams(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator, appSet *argoprojiov1alpha1.ApplicationSet) ([]map[string]string, error) {
+func (g *generatorMock) GenerateParams(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator, appSet *argoprojiov1alpha1.ApplicationSet) ([]map[string]interface{}, error) {
 	args := g.Called(appSetGenerator, appSet)
 
-	return args.Get(0).([]map[string]string), args.Error(1)
+	return args.Get(0).([]map[string]interface{}), args.Error(1)
 }
 
-func (g *generatorMock) GetRequeueAfter(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator) time.Duration {
+func (g *generatorMock) GetRequeueAfter(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator, appSet *argoprojiov1alpha1.ApplicationSet) time.Duration {
 	args := g.Called(appSetGenerator)
 
 	return args.Get(0).(time.Duration)
@@ -0,0 +1,120 @@
+package generators
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	argoprojiov1alpha1 "github.com/argoproj-labs/applicationset/api/v1alpha1"
+	"github.com/argoproj-labs/applicationset/pkg/utils"
+)
+
+var _ Generator = (*MergeGenerator)(nil)
+
+var LessThanTwoGeneratorsInMerge = errors.New("found less than two generators, Merge requires at least two")
+var NoMergeKeys = errors.New("no merge keys were specified, Merge requires at least one")
+var NonUniqueParamSets = errors.New("the parameters from a generator were not unique by the given mergeKeys, Merge requires all param sets to be unique")
+
+type MergeGenerator struct {
+	// The inner generators supported by the merge generator (cluster, git, list...)
+	supportedGenerators map[string]Generator
+}
+
+func NewMergeGenerator(supportedGenerators map[string]Generator) Generator {
+	m := &MergeGenerator{
+		supportedGenerators: supportedGenerators,
+	}
+	return m
+}
+
+// GenerateParams returns the parameter sets of the first child generator, where each parameter set is overridden
+// by the parameter sets of the following child generators that have the same values for all the merge keys.
+func (m *MergeGenerator) GenerateParams(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator, appSet *argoprojiov1alpha1.ApplicationSet) ([]map[string]interface{}, error) {
+
+	if len(appSetGenerator.Merge.Generators) < 2 {
+		return nil, LessThanTwoGeneratorsInMerge
+	}
+
+	if len(appSetGenerator.Merge.MergeKeys) == 0 {
+		return nil, NoMergeKeys
+	}
+
+	baseParams, err := getChildGeneratorParams(appSetGenerator.Merge.Generators[0], m.supportedGenerators, appSet)
+	if err != nil {
+		return nil, err
+	}
+
+	// The base parameter sets are copied, so that the overrides don't modify the params returned by the generator
+	res := make([]map[string]interface{}, len(baseParams))
+	for i, params := range baseParams {
+		res[i] = make(map[string]interface{}, len(params))
+		for k, v := range params {
+			res[i][k] = v
+		}
+	}
+
+	baseParamsByMergeKey, err := getParamSetsByMergeKey(appSetGenerator.Merge.MergeKeys, res)
+	if err != nil {
+		return nil, fmt.Errorf("error merging params of merge child generator 0 (%s): %v", strings.Join(utils.GeneratorNames(appSetGenerator.Merge.Generators[0]), ","), err)
+	}
+
+	for i, baseGenerator := range appSetGenerator.Merge.Generators[1:] {
+		overrideParams, err := getChildGeneratorParams(baseGenerator, m.supportedGenerators, appSet)
+		if err != nil {
+			return nil, err
+		}
+
+		overrideParamsByMergeKey, err := getParamSetsByMergeKey(appSetGenerator.Merge.MergeKeys, overrideParams)
+		if err != nil {
+			return nil, fmt.Errorf("error merging params of merge child generator %d (%s): %v", i+1, strings.Join(utils.GeneratorNames(baseGenerator), ","), err)
+		}
+
+		for mergeKeyValue, baseParamSet := range baseParamsByMergeKey {
+			overrideParamSet, exists := overrideParamsByMergeKey[mergeKeyValue]
+			if !exists {
+				continue
+			}
+			for k, v := range overrideParamSet {
+				baseParamSet[k] = v
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// getParamSetsByMergeKey indexes the parameter sets by the JSON encoding of their merge key values.
+func getParamSetsByMergeKey(mergeKeys []string, paramSets []map[string]interface{}) (map[string]map[string]interface{}, error) {
+	res := make(map[string]map[string]interface{}, len(paramSets))
+
+	for _, paramSet := range paramSets {
+		mergeKeyValues := make(map[string]interface{}, len(mergeKeys))
+		for _, mergeKey := range mergeKeys {
+			mergeKeyValues[mergeKey], _ = utils.GetParamValue(paramSet, mergeKey)
+		}
+
+		// json.Marshal sorts the map keys, so the same merge key values always produce the same string
+		mergeKeyJSON, err := json.Marshal(mergeKeyValues)
+		if err != nil {
+			return nil, err
+		}
+		mergeKey := string(mergeKeyJSON)
+
+		if _, exists := res[mergeKey]; exists {
+			return nil, fmt.Errorf("%v, duplicate key was %s", NonUniqueParamSets, mergeKey)
+		}
+		res[mergeKey] = paramSet
+	}
+
+	return res, nil
+}
+
+func (m *MergeGenerator) GetRequeueAfter(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator, appSet *argoprojiov1alpha1.ApplicationSet) time.Duration {
+	return getChildGeneratorsRequeueAfter(appSetGenerator.Merge.Generators, m.supportedGenerators, appSet)
+}
+
+func (m *MergeGenerator) GetTemplate(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator) *argoprojiov1alpha1.ApplicationSetTemplate {
+	return &appSetGenerator.Merge.Template
+}
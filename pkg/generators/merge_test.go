@@ -0,0 +1,149 @@
+package generators
+
+import (
+	"testing"
+
+	argoprojiov1alpha1 "github.com/argoproj-labs/applicationset/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+func getListGenerator(elements ...string) *argoprojiov1alpha1.ListGenerator {
+	listGenerator := &argoprojiov1alpha1.ListGenerator{}
+	for _, element := range elements {
+		listGenerator.Elements = append(listGenerator.Elements, apiextensionsv1.JSON{Raw: []byte(element)})
+	}
+	return listGenerator
+}
+
+func TestMergeGenerate(t *testing.T) {
+
+	testCases := []struct {
+		name           string
+		baseGenerators []argoprojiov1alpha1.ApplicationSetBaseGenerator
+		mergeKeys      []string
+		expectedErr    string
+		expected       []map[string]interface{}
+	}{
+		{
+			name: "override the params of matching param sets",
+			baseGenerators: []argoprojiov1alpha1.ApplicationSetBaseGenerator{
+				{
+					List: getListGenerator(
+						`{"cluster": "dev", "url": "https://1.2.3.4", "values": {"replicas": "1"}}`,
+						`{"cluster": "prod", "url": "https://2.4.6.8", "values": {"replicas": "1"}}`),
+				},
+				{
+					List: getListGenerator(
+						`{"cluster": "prod", "values": {"replicas": "3"}}`,
+						`{"cluster": "unknown", "values": {"replicas": "5"}}`),
+				},
+			},
+			mergeKeys: []string{"cluster"},
+			expected: []map[string]interface{}{
+				{"cluster": "dev", "url": "https://1.2.3.4", "values.replicas": "1"},
+				{"cluster": "prod", "url": "https://2.4.6.8", "values.replicas": "3"},
+			},
+		},
+		{
+			name: "later generators take precedence",
+			baseGenerators: []argoprojiov1alpha1.ApplicationSetBaseGenerator{
+				{
+					List: getListGenerator(`{"cluster": "dev", "values": {"replicas": "1"}}`),
+				},
+				{
+					List: getListGenerator(`{"cluster": "dev", "values": {"replicas": "2", "zone": "a"}}`),
+				},
+				{
+					List: getListGenerator(`{"cluster": "dev", "values": {"replicas": "3"}}`),
+				},
+			},
+			mergeKeys: []string{"cluster"},
+			expected: []map[string]interface{}{
+				{"cluster": "dev", "values.replicas": "3", "values.zone": "a"},
+			},
+		},
+		{
+			name: "match on all the merge keys",
+			baseGenerators: []argoprojiov1alpha1.ApplicationSetBaseGenerator{
+				{
+					List: getListGenerator(
+						`{"cluster": "dev", "app": "a", "values": {"replicas": "1"}}`,
+						`{"cluster": "dev", "app": "b", "values": {"replicas": "1"}}`),
+				},
+				{
+					List: getListGenerator(`{"cluster": "dev", "app": "b", "values": {"replicas": "2"}}`),
+				},
+			},
+			mergeKeys: []string{"cluster", "app"},
+			expected: []map[string]interface{}{
+				{"cluster": "dev", "app": "a", "values.replicas": "1"},
+				{"cluster": "dev", "app": "b", "values.replicas": "2"},
+			},
+		},
+		{
+			name: "returns error if there is less than two base generators",
+			baseGenerators: []argoprojiov1alpha1.ApplicationSetBaseGenerator{
+				{
+					List: getListGenerator(`{"cluster": "dev"}`),
+				},
+			},
+			mergeKeys:   []string{"cluster"},
+			expectedErr: LessThanTwoGeneratorsInMerge.Error(),
+		},
+		{
+			name: "returns error if there are no merge keys",
+			baseGenerators: []argoprojiov1alpha1.ApplicationSetBaseGenerator{
+				{
+					List: getListGenerator(`{"cluster": "dev"}`),
+				},
+				{
+					List: getListGenerator(`{"cluster": "dev"}`),
+				},
+			},
+			expectedErr: NoMergeKeys.Error(),
+		},
+		{
+			name: "returns error if the params are not unique by the merge keys",
+			baseGenerators: []argoprojiov1alpha1.ApplicationSetBaseGenerator{
+				{
+					List: getListGenerator(`{"cluster": "dev"}`),
+				},
+				{
+					List: getListGenerator(`{"cluster": "dev", "app": "a"}`, `{"cluster": "dev", "app": "b"}`),
+				},
+			},
+			mergeKeys:   []string{"cluster"},
+			expectedErr: `error merging params of merge child generator 1 (list): ` + NonUniqueParamSets.Error() + `, duplicate key was {"cluster":"dev"}`,
+		},
+	}
+
+	for _, c := range testCases {
+		cc := c
+
+		t.Run(cc.name, func(t *testing.T) {
+			appSet := &argoprojiov1alpha1.ApplicationSet{}
+
+			var mergeGenerator = NewMergeGenerator(
+				map[string]Generator{
+					"List": &ListGenerator{},
+				},
+			)
+
+			got, err := mergeGenerator.GenerateParams(&argoprojiov1alpha1.ApplicationSetGenerator{
+				Merge: &argoprojiov1alpha1.MergeGenerator{
+					Generators: cc.baseGenerators,
+					MergeKeys:  cc.mergeKeys,
+					Template:   argoprojiov1alpha1.ApplicationSetTemplate{},
+				},
+			}, appSet)
+
+			if cc.expectedErr != "" {
+				assert.EqualError(t, err, cc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, cc.expected, got)
+			}
+		})
+	}
+}
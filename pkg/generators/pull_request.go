@@ -0,0 +1,98 @@
+package generators
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	argoprojiov1alpha1 "github.com/argoproj-labs/applicationset/api/v1alpha1"
+	"github.com/argoproj-labs/applicationset/pkg/services/pull_request"
+)
+
+var _ Generator = (*PullRequestGenerator)(nil)
+
+const (
+	DefaultPullRequestRequeueAfterSeconds = 30 * time.Minute
+)
+
+type PullRequestGenerator struct {
+	client client.Client
+	// Testing hooks.
+	overrideService pull_request.PullRequestService
+}
+
+func NewPullRequestGenerator(client client.Client) Generator {
+	return &PullRequestGenerator{client: client}
+}
+
+func (g *PullRequestGenerator) GetRequeueAfter(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator, _ *argoprojiov1alpha1.ApplicationSet) time.Duration {
+	// Return a requeue default of 30 minutes, if no default is specified.
+
+	if appSetGenerator.PullRequest.RequeueAfterSeconds != nil {
+		return time.Duration(*appSetGenerator.PullRequest.RequeueAfterSeconds) * time.Second
+	}
+
+	return DefaultPullRequestRequeueAfterSeconds
+}
+
+func (g *PullRequestGenerator) GetTemplate(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator) *argoprojiov1alpha1.ApplicationSetTemplate {
+	return &appSetGenerator.PullRequest.Template
+}
+
+func (g *PullRequestGenerator) GenerateParams(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator, applicationSetInfo *argoprojiov1alpha1.ApplicationSet) ([]map[string]interface{}, error) {
+	if appSetGenerator == nil {
+		return nil, EmptyAppSetGeneratorError
+	}
+
+	if appSetGenerator.PullRequest == nil {
+		return nil, EmptyAppSetGeneratorError
+	}
+
+	ctx := context.Background()
+
+	// Create the pull request service helper.
+	providerConfig := appSetGenerator.PullRequest
+	var svc pull_request.PullRequestService
+	if g.overrideService != nil {
+		svc = g.overrideService
+	} else if providerConfig.Github != nil {
+		token, err := getSecretRef(ctx, g.client, providerConfig.Github.TokenRef, applicationSetInfo.Namespace)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching Github token: %v", err)
+		}
+		svc, err = pull_request.NewGithubService(ctx, token, providerConfig.Github.API, providerConfig.Github.Owner, providerConfig.Github.Repo, providerConfig.Github.Labels)
+		if err != nil {
+			return nil, fmt.Errorf("error initializing Github service: %v", err)
+		}
+	} else if providerConfig.Gitlab != nil {
+		token, err := getSecretRef(ctx, g.client, providerConfig.Gitlab.TokenRef, applicationSetInfo.Namespace)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching Gitlab token: %v", err)
+		}
+		svc, err = pull_request.NewGitlabService(ctx, token, providerConfig.Gitlab.API, providerConfig.Gitlab.Project, providerConfig.Gitlab.Labels)
+		if err != nil {
+			return nil, fmt.Errorf("error initializing Gitlab service: %v", err)
+		}
+	} else {
+		return nil, fmt.Errorf("no pull request provider implementation configured")
+	}
+
+	pullRequests, err := svc.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing pull requests: %v", err)
+	}
+	params := make([]map[string]interface{}, 0, len(pullRequests))
+	for _, pull := range pullRequests {
+		params = append(params, map[string]interface{}{
+			"number":   strconv.Itoa(pull.Number),
+			"branch":   pull.Branch,
+			"head_sha": pull.HeadSHA,
+			"labels":   strings.Join(pull.Labels, ","),
+		})
+	}
+	return params, nil
+}
@@ -0,0 +1,117 @@
+package generators
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	argoprojiov1alpha1 "github.com/argoproj-labs/applicationset/api/v1alpha1"
+	"github.com/argoproj-labs/applicationset/pkg/services/pull_request"
+)
+
+func TestPullRequestGenerateParams(t *testing.T) {
+	mockService := &pull_request.MockService{
+		PullRequests: []*pull_request.PullRequest{
+			{
+				Number:  1,
+				Branch:  "feature-a",
+				HeadSHA: "b7ad4e2d1ac5d1b4e8a0e2a1a4b4c5c6d7e8f901",
+				Labels:  []string{"preview", "team-a"},
+			},
+			{
+				Number:  2,
+				Branch:  "feature-b",
+				HeadSHA: "a1b2c3d4e5f60718293a4b5c6d7e8f9012345678",
+			},
+		},
+	}
+	gen := &PullRequestGenerator{overrideService: mockService}
+	params, err := gen.GenerateParams(&argoprojiov1alpha1.ApplicationSetGenerator{
+		PullRequest: &argoprojiov1alpha1.PullRequestGenerator{},
+	}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []map[string]interface{}{
+		{
+			"number":   "1",
+			"branch":   "feature-a",
+			"head_sha": "b7ad4e2d1ac5d1b4e8a0e2a1a4b4c5c6d7e8f901",
+			"labels":   "preview,team-a",
+		},
+		{
+			"number":   "2",
+			"branch":   "feature-b",
+			"head_sha": "a1b2c3d4e5f60718293a4b5c6d7e8f9012345678",
+			"labels":   "",
+		},
+	}, params)
+}
+
+func TestPullRequestGenerateParamsErrors(t *testing.T) {
+	appSet := &argoprojiov1alpha1.ApplicationSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "set", Namespace: "test"},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "test"},
+		Data: map[string][]byte{
+			"token": []byte("secret"),
+		},
+	}
+
+	cases := []struct {
+		name          string
+		generator     *argoprojiov1alpha1.PullRequestGenerator
+		service       pull_request.PullRequestService
+		expectedError string
+	}{
+		{
+			name:          "no provider",
+			generator:     &argoprojiov1alpha1.PullRequestGenerator{},
+			expectedError: "no pull request provider implementation configured",
+		},
+		{
+			name: "missing token secret",
+			generator: &argoprojiov1alpha1.PullRequestGenerator{
+				Github: &argoprojiov1alpha1.PullRequestGeneratorGithub{
+					Owner:    "argoproj",
+					Repo:     "applicationset",
+					TokenRef: &argoprojiov1alpha1.SecretRef{SecretName: "other-secret", Key: "token"},
+				},
+			},
+			expectedError: "error fetching Github token",
+		},
+		{
+			name: "missing token key",
+			generator: &argoprojiov1alpha1.PullRequestGenerator{
+				Gitlab: &argoprojiov1alpha1.PullRequestGeneratorGitlab{
+					Project:  "42",
+					TokenRef: &argoprojiov1alpha1.SecretRef{SecretName: "test-secret", Key: "other"},
+				},
+			},
+			expectedError: "error fetching Gitlab token",
+		},
+		{
+			name:          "list error",
+			generator:     &argoprojiov1alpha1.PullRequestGenerator{},
+			service:       &pull_request.MockService{Err: errors.New("fake error")},
+			expectedError: "error listing pull requests: fake error",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gen := &PullRequestGenerator{
+				client:          fake.NewClientBuilder().WithObjects(secret).Build(),
+				overrideService: c.service,
+			}
+			_, err := gen.GenerateParams(&argoprojiov1alpha1.ApplicationSetGenerator{
+				PullRequest: c.generator,
+			}, appSet)
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), c.expectedError)
+		})
+	}
+}
@@ -29,7 +29,7 @@ func NewSCMProviderGenerator(client client.Client) Generator {
 	return &SCMProviderGenerator{client: client}
 }
 
-func (g *SCMProviderGenerator) GetRequeueAfter(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator) time.Duration {
+func (g *SCMProviderGenerator) GetRequeueAfter(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator, _ *argoprojiov1alpha1.ApplicationSet) time.Duration {
 	// Return a requeue default of 30 minutes, if no default is specified.
 
 	if appSetGenerator.SCMProvider.RequeueAfterSeconds != nil {
@@ -43,7 +43,7 @@ func (g *SCMProviderGenerator) GetTemplate(appSetGenerator *argoprojiov1alpha1.A
 	return &appSetGenerator.SCMProvider.Template
 }
 
-func (g *SCMProviderGenerator) GenerateParams(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator, applicationSetInfo *argoprojiov1alpha1.ApplicationSet) ([]map[string]string, error) {
+func (g *SCMProviderGenerator) GenerateParams(appSetGenerator *argoprojiov1alpha1.ApplicationSetGenerator, applicationSetInfo *argoprojiov1alpha1.ApplicationSet) ([]map[string]interface{}, error) {
 	if appSetGenerator == nil {
 		return nil, EmptyAppSetGeneratorError
 	}
@@ -60,7 +60,7 @@ func (g *SCMProviderGenerator) GenerateParams(appSetGenerator *argoprojiov1alpha
 	if g.overrideProvider != nil {
 		provider = g.overrideProvider
 	} else if providerConfig.Github != nil {
-		token, err := g.getSecretRef(ctx, providerConfig.Github.TokenRef, applicationSetInfo.Namespace)
+		token, err := getSecretRef(ctx, g.client, providerConfig.Github.TokenRef, applicationSetInfo.Namespace)
 		if err != nil {
 			return nil, fmt.Errorf("error fetching Github token: %v", err)
 		}
@@ -69,7 +69,7 @@ func (g *SCMProviderGenerator) GenerateParams(appSetGenerator *argoprojiov1alpha
 			return nil, fmt.Errorf("error initializing Github service: %v", err)
 		}
 	} else if providerConfig.Gitlab != nil {
-		token, err := g.getSecretRef(ctx, providerConfig.Gitlab.TokenRef, applicationSetInfo.Namespace)
+		token, err := getSecretRef(ctx, g.client, providerConfig.Gitlab.TokenRef, applicationSetInfo.Namespace)
 		if err != nil {
 			return nil, fmt.Errorf("error fetching Gitlab token: %v", err)
 		}
@@ -77,6 +77,30 @@ func (g *SCMProviderGenerator) GenerateParams(appSetGenerator *argoprojiov1alpha
 		if err != nil {
 			return nil, fmt.Errorf("error initializing Gitlab service: %v", err)
 		}
+	} else if providerConfig.Gitea != nil {
+		token, err := getSecretRef(ctx, g.client, providerConfig.Gitea.TokenRef, applicationSetInfo.Namespace)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching Gitea token: %v", err)
+		}
+		provider, err = scm_provider.NewGiteaProvider(ctx, providerConfig.Gitea.Owner, token, providerConfig.Gitea.API, providerConfig.Gitea.AllBranches, providerConfig.Gitea.Insecure)
+		if err != nil {
+			return nil, fmt.Errorf("error initializing Gitea service: %v", err)
+		}
+	} else if providerConfig.AzureDevOps != nil {
+		token, err := getSecretRef(ctx, g.client, providerConfig.AzureDevOps.AccessTokenRef, applicationSetInfo.Namespace)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching Azure DevOps access token: %v", err)
+		}
+		provider, err = scm_provider.NewAzureDevOpsProvider(ctx, token, providerConfig.AzureDevOps.Organization, providerConfig.AzureDevOps.API, providerConfig.AzureDevOps.TeamProject, providerConfig.AzureDevOps.AllBranches)
+		if err != nil {
+			return nil, fmt.Errorf("error initializing Azure DevOps service: %v", err)
+		}
+	} else if providerConfig.BitbucketServer != nil {
+		var err error
+		provider, err = g.bitbucketServerProvider(ctx, providerConfig.BitbucketServer, applicationSetInfo.Namespace)
+		if err != nil {
+			return nil, fmt.Errorf("error initializing Bitbucket Server service: %v", err)
+		}
 	} else {
 		return nil, fmt.Errorf("no SCM provider implementation configured")
 	}
@@ -86,9 +110,9 @@ func (g *SCMProviderGenerator) GenerateParams(appSetGenerator *argoprojiov1alpha
 	if err != nil {
 		return nil, fmt.Errorf("error listing repos: %v", err)
 	}
-	params := make([]map[string]string, 0, len(repos))
+	params := make([]map[string]interface{}, 0, len(repos))
 	for _, repo := range repos {
-		params = append(params, map[string]string{
+		params = append(params, map[string]interface{}{
 			"organization": repo.Organization,
 			"repository":   repo.Repository,
 			"url":          repo.URL,
@@ -99,13 +123,35 @@ func (g *SCMProviderGenerator) GenerateParams(appSetGenerator *argoprojiov1alpha
 	return params, nil
 }
 
-func (g *SCMProviderGenerator) getSecretRef(ctx context.Context, ref *argoprojiov1alpha1.SecretRef, namespace string) (string, error) {
+func (g *SCMProviderGenerator) bitbucketServerProvider(ctx context.Context, providerConfig *argoprojiov1alpha1.SCMProviderGeneratorBitbucketServer, namespace string) (scm_provider.SCMProviderService, error) {
+	if providerConfig.BasicAuth != nil {
+		password, err := getSecretRef(ctx, g.client, providerConfig.BasicAuth.PasswordRef, namespace)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching Bitbucket Server password: %v", err)
+		}
+		return scm_provider.NewBitbucketServerProviderBasicAuth(ctx, providerConfig.BasicAuth.Username, password, providerConfig.API, providerConfig.Project, providerConfig.AllBranches)
+	}
+
+	if providerConfig.BearerToken != nil {
+		token, err := getSecretRef(ctx, g.client, providerConfig.BearerToken.TokenRef, namespace)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching Bitbucket Server token: %v", err)
+		}
+		return scm_provider.NewBitbucketServerProviderBearerToken(ctx, token, providerConfig.API, providerConfig.Project, providerConfig.AllBranches)
+	}
+
+	return scm_provider.NewBitbucketServerProviderNoAuth(ctx, providerConfig.API, providerConfig.Project, providerConfig.AllBranches)
+}
+
+// getSecretRef returns the value stored under the key of the referenced Secret, which must live in the
+// ApplicationSet's namespace. A nil ref returns an empty value.
+func getSecretRef(ctx context.Context, k8sClient client.Client, ref *argoprojiov1alpha1.SecretRef, namespace string) (string, error) {
 	if ref == nil {
 		return "", nil
 	}
 
 	secret := &corev1.Secret{}
-	err := g.client.Get(
+	err := k8sClient.Get(
 		ctx,
 		client.ObjectKey{
 			Name:      ref.SecretName,
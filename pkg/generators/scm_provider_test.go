@@ -20,7 +20,7 @@ func TestSCMProviderGetSecretRef(t *testing.T) {
 			"my-token": []byte("secret"),
 		},
 	}
-	gen := &SCMProviderGenerator{client: fake.NewClientBuilder().WithObjects(secret).Build()}
+	client := fake.NewClientBuilder().WithObjects(secret).Build()
 	ctx := context.Background()
 
 	cases := []struct {
@@ -67,7 +67,7 @@ func TestSCMProviderGetSecretRef(t *testing.T) {
 
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
-			token, err := gen.getSecretRef(ctx, c.ref, c.namespace)
+			token, err := getSecretRef(ctx, client, c.ref, c.namespace)
 			if c.hasError {
 				assert.NotNil(t, err)
 			} else {
@@ -2,6 +2,9 @@ package generators
 
 import (
 	"fmt"
+	"time"
+
+	argoprojiov1alpha1 "github.com/argoproj-labs/applicationset/api/v1alpha1"
 )
 
 func CombineMaps(a map[string]Generator, b map[string]Generator) (map[string]Generator, error) {
@@ -21,3 +24,67 @@ func CombineMaps(a map[string]Generator, b map[string]Generator) (map[string]Gen
 
 	return res, nil
 }
+
+// toApplicationSetGenerator converts a child generator of a Matrix or Merge generator into an ApplicationSetGenerator,
+// so that it can be handed to the generator implementations.
+func toApplicationSetGenerator(appSetBaseGenerator argoprojiov1alpha1.ApplicationSetBaseGenerator) *argoprojiov1alpha1.ApplicationSetGenerator {
+	return &argoprojiov1alpha1.ApplicationSetGenerator{
+		List:                    appSetBaseGenerator.List,
+		Clusters:                appSetBaseGenerator.Clusters,
+		Git:                     appSetBaseGenerator.Git,
+		SCMProvider:             appSetBaseGenerator.SCMProvider,
+		ClusterDecisionResource: appSetBaseGenerator.ClusterDecisionResource,
+		PullRequest:             appSetBaseGenerator.PullRequest,
+	}
+}
+
+// getChildGeneratorParams returns the params of a child generator of a Matrix or Merge generator. The child generator
+// must contain exactly one supported generator.
+func getChildGeneratorParams(appSetBaseGenerator argoprojiov1alpha1.ApplicationSetBaseGenerator, supportedGenerators map[string]Generator, appSet *argoprojiov1alpha1.ApplicationSet) ([]map[string]interface{}, error) {
+
+	t, err := Transform(
+		*toApplicationSetGenerator(appSetBaseGenerator),
+		supportedGenerators,
+		argoprojiov1alpha1.ApplicationSetTemplate{},
+		appSet)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(t) == 0 {
+		return nil, NoInnerGenerators
+	}
+
+	if len(t) > 1 {
+		return nil, MoreThenOneInnerGenerators
+	}
+
+	return t[0].Params, nil
+}
+
+// getChildGeneratorsRequeueAfter returns the minimal requeue time of the child generators of a Matrix or Merge
+// generator, or NoRequeueAfter if none of them requested one.
+func getChildGeneratorsRequeueAfter(appSetBaseGenerators []argoprojiov1alpha1.ApplicationSetBaseGenerator, supportedGenerators map[string]Generator, appSet *argoprojiov1alpha1.ApplicationSet) time.Duration {
+	res := maxDuration
+	var found bool
+
+	for _, r := range appSetBaseGenerators {
+		base := toApplicationSetGenerator(r)
+		generators := GetRelevantGenerators(base, supportedGenerators)
+
+		for _, g := range generators {
+			temp := g.GetRequeueAfter(base, appSet)
+			if temp < res && temp != NoRequeueAfter {
+				found = true
+				res = temp
+			}
+		}
+	}
+
+	if found {
+		return res
+	} else {
+		return NoRequeueAfter
+	}
+}
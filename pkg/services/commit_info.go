@@ -0,0 +1,63 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lastCommitFormat separates the SHA, author, commit time and message of the commit with NUL characters, which cannot
+// appear in them.
+const lastCommitFormat = "--format=%H%x00%an <%ae>%x00%ct%x00%B"
+
+// lastCommit returns the last commit that changed the path up to the revision, in the Git working tree at dir, or nil
+// if no commit changed it.
+func lastCommit(ctx context.Context, dir string, revision string, path string) (*CommitInfo, error) {
+	path = strings.TrimPrefix(path, "/")
+	if path == "" {
+		path = "."
+	}
+
+	// Paths are taken literally, rather than as patterns, as directory names may contain wildcards
+	out, err := runGit(ctx, dir, "--literal-pathspecs", "log", "-1", lastCommitFormat, revision, "--", path)
+	if err != nil {
+		return nil, fmt.Errorf("Error during reading the last commit of %s: %v", path, err)
+	}
+	if len(bytes.TrimSpace(out)) == 0 {
+		return nil, nil
+	}
+
+	fields := strings.SplitN(string(out), "\x00", 4)
+	if len(fields) != 4 {
+		return nil, fmt.Errorf("Error during reading the last commit of %s: unexpected output %q", path, out)
+	}
+	commitTime, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("Error during reading the last commit of %s: %v", path, err)
+	}
+
+	return &CommitInfo{
+		SHA:     fields[0],
+		Author:  fields[1],
+		Date:    time.Unix(commitTime, 0).UTC(),
+		Message: strings.TrimSpace(fields[3]),
+	}, nil
+}
+
+// runGit runs the git command in dir, and returns its standard output. The standard error is included in the error.
+func runGit(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("`git %s` failed: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/argoproj/argo-cd/v2/util/git"
+)
+
+// localRepos serves the files of local checkouts of repositories, rather than cloning them. It is used to render
+// ApplicationSets offline, in which case the revision requested by the generators is ignored: the files are read as
+// they are checked out.
+type localRepos struct {
+	// checkouts maps the normalized repository URLs to the directories they are checked out in
+	checkouts map[string]string
+}
+
+// NewLocalRepos returns a Repos backed by local checkouts, as a map of repository URLs to checkout directories.
+func NewLocalRepos(checkouts map[string]string) Repos {
+	normalized := map[string]string{}
+	for repoURL, dir := range checkouts {
+		normalized[git.NormalizeGitURL(repoURL)] = dir
+	}
+
+	return &localRepos{
+		checkouts: normalized,
+	}
+}
+
+func (l *localRepos) checkoutDir(repoURL string) (string, error) {
+	dir, exists := l.checkouts[git.NormalizeGitURL(repoURL)]
+	if !exists {
+		return "", fmt.Errorf("no local checkout was provided for repository %s", repoURL)
+	}
+	return dir, nil
+}
+
+func (l *localRepos) GetFilePaths(ctx context.Context, repoURL string, revision string, pattern string) ([]string, error) {
+	repoRoot, err := l.checkoutDir(repoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	pathspec, err := pathspecToRegexp(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := []string{}
+
+	if err := filepath.Walk(repoRoot, func(path string, info os.FileInfo, fnErr error) error {
+		if fnErr != nil {
+			return fnErr
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		relativePath, err := filepath.Rel(repoRoot, path)
+		if err != nil {
+			return err
+		}
+		relativePath = filepath.ToSlash(relativePath)
+
+		if matchesPathspec(pathspec, relativePath) {
+			paths = append(paths, relativePath)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(paths)
+	return paths, nil
+}
+
+func (l *localRepos) GetDirectories(ctx context.Context, repoURL string, revision string) ([]string, error) {
+	repoRoot, err := l.checkoutDir(repoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return listDirectories(repoRoot)
+}
+
+func (l *localRepos) GetFileContent(ctx context.Context, repoURL string, revision string, path string) ([]byte, error) {
+	repoRoot, err := l.checkoutDir(repoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.ReadFile(filepath.Join(repoRoot, path))
+}
+
+// GetRevisionInfo describes the commit checked out in the local checkout, rather than the one the revision resolves
+// to. It returns nil if the checkout is not a Git working tree.
+func (l *localRepos) GetRevisionInfo(ctx context.Context, repoURL string, revision string, paths []string) (*RevisionInfo, error) {
+	repoRoot, err := l.checkoutDir(repoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := runGit(ctx, repoRoot, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, nil
+	}
+
+	info := &RevisionInfo{
+		SHA:         strings.TrimSpace(string(out)),
+		LastCommits: map[string]CommitInfo{},
+	}
+	for _, path := range paths {
+		commit, err := lastCommit(ctx, repoRoot, info.SHA, path)
+		if err != nil {
+			return nil, err
+		}
+		if commit != nil {
+			info.LastCommits[path] = *commit
+		}
+	}
+	return info, nil
+}
+
+// pathspecToRegexp converts a git pathspec into a regular expression. As with 'git ls-files', wildcards match '/'.
+func pathspecToRegexp(pattern string) (*regexp.Regexp, error) {
+	var expr strings.Builder
+	expr.WriteString("^")
+
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				expr.WriteString(regexp.QuoteMeta("["))
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + class + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+			}
+			expr.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	expr.WriteString("$")
+	return regexp.Compile(expr.String())
+}
+
+// matchesPathspec returns whether the path, or one of its parent directories, matches the pathspec.
+func matchesPathspec(pathspec *regexp.Regexp, path string) bool {
+	for {
+		if pathspec.MatchString(path) {
+			return true
+		}
+		i := strings.LastIndexByte(path, '/')
+		if i < 0 {
+			return false
+		}
+		path = path[:i]
+	}
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestCheckout(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for path, content := range files {
+		fullPath := filepath.Join(dir, filepath.FromSlash(path))
+		err := os.MkdirAll(filepath.Dir(fullPath), 0755)
+		assert.NoError(t, err)
+		err = os.WriteFile(fullPath, []byte(content), 0644)
+		assert.NoError(t, err)
+	}
+	return dir
+}
+
+func TestLocalReposGetFilePaths(t *testing.T) {
+	dir := writeTestCheckout(t, map[string]string{
+		".git/config": "",
+		"README.md":   "",
+		"cluster-config/engineering/dev/config.json":  "{}",
+		"cluster-config/engineering/prod/config.json": "{}",
+		"cluster-config/engineering/prod/values.yaml": "",
+		"apps/[guestbook]/config.json":                "{}",
+	})
+
+	repos := NewLocalRepos(map[string]string{"https://github.com/argoproj/argocd-example-apps.git": dir})
+
+	for _, c := range []struct {
+		name          string
+		pattern       string
+		expectedPaths []string
+	}{
+		{
+			name:          "wildcards match across directories",
+			pattern:       "cluster-config/**/config.json",
+			expectedPaths: []string{"cluster-config/engineering/dev/config.json", "cluster-config/engineering/prod/config.json"},
+		},
+		{
+			name:          "single wildcard",
+			pattern:       "*.json",
+			expectedPaths: []string{"apps/[guestbook]/config.json", "cluster-config/engineering/dev/config.json", "cluster-config/engineering/prod/config.json"},
+		},
+		{
+			name:          "question mark and character class",
+			pattern:       "cluster-config/engineering/[dp]???/config.json",
+			expectedPaths: []string{"cluster-config/engineering/prod/config.json"},
+		},
+		{
+			name:          "escaped characters",
+			pattern:       `apps/\[guestbook\]/config.json`,
+			expectedPaths: []string{"apps/[guestbook]/config.json"},
+		},
+		{
+			name:          "directory matches the files within",
+			pattern:       "cluster-config/engineering/prod",
+			expectedPaths: []string{"cluster-config/engineering/prod/config.json", "cluster-config/engineering/prod/values.yaml"},
+		},
+		{
+			name:          "no match",
+			pattern:       "cluster-config/*.yml",
+			expectedPaths: []string{},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			paths, err := repos.GetFilePaths(context.Background(), "https://github.com/argoproj/argocd-example-apps", "HEAD", c.pattern)
+			assert.NoError(t, err)
+			assert.Equal(t, c.expectedPaths, paths)
+		})
+	}
+}
+
+func TestLocalReposGetDirectories(t *testing.T) {
+	dir := writeTestCheckout(t, map[string]string{
+		".git/config":           "",
+		".github/ci.yaml":       "",
+		"apps/guestbook/a.yaml": "",
+		"apps/helm/b.yaml":      "",
+	})
+
+	repos := NewLocalRepos(map[string]string{"https://github.com/argoproj/argocd-example-apps.git": dir})
+
+	directories, err := repos.GetDirectories(context.Background(), "https://github.com/argoproj/argocd-example-apps.git", "HEAD")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"apps", "apps/guestbook", "apps/helm"}, directories)
+}
+
+func TestLocalReposGetFileContent(t *testing.T) {
+	dir := writeTestCheckout(t, map[string]string{
+		"cluster-config/engineering/dev/config.json": `{"cluster": "dev"}`,
+	})
+
+	repos := NewLocalRepos(map[string]string{"https://github.com/argoproj/argocd-example-apps.git": dir})
+
+	content, err := repos.GetFileContent(context.Background(), "https://github.com/argoproj/argocd-example-apps.git", "HEAD", "cluster-config/engineering/dev/config.json")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"cluster": "dev"}`, string(content))
+
+	_, err = repos.GetFileContent(context.Background(), "https://github.com/argoproj/other.git", "HEAD", "cluster-config/engineering/dev/config.json")
+	assert.EqualError(t, err, "no local checkout was provided for repository https://github.com/argoproj/other.git")
+}
+
+// commitTestCheckout commits all the files of the checkout, with a fixed author and date.
+func commitTestCheckout(t *testing.T, dir string, message string, date time.Time) {
+	for _, args := range [][]string{{"add", "-A"}, {"commit", "-q", "-m", message}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Jane Doe", "GIT_AUTHOR_EMAIL=jane@example.com", "GIT_AUTHOR_DATE="+date.Format(time.RFC3339),
+			"GIT_COMMITTER_NAME=Jane Doe", "GIT_COMMITTER_EMAIL=jane@example.com", "GIT_COMMITTER_DATE="+date.Format(time.RFC3339))
+		out, err := cmd.CombinedOutput()
+		assert.NoError(t, err, string(out))
+	}
+}
+
+func TestLocalReposGetRevisionInfo(t *testing.T) {
+	dir := writeTestCheckout(t, map[string]string{
+		"apps/guestbook/deployment.yaml": "replicas: 1",
+		"apps/[helm]/Chart.yaml":         "name: helm",
+	})
+	_, err := runGit(context.Background(), dir, "init", "-q")
+	assert.NoError(t, err)
+
+	firstDate := time.Date(2021, 6, 17, 8, 0, 0, 0, time.UTC)
+	commitTestCheckout(t, dir, "Add the apps", firstDate)
+
+	err = os.WriteFile(filepath.Join(dir, "apps/guestbook/deployment.yaml"), []byte("replicas: 2"), 0644)
+	assert.NoError(t, err)
+	secondDate := time.Date(2021, 6, 18, 9, 21, 33, 0, time.UTC)
+	commitTestCheckout(t, dir, "Scale the guestbook\n\nTo handle more traffic.", secondDate)
+
+	head, err := runGit(context.Background(), dir, "rev-parse", "HEAD")
+	assert.NoError(t, err)
+
+	repos := NewLocalRepos(map[string]string{"https://github.com/argoproj/argocd-example-apps.git": dir})
+	info, err := repos.GetRevisionInfo(context.Background(), "https://github.com/argoproj/argocd-example-apps", "HEAD",
+		[]string{"apps/guestbook", "apps/[helm]", "apps/guestbook/deployment.yaml", "does-not-exist"})
+	assert.NoError(t, err)
+	if !assert.NotNil(t, info) {
+		return
+	}
+
+	assert.Equal(t, string(head[:40]), info.SHA)
+	assert.Len(t, info.LastCommits, 3)
+	assert.Equal(t, CommitInfo{
+		SHA:     info.SHA,
+		Author:  "Jane Doe <jane@example.com>",
+		Date:    secondDate,
+		Message: "Scale the guestbook\n\nTo handle more traffic.",
+	}, info.LastCommits["apps/guestbook"])
+	assert.Equal(t, info.LastCommits["apps/guestbook"], info.LastCommits["apps/guestbook/deployment.yaml"])
+
+	helmCommit := info.LastCommits["apps/[helm]"]
+	assert.NotEqual(t, info.SHA, helmCommit.SHA)
+	assert.Equal(t, "Add the apps", helmCommit.Message)
+	assert.Equal(t, firstDate, helmCommit.Date)
+}
+
+func TestLocalReposGetRevisionInfoNotAGitCheckout(t *testing.T) {
+	dir := writeTestCheckout(t, map[string]string{
+		"apps/guestbook/deployment.yaml": "",
+	})
+
+	repos := NewLocalRepos(map[string]string{"https://github.com/argoproj/argocd-example-apps.git": dir})
+	info, err := repos.GetRevisionInfo(context.Background(), "https://github.com/argoproj/argocd-example-apps.git", "HEAD", []string{"apps/guestbook"})
+	assert.NoError(t, err)
+	assert.Nil(t, info)
+}
@@ -0,0 +1,81 @@
+package pull_request
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v35/github"
+	"golang.org/x/oauth2"
+)
+
+type GithubService struct {
+	client *github.Client
+	owner  string
+	repo   string
+	labels []string
+}
+
+var _ PullRequestService = &GithubService{}
+
+func NewGithubService(ctx context.Context, token, url, owner, repo string, labels []string) (*GithubService, error) {
+	var ts oauth2.TokenSource
+	if token != "" {
+		ts = oauth2.StaticTokenSource(
+			&oauth2.Token{AccessToken: token},
+		)
+	}
+	httpClient := oauth2.NewClient(ctx, ts)
+	var client *github.Client
+	if url == "" {
+		client = github.NewClient(httpClient)
+	} else {
+		var err error
+		client, err = github.NewEnterpriseClient(url, url, httpClient)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &GithubService{
+		client: client,
+		owner:  owner,
+		repo:   repo,
+		labels: labels,
+	}, nil
+}
+
+func (g *GithubService) List(ctx context.Context) ([]*PullRequest, error) {
+	opts := &github.PullRequestListOptions{
+		State: "open",
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+	pullRequests := []*PullRequest{}
+	for {
+		pulls, resp, err := g.client.PullRequests.List(ctx, g.owner, g.repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error listing pull requests for %s/%s: %v", g.owner, g.repo, err)
+		}
+		for _, pull := range pulls {
+			labels := make([]string, 0, len(pull.Labels))
+			for _, label := range pull.Labels {
+				labels = append(labels, label.GetName())
+			}
+			// The GitHub API doesn't support filtering pull requests by label, so filter them here.
+			if !containLabels(g.labels, labels) {
+				continue
+			}
+			pullRequests = append(pullRequests, &PullRequest{
+				Number:  pull.GetNumber(),
+				Branch:  pull.GetHead().GetRef(),
+				HeadSHA: pull.GetHead().GetSHA(),
+				Labels:  labels,
+			})
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return pullRequests, nil
+}
@@ -0,0 +1,115 @@
+package pull_request
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func githubMockHandler(t *testing.T) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v3/repos/argoproj/applicationset/pulls", r.URL.Path)
+		assert.Equal(t, "open", r.URL.Query().Get("state"))
+		assert.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("page") {
+		case "", "1":
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s%s?page=2>; rel="next", <http://%s%s?page=2>; rel="last"`, r.Host, r.URL.Path, r.Host, r.URL.Path))
+			_, err := w.Write([]byte(`[
+				{
+					"number": 1,
+					"state": "open",
+					"labels": [{"name": "preview"}, {"name": "team-a"}],
+					"head": {"ref": "feature-a", "sha": "b7ad4e2d1ac5d1b4e8a0e2a1a4b4c5c6d7e8f901"}
+				},
+				{
+					"number": 2,
+					"state": "open",
+					"labels": [{"name": "team-a"}],
+					"head": {"ref": "feature-b", "sha": "a1b2c3d4e5f60718293a4b5c6d7e8f9012345678"}
+				}
+			]`))
+			assert.NoError(t, err)
+		case "2":
+			_, err := w.Write([]byte(`[
+				{
+					"number": 3,
+					"state": "open",
+					"labels": [{"name": "preview"}],
+					"head": {"ref": "feature-c", "sha": "0f1e2d3c4b5a69788796a5b4c3d2e1f00f1e2d3c"}
+				}
+			]`))
+			assert.NoError(t, err)
+		default:
+			t.Fatalf("unexpected page %q", r.URL.Query().Get("page"))
+		}
+	}
+}
+
+func TestGithubList(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(githubMockHandler(t)))
+	defer ts.Close()
+
+	cases := []struct {
+		name     string
+		labels   []string
+		expected []*PullRequest
+	}{
+		{
+			name: "no label filter",
+			expected: []*PullRequest{
+				{Number: 1, Branch: "feature-a", HeadSHA: "b7ad4e2d1ac5d1b4e8a0e2a1a4b4c5c6d7e8f901", Labels: []string{"preview", "team-a"}},
+				{Number: 2, Branch: "feature-b", HeadSHA: "a1b2c3d4e5f60718293a4b5c6d7e8f9012345678", Labels: []string{"team-a"}},
+				{Number: 3, Branch: "feature-c", HeadSHA: "0f1e2d3c4b5a69788796a5b4c3d2e1f00f1e2d3c", Labels: []string{"preview"}},
+			},
+		},
+		{
+			name:   "single label filter",
+			labels: []string{"preview"},
+			expected: []*PullRequest{
+				{Number: 1, Branch: "feature-a", HeadSHA: "b7ad4e2d1ac5d1b4e8a0e2a1a4b4c5c6d7e8f901", Labels: []string{"preview", "team-a"}},
+				{Number: 3, Branch: "feature-c", HeadSHA: "0f1e2d3c4b5a69788796a5b4c3d2e1f00f1e2d3c", Labels: []string{"preview"}},
+			},
+		},
+		{
+			name:   "all labels must match",
+			labels: []string{"preview", "team-a"},
+			expected: []*PullRequest{
+				{Number: 1, Branch: "feature-a", HeadSHA: "b7ad4e2d1ac5d1b4e8a0e2a1a4b4c5c6d7e8f901", Labels: []string{"preview", "team-a"}},
+			},
+		},
+		{
+			name:     "no match",
+			labels:   []string{"other"},
+			expected: []*PullRequest{},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			svc, err := NewGithubService(context.Background(), "test-token", ts.URL, "argoproj", "applicationset", c.labels)
+			assert.NoError(t, err)
+			pullRequests, err := svc.List(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, pullRequests)
+		})
+	}
+}
+
+func TestGithubListError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	svc, err := NewGithubService(context.Background(), "", ts.URL, "argoproj", "missing", nil)
+	assert.NoError(t, err)
+	_, err = svc.List(context.Background())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "error listing pull requests for argoproj/missing")
+}
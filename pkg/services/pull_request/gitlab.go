@@ -0,0 +1,71 @@
+package pull_request
+
+import (
+	"context"
+	"fmt"
+
+	gitlab "github.com/xanzy/go-gitlab"
+)
+
+type GitlabService struct {
+	client  *gitlab.Client
+	project string
+	labels  []string
+}
+
+var _ PullRequestService = &GitlabService{}
+
+func NewGitlabService(ctx context.Context, token, url, project string, labels []string) (*GitlabService, error) {
+	var client *gitlab.Client
+	if url == "" {
+		var err error
+		client, err = gitlab.NewClient(token)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		client, err = gitlab.NewClient(token, gitlab.WithBaseURL(url))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &GitlabService{
+		client:  client,
+		project: project,
+		labels:  labels,
+	}, nil
+}
+
+func (g *GitlabService) List(ctx context.Context) ([]*PullRequest, error) {
+	opts := &gitlab.ListProjectMergeRequestsOptions{
+		ListOptions: gitlab.ListOptions{
+			PerPage: 100,
+		},
+		State: gitlab.String("opened"),
+	}
+	pullRequests := []*PullRequest{}
+	for {
+		mergeRequests, resp, err := g.client.MergeRequests.ListProjectMergeRequests(g.project, opts, gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("error listing merge requests for project %s: %v", g.project, err)
+		}
+		for _, mergeRequest := range mergeRequests {
+			labels := []string(mergeRequest.Labels)
+			if !containLabels(g.labels, labels) {
+				continue
+			}
+			pullRequests = append(pullRequests, &PullRequest{
+				Number:  mergeRequest.IID,
+				Branch:  mergeRequest.SourceBranch,
+				HeadSHA: mergeRequest.SHA,
+				Labels:  labels,
+			})
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return pullRequests, nil
+}
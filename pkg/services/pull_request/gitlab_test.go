@@ -0,0 +1,96 @@
+package pull_request
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func gitlabMockHandler(t *testing.T) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v4/projects/42/merge_requests", r.URL.Path)
+		assert.Equal(t, "opened", r.URL.Query().Get("state"))
+		assert.Equal(t, "test-token", r.Header.Get("PRIVATE-TOKEN"))
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("page") {
+		case "", "1":
+			w.Header().Set("X-Next-Page", "2")
+			_, err := w.Write([]byte(`[
+				{
+					"iid": 7,
+					"state": "opened",
+					"source_branch": "feature-a",
+					"sha": "b7ad4e2d1ac5d1b4e8a0e2a1a4b4c5c6d7e8f901",
+					"labels": ["preview", "team-a"]
+				}
+			]`))
+			assert.NoError(t, err)
+		case "2":
+			_, err := w.Write([]byte(`[
+				{
+					"iid": 8,
+					"state": "opened",
+					"source_branch": "feature-b",
+					"sha": "a1b2c3d4e5f60718293a4b5c6d7e8f9012345678",
+					"labels": ["team-a"]
+				}
+			]`))
+			assert.NoError(t, err)
+		default:
+			t.Fatalf("unexpected page %q", r.URL.Query().Get("page"))
+		}
+	}
+}
+
+func TestGitlabList(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(gitlabMockHandler(t)))
+	defer ts.Close()
+
+	cases := []struct {
+		name     string
+		labels   []string
+		expected []*PullRequest
+	}{
+		{
+			name: "no label filter",
+			expected: []*PullRequest{
+				{Number: 7, Branch: "feature-a", HeadSHA: "b7ad4e2d1ac5d1b4e8a0e2a1a4b4c5c6d7e8f901", Labels: []string{"preview", "team-a"}},
+				{Number: 8, Branch: "feature-b", HeadSHA: "a1b2c3d4e5f60718293a4b5c6d7e8f9012345678", Labels: []string{"team-a"}},
+			},
+		},
+		{
+			name:   "label filter",
+			labels: []string{"preview"},
+			expected: []*PullRequest{
+				{Number: 7, Branch: "feature-a", HeadSHA: "b7ad4e2d1ac5d1b4e8a0e2a1a4b4c5c6d7e8f901", Labels: []string{"preview", "team-a"}},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			svc, err := NewGitlabService(context.Background(), "test-token", ts.URL, "42", c.labels)
+			assert.NoError(t, err)
+			pullRequests, err := svc.List(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, pullRequests)
+		})
+	}
+}
+
+func TestGitlabListError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	svc, err := NewGitlabService(context.Background(), "", ts.URL, "42", nil)
+	assert.NoError(t, err)
+	_, err = svc.List(context.Background())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "error listing merge requests for project 42")
+}
@@ -0,0 +1,14 @@
+package pull_request
+
+import "context"
+
+type MockService struct {
+	PullRequests []*PullRequest
+	Err          error
+}
+
+var _ PullRequestService = &MockService{}
+
+func (m *MockService) List(_ context.Context) ([]*PullRequest, error) {
+	return m.PullRequests, m.Err
+}
@@ -0,0 +1,22 @@
+package pull_request
+
+import (
+	"context"
+)
+
+// An abstract pull request from an API provider.
+type PullRequest struct {
+	// Number is a number that will be the ID of the pull request.
+	Number int
+	// Branch is the name of the branch from which the pull request originated.
+	Branch string
+	// HeadSHA is the SHA of the HEAD from which the pull request originated.
+	HeadSHA string
+	// Labels are the labels attached to the pull request.
+	Labels []string
+}
+
+type PullRequestService interface {
+	// List gets a list of open pull requests, filtered by the labels configured for the service.
+	List(context.Context) ([]*PullRequest, error)
+}
@@ -0,0 +1,15 @@
+package pull_request
+
+// containLabels returns true if all the required labels are present in the given labels.
+func containLabels(required []string, labels []string) bool {
+	present := make(map[string]bool, len(labels))
+	for _, label := range labels {
+		present[label] = true
+	}
+	for _, label := range required {
+		if !present[label] {
+			return false
+		}
+	}
+	return true
+}
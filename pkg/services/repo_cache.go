@@ -0,0 +1,242 @@
+package services
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/argoproj/argo-cd/v2/util/git"
+)
+
+// maxCachedCommits is the maximum number of commits whose entries are cached, across all the repositories
+const maxCachedCommits = 64
+
+// repoCache caches the directories, file paths, file contents and last commits read from the checkouts of
+// repositories, by repository and commit SHA. As commits are immutable, the entries of a commit remain valid as long as
+// a revision resolves to it: the entries of the commits which no revision resolves to anymore are evicted, as well as
+// the least recently used ones once more than maxCachedCommits commits are cached.
+//
+// The zero value is ready to use.
+type repoCache struct {
+	lock sync.Mutex
+	// revisions are the commit SHAs the revisions of the repositories last resolved to
+	revisions map[revisionKey]string
+	// commits are the elements of recentCommits, by commit
+	commits map[commitKey]*list.Element
+	// recentCommits are the entries of the cached commits, from the most to the least recently used
+	recentCommits *list.List
+	// checkouts are the commit SHAs checked out in the working trees, by root directory
+	checkouts map[string]string
+	// workingTreeLocks serialize the use of each working tree, by root directory
+	workingTreeLocks map[string]*sync.Mutex
+}
+
+type revisionKey struct {
+	repoURL  string
+	revision string
+}
+
+type commitKey struct {
+	repoURL   string
+	commitSHA string
+}
+
+type commitEntries struct {
+	key          commitKey
+	directories  []string
+	filePaths    map[string][]string
+	fileContents map[string][]byte
+	// lastCommits are nil for the paths which no commit changed
+	lastCommits map[string]*CommitInfo
+}
+
+// setRevision records the commit SHA the revision of the repository resolves to, and evicts the entries of the
+// commit it previously resolved to, unless another revision still resolves to it.
+func (c *repoCache) setRevision(repoURL, revision, commitSHA string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	repoURL = git.NormalizeGitURL(repoURL)
+	if c.revisions == nil {
+		c.revisions = map[revisionKey]string{}
+	}
+	key := revisionKey{repoURL: repoURL, revision: revision}
+	previousSHA, found := c.revisions[key]
+	c.revisions[key] = commitSHA
+	if !found || previousSHA == commitSHA {
+		return
+	}
+
+	for other, otherSHA := range c.revisions {
+		if other.repoURL == repoURL && otherSHA == previousSHA {
+			return
+		}
+	}
+	if element, found := c.commits[commitKey{repoURL: repoURL, commitSHA: previousSHA}]; found {
+		c.removeCommit(element)
+	}
+}
+
+// lookup returns the entries of the commit of the repository, or nil if none are cached, and marks them as recently
+// used. The lock must be held.
+func (c *repoCache) lookup(repoURL, commitSHA string) *commitEntries {
+	element, found := c.commits[commitKey{repoURL: git.NormalizeGitURL(repoURL), commitSHA: commitSHA}]
+	if !found {
+		return nil
+	}
+	c.recentCommits.MoveToFront(element)
+	return element.Value.(*commitEntries)
+}
+
+// entries returns the entries of the commit of the repository, creating them if needed, in which case the least
+// recently used commit is evicted if the cache is full. The lock must be held.
+func (c *repoCache) entries(repoURL, commitSHA string) *commitEntries {
+	if entries := c.lookup(repoURL, commitSHA); entries != nil {
+		return entries
+	}
+
+	if c.commits == nil {
+		c.commits = map[commitKey]*list.Element{}
+		c.recentCommits = list.New()
+	}
+	for c.recentCommits.Len() >= maxCachedCommits {
+		c.removeCommit(c.recentCommits.Back())
+	}
+	entries := &commitEntries{
+		key:          commitKey{repoURL: git.NormalizeGitURL(repoURL), commitSHA: commitSHA},
+		filePaths:    map[string][]string{},
+		fileContents: map[string][]byte{},
+		lastCommits:  map[string]*CommitInfo{},
+	}
+	c.commits[entries.key] = c.recentCommits.PushFront(entries)
+	return entries
+}
+
+// removeCommit evicts the entries of a commit. The lock must be held.
+func (c *repoCache) removeCommit(element *list.Element) {
+	c.recentCommits.Remove(element)
+	delete(c.commits, element.Value.(*commitEntries).key)
+}
+
+func (c *repoCache) getDirectories(repoURL, commitSHA string) ([]string, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	entries := c.lookup(repoURL, commitSHA)
+	if entries == nil || entries.directories == nil {
+		return nil, false
+	}
+	return append([]string{}, entries.directories...), true
+}
+
+func (c *repoCache) setDirectories(repoURL, commitSHA string, directories []string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.entries(repoURL, commitSHA).directories = append([]string{}, directories...)
+}
+
+func (c *repoCache) getFilePaths(repoURL, commitSHA, pattern string) ([]string, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	entries := c.lookup(repoURL, commitSHA)
+	if entries == nil {
+		return nil, false
+	}
+	paths, found := entries.filePaths[pattern]
+	if !found {
+		return nil, false
+	}
+	return append([]string{}, paths...), true
+}
+
+func (c *repoCache) setFilePaths(repoURL, commitSHA, pattern string, paths []string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.entries(repoURL, commitSHA).filePaths[pattern] = append([]string{}, paths...)
+}
+
+func (c *repoCache) getFileContent(repoURL, commitSHA, path string) ([]byte, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	entries := c.lookup(repoURL, commitSHA)
+	if entries == nil {
+		return nil, false
+	}
+	content, found := entries.fileContents[path]
+	if !found {
+		return nil, false
+	}
+	return append([]byte{}, content...), true
+}
+
+func (c *repoCache) setFileContent(repoURL, commitSHA, path string, content []byte) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.entries(repoURL, commitSHA).fileContents[path] = append([]byte{}, content...)
+}
+
+// getLastCommit returns the last commit that changed the path, which is nil if no commit changed it, and whether it
+// was cached.
+func (c *repoCache) getLastCommit(repoURL, commitSHA, path string) (*CommitInfo, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	entries := c.lookup(repoURL, commitSHA)
+	if entries == nil {
+		return nil, false
+	}
+	commit, found := entries.lastCommits[path]
+	return commit, found
+}
+
+func (c *repoCache) setLastCommit(repoURL, commitSHA, path string, commit *CommitInfo) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.entries(repoURL, commitSHA).lastCommits[path] = commit
+}
+
+// getCheckout returns the commit SHA checked out in the working tree, or "" if it is unknown.
+func (c *repoCache) getCheckout(root string) string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.checkouts[root]
+}
+
+// setCheckout records the commit SHA checked out in the working tree, or forgets it if commitSHA is "".
+func (c *repoCache) setCheckout(root, commitSHA string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.checkouts == nil {
+		c.checkouts = map[string]string{}
+	}
+	if commitSHA == "" {
+		delete(c.checkouts, root)
+		return
+	}
+	c.checkouts[root] = commitSHA
+}
+
+// lockWorkingTree acquires the lock of the working tree, so that it is not checked out at another commit while it is
+// read, and returns the function releasing it.
+func (c *repoCache) lockWorkingTree(root string) func() {
+	c.lock.Lock()
+	if c.workingTreeLocks == nil {
+		c.workingTreeLocks = map[string]*sync.Mutex{}
+	}
+	workingTreeLock, found := c.workingTreeLocks[root]
+	if !found {
+		workingTreeLock = &sync.Mutex{}
+		c.workingTreeLocks[root] = workingTreeLock
+	}
+	c.lock.Unlock()
+
+	workingTreeLock.Lock()
+	return workingTreeLock.Unlock
+}
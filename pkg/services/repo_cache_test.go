@@ -0,0 +1,108 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepoCacheEviction(t *testing.T) {
+	var cache repoCache
+	repoURL := "https://github.com/argoproj/argocd-example-apps.git"
+
+	cache.setRevision(repoURL, "HEAD", "sha-1")
+	cache.setRevision(repoURL, "master", "sha-1")
+	cache.setDirectories(repoURL, "sha-1", []string{"guestbook"})
+	cache.setFilePaths(repoURL, "sha-1", "*.yaml", []string{"apps/values.yaml"})
+	cache.setFileContent(repoURL, "sha-1", "apps/values.yaml", []byte("key: value"))
+
+	// The entries are shared by the equivalent URLs of the repository
+	directories, found := cache.getDirectories("https://github.com/argoproj/argocd-example-apps", "sha-1")
+	assert.True(t, found)
+	assert.Equal(t, []string{"guestbook"}, directories)
+
+	// master still resolves to sha-1, so its entries are kept
+	cache.setRevision(repoURL, "HEAD", "sha-2")
+	paths, found := cache.getFilePaths(repoURL, "sha-1", "*.yaml")
+	assert.True(t, found)
+	assert.Equal(t, []string{"apps/values.yaml"}, paths)
+	_, found = cache.getFilePaths(repoURL, "sha-1", "*.json")
+	assert.False(t, found)
+	_, found = cache.getDirectories(repoURL, "sha-2")
+	assert.False(t, found)
+
+	// No revision resolves to sha-1 anymore
+	cache.setRevision(repoURL, "master", "sha-2")
+	_, found = cache.getFileContent(repoURL, "sha-1", "apps/values.yaml")
+	assert.False(t, found)
+	_, found = cache.getDirectories(repoURL, "sha-1")
+	assert.False(t, found)
+}
+
+func TestRepoCacheLeastRecentlyUsedEviction(t *testing.T) {
+	var cache repoCache
+	repoURL := "https://github.com/argoproj/argocd-example-apps.git"
+
+	// Lookups of commits which are not cached do not add entries
+	for i := 0; i < 2*maxCachedCommits; i++ {
+		_, found := cache.getDirectories(repoURL, fmt.Sprintf("missing-%d", i))
+		assert.False(t, found)
+	}
+	assert.Len(t, cache.commits, 0)
+
+	for i := 0; i < maxCachedCommits; i++ {
+		cache.setDirectories(repoURL, fmt.Sprintf("sha-%d", i), []string{"guestbook"})
+	}
+	// sha-0 is used again, so sha-1 is the least recently used commit
+	_, found := cache.getDirectories(repoURL, "sha-0")
+	assert.True(t, found)
+
+	cache.setDirectories(repoURL, "sha-new", []string{"guestbook"})
+	assert.Len(t, cache.commits, maxCachedCommits)
+	_, found = cache.getDirectories(repoURL, "sha-0")
+	assert.True(t, found)
+	_, found = cache.getDirectories(repoURL, "sha-1")
+	assert.False(t, found)
+	_, found = cache.getDirectories(repoURL, "sha-new")
+	assert.True(t, found)
+}
+
+func TestRepoCacheReturnsCopies(t *testing.T) {
+	var cache repoCache
+	repoURL := "https://github.com/argoproj/argocd-example-apps.git"
+	cache.setRevision(repoURL, "HEAD", "sha-1")
+
+	directories := []string{"b", "a"}
+	cache.setDirectories(repoURL, "sha-1", directories)
+	directories[0] = "c"
+
+	got, found := cache.getDirectories(repoURL, "sha-1")
+	assert.True(t, found)
+	assert.Equal(t, []string{"b", "a"}, got)
+	got[0] = "d"
+
+	got, _ = cache.getDirectories(repoURL, "sha-1")
+	assert.Equal(t, []string{"b", "a"}, got)
+
+	// An empty list of directories is cached as well
+	cache.setDirectories(repoURL, "sha-2", []string{})
+	got, found = cache.getDirectories(repoURL, "sha-2")
+	assert.True(t, found)
+	assert.Equal(t, []string{}, got)
+}
+
+func TestRepoCacheCheckouts(t *testing.T) {
+	var cache repoCache
+
+	assert.Equal(t, "", cache.getCheckout("/tmp/repo"))
+	cache.setCheckout("/tmp/repo", "sha-1")
+	assert.Equal(t, "sha-1", cache.getCheckout("/tmp/repo"))
+	cache.setCheckout("/tmp/repo", "")
+	assert.Equal(t, "", cache.getCheckout("/tmp/repo"))
+
+	unlock := cache.lockWorkingTree("/tmp/repo")
+	unlockOther := cache.lockWorkingTree("/tmp/other-repo")
+	unlockOther()
+	unlock()
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+
+	"github.com/argoproj/argo-cd/v2/util/db"
+)
+
+// repoServerService reads repositories with --use-argocd-repo-server. The repo server of the supported Argo CD version
+// only lists the directories it detects as applications, i.e. those containing a Helm chart, a Kustomization or a
+// Ksonnet application, and serves neither the files nor the history of repositories. Answering from it would change
+// the parameters of the Git generators, and delete the Applications of the directories it does not list, so the
+// reads it cannot answer with the same results fall back to local checkouts: with this version, all of them.
+type repoServerService struct {
+	// local reads what the repo server cannot answer
+	local Repos
+}
+
+// NewRepoServerService returns a Repos backed by the Argo CD repo server, falling back to local checkouts for the
+// calls the repo server cannot answer with the same results.
+func NewRepoServerService(db db.ArgoDB) Repos {
+	return &repoServerService{
+		local: NewArgoCDService(db, ""),
+	}
+}
+
+func (r *repoServerService) GetFilePaths(ctx context.Context, repoURL string, revision string, pattern string) ([]string, error) {
+	return r.local.GetFilePaths(ctx, repoURL, revision, pattern)
+}
+
+func (r *repoServerService) GetFileContent(ctx context.Context, repoURL string, revision string, path string) ([]byte, error) {
+	return r.local.GetFileContent(ctx, repoURL, revision, path)
+}
+
+func (r *repoServerService) GetRevisionInfo(ctx context.Context, repoURL string, revision string, paths []string) (*RevisionInfo, error) {
+	return r.local.GetRevisionInfo(ctx, repoURL, revision, paths)
+}
+
+// GetDirectories lists all the directories of the repository, including those of plain manifests and the intermediate
+// ones, which the ListApps call of the repo server omits.
+func (r *repoServerService) GetDirectories(ctx context.Context, repoURL string, revision string) ([]string, error) {
+	return r.local.GetDirectories(ctx, repoURL, revision)
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+	"github.com/argoproj/argo-cd/v2/util/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRepoServerMatchesLocalCheckouts(t *testing.T) {
+	repoURL := "https://github.com/argoproj/argocd-example-apps/"
+
+	// Plain manifests, a Helm chart and a Kustomization, which is the only kind of directory ListApps reports
+	gitClient := mockGitClient(t, "08f72e2a309beab929d9fd14626071b1a61a47f9", map[string]string{
+		"guestbook/guestbook-ui-deployment.yaml":         "kind: Deployment",
+		"helm-guestbook/Chart.yaml":                      "name: helm-guestbook",
+		"helm-guestbook/templates/deployment.yaml":       "kind: Deployment",
+		"apps/kustomize-guestbook/kustomization.yaml":    "resources: []",
+		"cluster-config/engineering/dev/config.json":     `{"cluster":"dev"}`,
+		"cluster-config/engineering/staging/config.json": `{"cluster":"staging"}`,
+	})
+	gitClient.On("LsFiles", "cluster-config/**/config.json").
+		Return([]string{"cluster-config/engineering/dev/config.json", "cluster-config/engineering/staging/config.json"}, nil)
+
+	argocdRepositoryMock := ArgocdRepositoryMock{mock: &mock.Mock{}}
+	argocdRepositoryMock.mock.On("GetRepository", mock.Anything, repoURL).Return(&v1alpha1.Repository{Repo: repoURL}, nil)
+
+	local := &argoCDService{
+		repositoriesDB: argocdRepositoryMock,
+		newGitClient: func(repo *v1alpha1.Repository) (git.Client, error) {
+			return gitClient, nil
+		},
+	}
+	repoServer := &repoServerService{local: local}
+
+	for name, repos := range map[string]Repos{"local": local, "repo server": repoServer} {
+		directories, err := repos.GetDirectories(context.Background(), repoURL, "HEAD")
+		assert.NoError(t, err, name)
+		assert.Equal(t, []string{"apps", "apps/kustomize-guestbook", "cluster-config", "cluster-config/engineering",
+			"cluster-config/engineering/dev", "cluster-config/engineering/staging", "guestbook", "helm-guestbook",
+			"helm-guestbook/templates"}, directories, name)
+
+		paths, err := repos.GetFilePaths(context.Background(), repoURL, "HEAD", "cluster-config/**/config.json")
+		assert.NoError(t, err, name)
+		assert.Equal(t, []string{"cluster-config/engineering/dev/config.json", "cluster-config/engineering/staging/config.json"}, paths, name)
+
+		content, err := repos.GetFileContent(context.Background(), repoURL, "HEAD", "cluster-config/engineering/dev/config.json")
+		assert.NoError(t, err, name)
+		assert.Equal(t, `{"cluster":"dev"}`, string(content), name)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
 	"github.com/argoproj/argo-cd/v2/util/db"
@@ -20,6 +22,11 @@ type RepositoryDB interface {
 
 type argoCDService struct {
 	repositoriesDB RepositoryDB
+	// cache holds what was read from the checkouts of the repositories, so that the revisions which still resolve
+	// to the same commit are not fetched and checked out again
+	cache repoCache
+	// newGitClient returns a client of the repository, overridden in the tests
+	newGitClient func(repo *v1alpha1.Repository) (git.Client, error)
 }
 
 type Repos interface {
@@ -32,28 +39,84 @@ type Repos interface {
 
 	// GetFileContent returns the contents of a particular repository file
 	GetFileContent(ctx context.Context, repoURL string, revision string, path string) ([]byte, error)
+
+	// GetRevisionInfo returns the commit the revision resolves to, along with the last commit that changed each of the
+	// paths. It returns nil if the repository has no commit history, e.g. a local directory which is not a Git checkout.
+	GetRevisionInfo(ctx context.Context, repoURL string, revision string, paths []string) (*RevisionInfo, error)
+}
+
+// CommitInfo describes a commit of a repository.
+type CommitInfo struct {
+	SHA string
+	// Author is the name and email of the author, as 'name <email>'
+	Author  string
+	Date    time.Time
+	Message string
+}
+
+// RevisionInfo describes the commit a revision resolves to, and the last commits that changed paths of the repository
+// up to it.
+type RevisionInfo struct {
+	SHA string
+	// LastCommits are the last commits that changed the requested paths, by path. A path which no commit changed, e.g.
+	// because it does not exist, is absent.
+	LastCommits map[string]CommitInfo
 }
 
 func NewArgoCDService(db db.ArgoDB, repoServerAddress string) Repos {
 
 	return &argoCDService{
 		repositoriesDB: db.(RepositoryDB),
+		newGitClient:   newGitClient,
 	}
 }
 
-func (a *argoCDService) GetFilePaths(ctx context.Context, repoURL string, revision string, pattern string) ([]string, error) {
-	repo, err := a.repositoriesDB.GetRepository(ctx, repoURL)
-	if err != nil {
-		return nil, errors.Wrap(err, "Error in GetRepository")
-	}
+func newGitClient(repo *v1alpha1.Repository) (git.Client, error) {
+	return git.NewClient(repo.Repo, repo.GetGitCreds(), repo.IsInsecure(), repo.IsLFSEnabled())
+}
+
+// resolvedRevisionsKey is the key of the resolvedRevisions of a context
+type resolvedRevisionsKey struct{}
+
+// resolvedRevisions are the commit SHAs the revisions of the repositories resolved to during a generation pass
+type resolvedRevisions struct {
+	lock sync.Mutex
+	shas map[revisionKey]string
+}
+
+// WithResolvedRevisions returns a context in which each revision of a repository is resolved to a commit SHA at most
+// once, e.g. for a generation pass: the remote refs of the repository are not listed again for each of its reads,
+// which all read the same commit.
+func WithResolvedRevisions(ctx context.Context) context.Context {
+	return context.WithValue(ctx, resolvedRevisionsKey{}, &resolvedRevisions{shas: map[revisionKey]string{}})
+}
 
-	gitRepoClient, err := git.NewClient(repo.Repo, repo.GetGitCreds(), repo.IsInsecure(), repo.IsLFSEnabled())
+func (r *resolvedRevisions) get(repoURL, revision string) (string, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	sha, found := r.shas[revisionKey{repoURL: git.NormalizeGitURL(repoURL), revision: revision}]
+	return sha, found
+}
+
+func (r *resolvedRevisions) set(repoURL, revision, commitSHA string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.shas[revisionKey{repoURL: git.NormalizeGitURL(repoURL), revision: revision}] = commitSHA
+}
 
+func (a *argoCDService) GetFilePaths(ctx context.Context, repoURL string, revision string, pattern string) ([]string, error) {
+	gitRepoClient, commitSHA, err := a.resolveRevision(ctx, repoURL, revision)
 	if err != nil {
 		return nil, err
 	}
+	if paths, found := a.cache.getFilePaths(repoURL, commitSHA, pattern); found {
+		return paths, nil
+	}
+
+	unlock := a.cache.lockWorkingTree(gitRepoClient.Root())
+	defer unlock()
 
-	err = checkoutRepo(gitRepoClient, revision)
+	err = a.checkout(gitRepoClient, revision, commitSHA)
 	if err != nil {
 		return nil, err
 	}
@@ -63,29 +126,162 @@ func (a *argoCDService) GetFilePaths(ctx context.Context, repoURL string, revisi
 		return nil, errors.Wrap(err, "Error during listing files of local repo")
 	}
 
+	a.cache.setFilePaths(repoURL, commitSHA, pattern, paths)
 	return paths, nil
 }
 
 func (a *argoCDService) GetDirectories(ctx context.Context, repoURL string, revision string) ([]string, error) {
+	gitRepoClient, commitSHA, err := a.resolveRevision(ctx, repoURL, revision)
+	if err != nil {
+		return nil, err
+	}
+	if directories, found := a.cache.getDirectories(repoURL, commitSHA); found {
+		return directories, nil
+	}
 
-	repo, err := a.repositoriesDB.GetRepository(ctx, repoURL)
+	unlock := a.cache.lockWorkingTree(gitRepoClient.Root())
+	defer unlock()
+
+	err = a.checkout(gitRepoClient, revision, commitSHA)
 	if err != nil {
-		return nil, errors.Wrap(err, "Error in GetRepository")
+		return nil, err
 	}
 
-	gitRepoClient, err := git.NewClient(repo.Repo, repo.GetGitCreds(), repo.IsInsecure(), repo.IsLFSEnabled())
+	directories, err := listDirectories(gitRepoClient.Root())
 	if err != nil {
 		return nil, err
 	}
 
-	err = checkoutRepo(gitRepoClient, revision)
+	a.cache.setDirectories(repoURL, commitSHA, directories)
+	return directories, nil
+}
+
+func (a *argoCDService) GetFileContent(ctx context.Context, repoURL string, revision string, path string) ([]byte, error) {
+	gitRepoClient, commitSHA, err := a.resolveRevision(ctx, repoURL, revision)
 	if err != nil {
 		return nil, err
 	}
+	if content, found := a.cache.getFileContent(repoURL, commitSHA, path); found {
+		return content, nil
+	}
 
-	filteredPaths := []string{}
+	unlock := a.cache.lockWorkingTree(gitRepoClient.Root())
+	defer unlock()
 
-	repoRoot := gitRepoClient.Root()
+	err = a.checkout(gitRepoClient, revision, commitSHA)
+	if err != nil {
+		return nil, err
+	}
+
+	bytes, err := os.ReadFile(filepath.Join(gitRepoClient.Root(), path))
+	if err != nil {
+		return nil, err
+	}
+
+	a.cache.setFileContent(repoURL, commitSHA, path, bytes)
+	return bytes, nil
+}
+
+func (a *argoCDService) GetRevisionInfo(ctx context.Context, repoURL string, revision string, paths []string) (*RevisionInfo, error) {
+	gitRepoClient, commitSHA, err := a.resolveRevision(ctx, repoURL, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &RevisionInfo{
+		SHA:         commitSHA,
+		LastCommits: map[string]CommitInfo{},
+	}
+	uncachedPaths := []string{}
+	for _, path := range paths {
+		commit, found := a.cache.getLastCommit(repoURL, commitSHA, path)
+		if !found {
+			uncachedPaths = append(uncachedPaths, path)
+		} else if commit != nil {
+			info.LastCommits[path] = *commit
+		}
+	}
+	if len(uncachedPaths) == 0 {
+		return info, nil
+	}
+
+	unlock := a.cache.lockWorkingTree(gitRepoClient.Root())
+	defer unlock()
+
+	err = a.checkout(gitRepoClient, revision, commitSHA)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range uncachedPaths {
+		commit, err := lastCommit(ctx, gitRepoClient.Root(), commitSHA, path)
+		if err != nil {
+			return nil, err
+		}
+		a.cache.setLastCommit(repoURL, commitSHA, path, commit)
+		if commit != nil {
+			info.LastCommits[path] = *commit
+		}
+	}
+	return info, nil
+}
+
+// resolveRevision returns a client of the repository, and the commit SHA the revision currently resolves to. Only the
+// remote refs are listed: nothing is fetched. Within a context returned by WithResolvedRevisions, the revision is only
+// resolved once.
+func (a *argoCDService) resolveRevision(ctx context.Context, repoURL string, revision string) (git.Client, string, error) {
+	repo, err := a.repositoriesDB.GetRepository(ctx, repoURL)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "Error in GetRepository")
+	}
+
+	gitRepoClient, err := a.newGitClient(repo)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resolved, _ := ctx.Value(resolvedRevisionsKey{}).(*resolvedRevisions)
+	if resolved != nil {
+		if commitSHA, found := resolved.get(repoURL, revision); found {
+			return gitRepoClient, commitSHA, nil
+		}
+	}
+
+	commitSHA, err := gitRepoClient.LsRemote(revision)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "Error during fetching commitSHA")
+	}
+
+	if resolved != nil {
+		resolved.set(repoURL, revision, commitSHA)
+	}
+	a.cache.setRevision(repoURL, revision, commitSHA)
+	return gitRepoClient, commitSHA, nil
+}
+
+// checkout checks out the commit in the working tree of the repository, unless it is already checked out. The lock of
+// the working tree must be held.
+func (a *argoCDService) checkout(gitRepoClient git.Client, revision string, commitSHA string) error {
+	root := gitRepoClient.Root()
+	if a.cache.getCheckout(root) == commitSHA {
+		// The working tree may have been removed from the temp directory since it was checked out
+		if _, err := os.Stat(filepath.Join(root, ".git")); err == nil {
+			return nil
+		}
+	}
+
+	// Forget the commit checked out in the working tree until the checkout succeeds, as it may be left half-done
+	a.cache.setCheckout(root, "")
+	if err := checkoutRepo(gitRepoClient, revision, commitSHA); err != nil {
+		return err
+	}
+	a.cache.setCheckout(root, commitSHA)
+	return nil
+}
+
+// listDirectories returns the directories within repoRoot, relative to it, skipping those whose name starts with "."
+func listDirectories(repoRoot string) ([]string, error) {
+	filteredPaths := []string{}
 
 	if err := filepath.Walk(repoRoot, func(path string, info os.FileInfo, fnErr error) error {
 		if fnErr != nil {
@@ -117,35 +313,9 @@ func (a *argoCDService) GetDirectories(ctx context.Context, repoURL string, revi
 	}
 
 	return filteredPaths, nil
-
 }
 
-func (a *argoCDService) GetFileContent(ctx context.Context, repoURL string, revision string, path string) ([]byte, error) {
-	repo, err := a.repositoriesDB.GetRepository(ctx, repoURL)
-	if err != nil {
-		return nil, errors.Wrap(err, "Error in GetRepository")
-	}
-
-	gitRepoClient, err := git.NewClient(repo.Repo, repo.GetGitCreds(), repo.IsInsecure(), repo.IsLFSEnabled())
-
-	if err != nil {
-		return nil, err
-	}
-
-	err = checkoutRepo(gitRepoClient, revision)
-	if err != nil {
-		return nil, err
-	}
-
-	bytes, err := os.ReadFile(filepath.Join(gitRepoClient.Root(), path))
-	if err != nil {
-		return nil, err
-	}
-
-	return bytes, nil
-}
-
-func checkoutRepo(gitRepoClient git.Client, revision string) error {
+func checkoutRepo(gitRepoClient git.Client, revision string, commitSHA string) error {
 	err := gitRepoClient.Init()
 	if err != nil {
 		return errors.Wrap(err, "Error during initializing repo")
@@ -156,10 +326,6 @@ func checkoutRepo(gitRepoClient git.Client, revision string) error {
 		return errors.Wrap(err, "Error during fetching repo")
 	}
 
-	commitSHA, err := gitRepoClient.LsRemote(revision)
-	if err != nil {
-		return errors.Wrap(err, "Error during fetching commitSHA")
-	}
 	err = gitRepoClient.Checkout(commitSHA)
 	if err != nil {
 		return errors.Wrap(err, "Error during repo checkout")
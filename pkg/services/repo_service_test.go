@@ -10,6 +10,8 @@ import (
 	"testing"
 
 	"github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+	"github.com/argoproj/argo-cd/v2/util/git"
+	gitmocks "github.com/argoproj/argo-cd/v2/util/git/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -87,6 +89,7 @@ func TestGetDirectories(t *testing.T) {
 
 			argocd := argoCDService{
 				repositoriesDB: argocdRepositoryMock,
+				newGitClient:   newGitClient,
 			}
 
 			got, err := argocd.GetDirectories(context.TODO(), cc.repoURL, cc.revision)
@@ -165,7 +168,7 @@ func TestGetPaths(t *testing.T) {
 			revision:            "this-tag-does-not-exist",
 			pattern:             "*",
 			expectSubsetOfPaths: []string{},
-			expectedError:       errors.New("Error during fetching repo: `git fetch origin this-tag-does-not-exist --tags --force` failed exit status 128: fatal: couldn't find remote ref this-tag-does-not-exist"),
+			expectedError:       errors.New("Error during fetching commitSHA: Unable to resolve 'this-tag-does-not-exist' to a commit SHA"),
 		},
 		{
 			name: "pull a specific revision of example apps, and use a ** pattern",
@@ -212,6 +215,7 @@ func TestGetPaths(t *testing.T) {
 
 			argocd := argoCDService{
 				repositoriesDB: argocdRepositoryMock,
+				newGitClient:   newGitClient,
 			}
 
 			getPathsRes, err := argocd.GetFilePaths(context.Background(), cc.repoURL, cc.revision, cc.pattern)
@@ -287,7 +291,7 @@ func TestGetFileContent(t *testing.T) {
 			repoURL:       "https://github.com/argoproj/argocd-example-apps/",
 			revision:      "this-tag-does-not-exist",
 			path:          "/README.md",
-			expectedError: errors.New("Error during fetching repo: `git fetch origin this-tag-does-not-exist --tags --force` failed exit status 128: fatal: couldn't find remote ref this-tag-does-not-exist"),
+			expectedError: errors.New("Error during fetching commitSHA: Unable to resolve 'this-tag-does-not-exist' to a commit SHA"),
 		},
 		{
 			name: "pull an invalid file",
@@ -312,6 +316,7 @@ func TestGetFileContent(t *testing.T) {
 
 			argocd := argoCDService{
 				repositoriesDB: argocdRepositoryMock,
+				newGitClient:   newGitClient,
 			}
 
 			argocdRepositoryMock.mock.On("GetRepository", mock.Anything, cc.repoURL).Return(cc.repoRes, cc.repoErr)
@@ -332,3 +337,64 @@ func TestGetFileContent(t *testing.T) {
 	}
 
 }
+
+// mockGitClient returns a client of a repository whose revision HEAD resolves to the commit, checked out in a temp
+// directory with the files, by path.
+func mockGitClient(t *testing.T, commitSHA string, files map[string]string) *gitmocks.Client {
+	root := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, ".git"), 0755))
+	for path, content := range files {
+		assert.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, path)), 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(root, path), []byte(content), 0644))
+	}
+
+	gitClient := &gitmocks.Client{}
+	gitClient.On("Root").Return(root)
+	gitClient.On("LsRemote", "HEAD").Return(commitSHA, nil)
+	gitClient.On("Init").Return(nil)
+	gitClient.On("Fetch", "HEAD").Return(nil)
+	gitClient.On("Checkout", commitSHA).Return(nil)
+	return gitClient
+}
+
+func TestResolvedRevisions(t *testing.T) {
+	repoURL := "https://github.com/argoproj/argocd-example-apps/"
+	commitSHA := "08f72e2a309beab929d9fd14626071b1a61a47f9"
+
+	gitClient := mockGitClient(t, commitSHA, map[string]string{"cluster-config/staging/config.json": `{"cluster":"staging"}`})
+	gitClient.On("LsFiles", "cluster-config/*/config.json").Return([]string{"cluster-config/staging/config.json"}, nil)
+
+	argocdRepositoryMock := ArgocdRepositoryMock{mock: &mock.Mock{}}
+	argocdRepositoryMock.mock.On("GetRepository", mock.Anything, repoURL).Return(&v1alpha1.Repository{Repo: repoURL}, nil)
+
+	argocd := argoCDService{
+		repositoriesDB: argocdRepositoryMock,
+		newGitClient: func(repo *v1alpha1.Repository) (git.Client, error) {
+			return gitClient, nil
+		},
+	}
+
+	// The reads of a generation pass resolve the revision once
+	ctx := WithResolvedRevisions(context.Background())
+	directories, err := argocd.GetDirectories(ctx, repoURL, "HEAD")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cluster-config", "cluster-config/staging"}, directories)
+	paths, err := argocd.GetFilePaths(ctx, repoURL, "HEAD", "cluster-config/*/config.json")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cluster-config/staging/config.json"}, paths)
+	content, err := argocd.GetFileContent(ctx, repoURL, "HEAD", "cluster-config/staging/config.json")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"cluster":"staging"}`, string(content))
+	gitClient.AssertNumberOfCalls(t, "LsRemote", 1)
+
+	// Another generation pass resolves it again
+	_, err = argocd.GetDirectories(WithResolvedRevisions(context.Background()), repoURL, "HEAD")
+	assert.NoError(t, err)
+	gitClient.AssertNumberOfCalls(t, "LsRemote", 2)
+
+	// Without a generation pass, each read resolves it
+	_, err = argocd.GetDirectories(context.Background(), repoURL, "HEAD")
+	assert.NoError(t, err)
+	gitClient.AssertNumberOfCalls(t, "LsRemote", 3)
+	gitClient.AssertNumberOfCalls(t, "Checkout", 1)
+}
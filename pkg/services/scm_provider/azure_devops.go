@@ -0,0 +1,215 @@
+package scm_provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// AzureDevOpsDefaultURL is the URL of the Azure DevOps Services, used unless an Azure DevOps Server URL is set.
+	AzureDevOpsDefaultURL = "https://dev.azure.com"
+
+	azureDevOpsAPIVersion = "6.0"
+	// azureDevOpsContinuationTokenHeader is the header of the responses of paged APIs which have further pages.
+	azureDevOpsContinuationTokenHeader = "x-ms-continuationtoken"
+)
+
+// AzureDevOpsProvider lists the Git repositories of an Azure DevOps team project, using its REST API.
+type AzureDevOpsProvider struct {
+	client       *http.Client
+	baseURL      string
+	organization string
+	teamProject  string
+	accessToken  string
+	allBranches  bool
+}
+
+var _ SCMProviderService = &AzureDevOpsProvider{}
+
+type azureDevOpsRepository struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	RemoteURL     string `json:"remoteUrl"`
+	SSHURL        string `json:"sshUrl"`
+	DefaultBranch string `json:"defaultBranch"`
+	IsDisabled    bool   `json:"isDisabled"`
+}
+
+type azureDevOpsRef struct {
+	Name string `json:"name"`
+}
+
+type azureDevOpsError struct {
+	Message string `json:"message"`
+}
+
+func NewAzureDevOpsProvider(ctx context.Context, accessToken, organization, url, teamProject string, allBranches bool) (*AzureDevOpsProvider, error) {
+	if accessToken == "" {
+		return nil, fmt.Errorf("no Azure DevOps access token provided")
+	}
+	if organization == "" || teamProject == "" {
+		return nil, fmt.Errorf("the Azure DevOps organization and team project are required")
+	}
+	if url == "" {
+		url = AzureDevOpsDefaultURL
+	}
+
+	return &AzureDevOpsProvider{
+		client:       http.DefaultClient,
+		baseURL:      strings.TrimSuffix(url, "/"),
+		organization: organization,
+		teamProject:  teamProject,
+		accessToken:  accessToken,
+		allBranches:  allBranches,
+	}, nil
+}
+
+func (a *AzureDevOpsProvider) ListRepos(ctx context.Context, cloneProtocol string) ([]*Repository, error) {
+	azureRepos := struct {
+		Value []azureDevOpsRepository `json:"value"`
+	}{}
+	if _, _, err := a.get(ctx, a.gitPath("repositories"), nil, &azureRepos); err != nil {
+		return nil, fmt.Errorf("error listing repositories for %s/%s: %v", a.organization, a.teamProject, err)
+	}
+
+	repos := []*Repository{}
+	for _, azureRepo := range azureRepos.Value {
+		var url string
+		switch cloneProtocol {
+		// Default to HTTPS if unspecified (i.e. if ""), as the access token is only usable over HTTPS.
+		case "", "https":
+			url = azureRepo.RemoteURL
+		case "ssh":
+			url = azureRepo.SSHURL
+		default:
+			return nil, fmt.Errorf("unknown clone protocol for Azure DevOps %v", cloneProtocol)
+		}
+
+		// Disabled repositories cannot be read, and empty repositories have no default branch
+		if azureRepo.IsDisabled || azureRepo.DefaultBranch == "" {
+			continue
+		}
+
+		branches, err := a.listBranches(ctx, azureRepo)
+		if err != nil {
+			return nil, fmt.Errorf("error listing branches for %s/%s: %v", a.teamProject, azureRepo.Name, err)
+		}
+
+		for _, branch := range branches {
+			repos = append(repos, &Repository{
+				Organization: a.organization,
+				Repository:   azureRepo.Name,
+				URL:          url,
+				Branch:       branch,
+				// Azure DevOps has no repository labels
+				Labels: []string{},
+			})
+		}
+	}
+	return repos, nil
+}
+
+func (a *AzureDevOpsProvider) RepoHasPath(ctx context.Context, repo *Repository, path string) (bool, error) {
+	query := url.Values{}
+	query.Set("path", path)
+	query.Set("versionDescriptor.version", repo.Branch)
+	query.Set("versionDescriptor.versionType", "branch")
+
+	// The API accepts the name of a repository in place of its id
+	status, _, err := a.get(ctx, a.gitPath("repositories", repo.Repository, "items"), query, nil)
+	if status == http.StatusNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (a *AzureDevOpsProvider) listBranches(ctx context.Context, repo azureDevOpsRepository) ([]string, error) {
+	// If we don't specifically want to query for all branches, just use the default branch and call it a day.
+	if !a.allBranches {
+		return []string{strings.TrimPrefix(repo.DefaultBranch, "refs/heads/")}, nil
+	}
+
+	// Otherwise, scrape the refs API.
+	query := url.Values{}
+	query.Set("filter", "heads/")
+
+	branches := []string{}
+	for {
+		refs := struct {
+			Value []azureDevOpsRef `json:"value"`
+		}{}
+		_, continuationToken, err := a.get(ctx, a.gitPath("repositories", repo.ID, "refs"), query, &refs)
+		if err != nil {
+			return nil, err
+		}
+		for _, ref := range refs.Value {
+			branches = append(branches, strings.TrimPrefix(ref.Name, "refs/heads/"))
+		}
+
+		if continuationToken == "" {
+			break
+		}
+		query.Set("continuationToken", continuationToken)
+	}
+	return branches, nil
+}
+
+// gitPath returns the path of the Git REST API of the team project, followed by the escaped elements.
+func (a *AzureDevOpsProvider) gitPath(elements ...string) string {
+	path := "/" + url.PathEscape(a.organization) + "/" + url.PathEscape(a.teamProject) + "/_apis/git"
+	for _, element := range elements {
+		path += "/" + url.PathEscape(element)
+	}
+	return path
+}
+
+// get calls the REST API, and decodes the response into out unless it is nil. The status code and the continuation
+// token of the response are returned along with the error, if any.
+func (a *AzureDevOpsProvider) get(ctx context.Context, path string, query url.Values, out interface{}) (int, string, error) {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("api-version", azureDevOpsAPIVersion)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.baseURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Accept", "application/json")
+	// Personal access tokens are sent as the password of Basic auth, with an empty username
+	req.SetBasicAuth("", a.accessToken)
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, "", err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		azureErr := azureDevOpsError{}
+		if json.Unmarshal(body, &azureErr) == nil && azureErr.Message != "" {
+			return resp.StatusCode, "", fmt.Errorf("GET %s: %s: %s", path, resp.Status, azureErr.Message)
+		}
+		return resp.StatusCode, "", fmt.Errorf("GET %s: %s", path, resp.Status)
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(body, out); err != nil {
+			return resp.StatusCode, "", fmt.Errorf("error decoding the response of GET %s: %v", path, err)
+		}
+	}
+	return resp.StatusCode, resp.Header.Get(azureDevOpsContinuationTokenHeader), nil
+}
@@ -0,0 +1,184 @@
+package scm_provider
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newAzureDevOpsMock returns a fake of the Azure DevOps REST API, serving the my-project team project of the
+// my-org organization.
+func newAzureDevOpsMock(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+
+	handle := func(path string, handler func(w http.ResponseWriter, r *http.Request)) {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "6.0", r.URL.Query().Get("api-version"))
+			if username, password, ok := r.BasicAuth(); !ok || username != "" || password != "my-pat" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			handler(w, r)
+		})
+	}
+
+	handle("/my-org/my-project/_apis/git/repositories", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"count": 4, "value": [
+			{
+				"id": "1c9ea0b4-0fa4-4d0b-8b41-0a6d3e8a2a66",
+				"name": "guestbook",
+				"remoteUrl": "https://my-org@dev.azure.com/my-org/my-project/_git/guestbook",
+				"sshUrl": "git@ssh.dev.azure.com:v3/my-org/my-project/guestbook",
+				"defaultBranch": "refs/heads/main"
+			},
+			{
+				"id": "7bb6fd6c-4d5a-4f3e-9c06-95e7a4b1c1f2",
+				"name": "helm-guestbook",
+				"remoteUrl": "https://my-org@dev.azure.com/my-org/my-project/_git/helm-guestbook",
+				"sshUrl": "git@ssh.dev.azure.com:v3/my-org/my-project/helm-guestbook",
+				"defaultBranch": "refs/heads/master"
+			},
+			{
+				"id": "cf1b1b5e-52b5-4c7e-8f0f-06f53c1e1b2b",
+				"name": "empty",
+				"remoteUrl": "https://my-org@dev.azure.com/my-org/my-project/_git/empty",
+				"sshUrl": "git@ssh.dev.azure.com:v3/my-org/my-project/empty"
+			},
+			{
+				"id": "0f4c1c8e-6a7b-4b8e-9f7c-3c2f9a1d2e3f",
+				"name": "disabled",
+				"remoteUrl": "https://my-org@dev.azure.com/my-org/my-project/_git/disabled",
+				"sshUrl": "git@ssh.dev.azure.com:v3/my-org/my-project/disabled",
+				"defaultBranch": "refs/heads/main",
+				"isDisabled": true
+			}
+		]}`)
+	})
+	handle("/my-org/my-project/_apis/git/repositories/1c9ea0b4-0fa4-4d0b-8b41-0a6d3e8a2a66/refs", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "heads/", r.URL.Query().Get("filter"))
+		switch r.URL.Query().Get("continuationToken") {
+		case "":
+			w.Header().Set("x-ms-continuationtoken", "next-page")
+			_, _ = io.WriteString(w, `{"count": 1, "value": [{"name": "refs/heads/main"}]}`)
+		case "next-page":
+			_, _ = io.WriteString(w, `{"count": 1, "value": [{"name": "refs/heads/feature/foo"}]}`)
+		default:
+			t.Errorf("unexpected continuation token %q", r.URL.Query().Get("continuationToken"))
+		}
+	})
+	handle("/my-org/my-project/_apis/git/repositories/7bb6fd6c-4d5a-4f3e-9c06-95e7a4b1c1f2/refs", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"count": 1, "value": [{"name": "refs/heads/master"}]}`)
+	})
+	handle("/my-org/my-project/_apis/git/repositories/guestbook/items", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("versionDescriptor.version") == "main" && query.Get("versionDescriptor.versionType") == "branch" &&
+			(query.Get("path") == "kustomize" || query.Get("path") == "kustomize/kustomization.yaml") {
+			_, _ = io.WriteString(w, `{"objectId": "61a86fdaa79e5c6f5fb6e4026508489feb4aa3ef", "gitObjectType": "blob", "path": "/kustomize"}`)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = io.WriteString(w, `{"message": "TF401174: The item could not be found in the repository at the specified version."}`)
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func TestAzureDevOpsListRepos(t *testing.T) {
+	cases := []struct {
+		name, proto   string
+		allBranches   bool
+		expectedRepos []*Repository
+		expectedError string
+	}{
+		{
+			name: "blank protocol",
+			expectedRepos: []*Repository{
+				{Organization: "my-org", Repository: "guestbook", URL: "https://my-org@dev.azure.com/my-org/my-project/_git/guestbook", Branch: "main", Labels: []string{}},
+				{Organization: "my-org", Repository: "helm-guestbook", URL: "https://my-org@dev.azure.com/my-org/my-project/_git/helm-guestbook", Branch: "master", Labels: []string{}},
+			},
+		},
+		{
+			name:  "ssh protocol",
+			proto: "ssh",
+			expectedRepos: []*Repository{
+				{Organization: "my-org", Repository: "guestbook", URL: "git@ssh.dev.azure.com:v3/my-org/my-project/guestbook", Branch: "main", Labels: []string{}},
+				{Organization: "my-org", Repository: "helm-guestbook", URL: "git@ssh.dev.azure.com:v3/my-org/my-project/helm-guestbook", Branch: "master", Labels: []string{}},
+			},
+		},
+		{
+			name:          "other protocol",
+			proto:         "other",
+			expectedError: "unknown clone protocol for Azure DevOps other",
+		},
+		{
+			name:        "all branches",
+			allBranches: true,
+			expectedRepos: []*Repository{
+				{Organization: "my-org", Repository: "guestbook", URL: "https://my-org@dev.azure.com/my-org/my-project/_git/guestbook", Branch: "main", Labels: []string{}},
+				{Organization: "my-org", Repository: "guestbook", URL: "https://my-org@dev.azure.com/my-org/my-project/_git/guestbook", Branch: "feature/foo", Labels: []string{}},
+				{Organization: "my-org", Repository: "helm-guestbook", URL: "https://my-org@dev.azure.com/my-org/my-project/_git/helm-guestbook", Branch: "master", Labels: []string{}},
+			},
+		},
+	}
+
+	ts := newAzureDevOpsMock(t)
+	defer ts.Close()
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			provider, err := NewAzureDevOpsProvider(context.Background(), "my-pat", "my-org", ts.URL, "my-project", c.allBranches)
+			assert.NoError(t, err)
+
+			repos, err := provider.ListRepos(context.Background(), c.proto)
+			if c.expectedError != "" {
+				assert.EqualError(t, err, c.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, c.expectedRepos, repos)
+			}
+		})
+	}
+}
+
+func TestAzureDevOpsAuth(t *testing.T) {
+	ts := newAzureDevOpsMock(t)
+	defer ts.Close()
+
+	_, err := NewAzureDevOpsProvider(context.Background(), "", "my-org", ts.URL, "my-project", false)
+	assert.EqualError(t, err, "no Azure DevOps access token provided")
+
+	provider, err := NewAzureDevOpsProvider(context.Background(), "other-pat", "my-org", ts.URL, "my-project", false)
+	assert.NoError(t, err)
+	_, err = provider.ListRepos(context.Background(), "")
+	assert.EqualError(t, err, "error listing repositories for my-org/my-project: GET /my-org/my-project/_apis/git/repositories: 401 Unauthorized")
+}
+
+func TestAzureDevOpsRepoHasPath(t *testing.T) {
+	ts := newAzureDevOpsMock(t)
+	defer ts.Close()
+
+	provider, err := NewAzureDevOpsProvider(context.Background(), "my-pat", "my-org", ts.URL, "my-project", false)
+	assert.NoError(t, err)
+	repo := &Repository{Organization: "my-org", Repository: "guestbook", Branch: "main"}
+
+	ok, err := provider.RepoHasPath(context.Background(), repo, "kustomize/kustomization.yaml")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = provider.RepoHasPath(context.Background(), repo, "kustomize")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = provider.RepoHasPath(context.Background(), repo, "notathing")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = provider.RepoHasPath(context.Background(), &Repository{Organization: "my-org", Repository: "guestbook", Branch: "other"}, "kustomize")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -0,0 +1,266 @@
+package scm_provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// BitbucketServerProvider lists the repositories of a Bitbucket Server (Data Center) project, using its REST API.
+type BitbucketServerProvider struct {
+	client      *http.Client
+	baseURL     string
+	projectKey  string
+	allBranches bool
+	// authorize sets the credentials of a request
+	authorize func(req *http.Request)
+}
+
+var _ SCMProviderService = &BitbucketServerProvider{}
+
+type bitbucketServerPage struct {
+	IsLastPage    bool            `json:"isLastPage"`
+	NextPageStart int             `json:"nextPageStart"`
+	Values        json.RawMessage `json:"values"`
+}
+
+type bitbucketServerRepository struct {
+	Slug    string `json:"slug"`
+	Project struct {
+		Key string `json:"key"`
+	} `json:"project"`
+	Links struct {
+		Clone []struct {
+			Href string `json:"href"`
+			Name string `json:"name"`
+		} `json:"clone"`
+	} `json:"links"`
+}
+
+type bitbucketServerBranch struct {
+	DisplayID string `json:"displayId"`
+}
+
+type bitbucketServerErrors struct {
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func NewBitbucketServerProviderBasicAuth(ctx context.Context, username, password, url, projectKey string, allBranches bool) (*BitbucketServerProvider, error) {
+	return newBitbucketServerProvider(url, projectKey, allBranches, func(req *http.Request) {
+		req.SetBasicAuth(username, password)
+	})
+}
+
+func NewBitbucketServerProviderBearerToken(ctx context.Context, token, url, projectKey string, allBranches bool) (*BitbucketServerProvider, error) {
+	return newBitbucketServerProvider(url, projectKey, allBranches, func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+token)
+	})
+}
+
+func NewBitbucketServerProviderNoAuth(ctx context.Context, url, projectKey string, allBranches bool) (*BitbucketServerProvider, error) {
+	return newBitbucketServerProvider(url, projectKey, allBranches, func(req *http.Request) {})
+}
+
+func newBitbucketServerProvider(baseURL, projectKey string, allBranches bool, authorize func(req *http.Request)) (*BitbucketServerProvider, error) {
+	if baseURL == "" {
+		return nil, fmt.Errorf("the Bitbucket Server URL is required")
+	}
+	if projectKey == "" {
+		return nil, fmt.Errorf("the Bitbucket Server project is required")
+	}
+	return &BitbucketServerProvider{
+		client:      http.DefaultClient,
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+		projectKey:  projectKey,
+		allBranches: allBranches,
+		authorize:   authorize,
+	}, nil
+}
+
+func (b *BitbucketServerProvider) ListRepos(ctx context.Context, cloneProtocol string) ([]*Repository, error) {
+	// Bitbucket Server names the https clone links "http"
+	var linkName string
+	switch cloneProtocol {
+	// Default to SSH if unspecified (i.e. if "").
+	case "", "ssh":
+		linkName = "ssh"
+	case "https":
+		linkName = "http"
+	default:
+		return nil, fmt.Errorf("unknown clone protocol for Bitbucket Server %v", cloneProtocol)
+	}
+
+	bitbucketRepos := []bitbucketServerRepository{}
+	err := b.listPages(ctx, b.projectPath("repos"), nil, func(values json.RawMessage) error {
+		page := []bitbucketServerRepository{}
+		if err := json.Unmarshal(values, &page); err != nil {
+			return err
+		}
+		bitbucketRepos = append(bitbucketRepos, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing repositories for %s: %v", b.projectKey, err)
+	}
+
+	repos := []*Repository{}
+	for _, bitbucketRepo := range bitbucketRepos {
+		var url string
+		for _, link := range bitbucketRepo.Links.Clone {
+			if link.Name == linkName {
+				url = link.Href
+				break
+			}
+		}
+		if url == "" {
+			return nil, fmt.Errorf("no %s clone link found for %s/%s", cloneProtocol, b.projectKey, bitbucketRepo.Slug)
+		}
+
+		branches, err := b.listBranches(ctx, bitbucketRepo.Slug)
+		if err != nil {
+			return nil, fmt.Errorf("error listing branches for %s/%s: %v", b.projectKey, bitbucketRepo.Slug, err)
+		}
+
+		for _, branch := range branches {
+			repos = append(repos, &Repository{
+				Organization: bitbucketRepo.Project.Key,
+				Repository:   bitbucketRepo.Slug,
+				URL:          url,
+				Branch:       branch,
+				// Bitbucket Server has no repository labels
+				Labels: []string{},
+			})
+		}
+	}
+	return repos, nil
+}
+
+func (b *BitbucketServerProvider) RepoHasPath(ctx context.Context, repo *Repository, path string) (bool, error) {
+	query := url.Values{}
+	query.Set("at", repo.Branch)
+	query.Set("type", "true")
+
+	status, err := b.get(ctx, b.projectPath("repos", repo.Repository, "browse", path), query, nil)
+	if status == http.StatusNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (b *BitbucketServerProvider) listBranches(ctx context.Context, repoSlug string) ([]string, error) {
+	// If we don't specifically want to query for all branches, just use the default branch and call it a day.
+	if !b.allBranches {
+		defaultBranch := bitbucketServerBranch{}
+		status, err := b.get(ctx, b.projectPath("repos", repoSlug, "branches", "default"), nil, &defaultBranch)
+		// Empty repositories have no default branch
+		if status == http.StatusNoContent || status == http.StatusNotFound {
+			return []string{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return []string{defaultBranch.DisplayID}, nil
+	}
+
+	// Otherwise, scrape the branches API.
+	branches := []string{}
+	err := b.listPages(ctx, b.projectPath("repos", repoSlug, "branches"), nil, func(values json.RawMessage) error {
+		page := []bitbucketServerBranch{}
+		if err := json.Unmarshal(values, &page); err != nil {
+			return err
+		}
+		for _, branch := range page {
+			branches = append(branches, branch.DisplayID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return branches, nil
+}
+
+// projectPath returns the REST API path of the project, followed by the escaped elements.
+func (b *BitbucketServerProvider) projectPath(elements ...string) string {
+	path := "/rest/api/1.0/projects/" + url.PathEscape(b.projectKey)
+	for _, element := range elements {
+		for _, segment := range strings.Split(element, "/") {
+			path += "/" + url.PathEscape(segment)
+		}
+	}
+	return path
+}
+
+// listPages calls handlePage with the values of each page of the paged API.
+func (b *BitbucketServerProvider) listPages(ctx context.Context, path string, query url.Values, handlePage func(values json.RawMessage) error) error {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("limit", "100")
+
+	for {
+		page := bitbucketServerPage{}
+		if _, err := b.get(ctx, path, query, &page); err != nil {
+			return err
+		}
+		if err := handlePage(page.Values); err != nil {
+			return err
+		}
+		if page.IsLastPage {
+			return nil
+		}
+		query.Set("start", strconv.Itoa(page.NextPageStart))
+	}
+}
+
+// get calls the REST API, and decodes the response into out unless it is nil. The status code of the response is
+// returned along with the error, if any.
+func (b *BitbucketServerProvider) get(ctx context.Context, path string, query url.Values, out interface{}) (int, error) {
+	reqURL := b.baseURL + path
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+	b.authorize(req)
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		errs := bitbucketServerErrors{}
+		if json.Unmarshal(body, &errs) == nil && len(errs.Errors) > 0 {
+			return resp.StatusCode, fmt.Errorf("GET %s: %s: %s", path, resp.Status, errs.Errors[0].Message)
+		}
+		return resp.StatusCode, fmt.Errorf("GET %s: %s", path, resp.Status)
+	}
+
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.Unmarshal(body, out); err != nil {
+			return resp.StatusCode, fmt.Errorf("error decoding the response of GET %s: %v", path, err)
+		}
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,186 @@
+package scm_provider
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func bitbucketServerRepoJSON(slug string) string {
+	return fmt.Sprintf(`{
+		"slug": "%[1]s",
+		"name": "%[1]s",
+		"project": {"key": "PROJECT"},
+		"links": {
+			"clone": [
+				{"href": "ssh://git@bitbucket.example.com:7999/project/%[1]s.git", "name": "ssh"},
+				{"href": "https://bitbucket.example.com/scm/project/%[1]s.git", "name": "http"}
+			]
+		}
+	}`, slug)
+}
+
+// newBitbucketServerMock returns a fake of the Bitbucket Server REST API, which serves the PROJECT project and
+// requires the requests to have the given Authorization header.
+func newBitbucketServerMock(t *testing.T, authorization string) *httptest.Server {
+	mux := http.NewServeMux()
+
+	handle := func(path string, handler func(w http.ResponseWriter, r *http.Request)) {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != authorization {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = io.WriteString(w, `{"errors": [{"message": "Authentication failed. Please check your credentials and try again."}]}`)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			handler(w, r)
+		})
+	}
+
+	handle("/rest/api/1.0/projects/PROJECT/repos", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "100", r.URL.Query().Get("limit"))
+		switch r.URL.Query().Get("start") {
+		case "":
+			_, _ = fmt.Fprintf(w, `{"size": 1, "limit": 1, "isLastPage": false, "nextPageStart": 1, "values": [%s]}`, bitbucketServerRepoJSON("repo-1"))
+		case "1":
+			_, _ = fmt.Fprintf(w, `{"size": 2, "limit": 2, "isLastPage": true, "values": [%s, %s]}`, bitbucketServerRepoJSON("repo-2"), bitbucketServerRepoJSON("empty-repo"))
+		default:
+			t.Errorf("unexpected start %q", r.URL.Query().Get("start"))
+		}
+	})
+	handle("/rest/api/1.0/projects/PROJECT/repos/repo-1/branches/default", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"id": "refs/heads/main", "displayId": "main", "type": "BRANCH", "isDefault": true}`)
+	})
+	handle("/rest/api/1.0/projects/PROJECT/repos/repo-2/branches/default", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"id": "refs/heads/master", "displayId": "master", "type": "BRANCH", "isDefault": true}`)
+	})
+	handle("/rest/api/1.0/projects/PROJECT/repos/empty-repo/branches/default", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	handle("/rest/api/1.0/projects/PROJECT/repos/repo-1/branches", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"size": 2, "limit": 100, "isLastPage": true, "values": [{"displayId": "main"}, {"displayId": "feature/foo"}]}`)
+	})
+	handle("/rest/api/1.0/projects/PROJECT/repos/repo-2/branches", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"size": 1, "limit": 100, "isLastPage": true, "values": [{"displayId": "master"}]}`)
+	})
+	handle("/rest/api/1.0/projects/PROJECT/repos/empty-repo/branches", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"size": 0, "limit": 100, "isLastPage": true, "values": []}`)
+	})
+	handle("/rest/api/1.0/projects/PROJECT/repos/repo-1/browse/", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "true", r.URL.Query().Get("type"))
+		switch {
+		case r.URL.Path == "/rest/api/1.0/projects/PROJECT/repos/repo-1/browse/kubernetes/kustomization.yaml" && r.URL.Query().Get("at") == "main":
+			_, _ = io.WriteString(w, `{"type": "FILE"}`)
+		case r.URL.Path == "/rest/api/1.0/projects/PROJECT/repos/repo-1/browse/helm" && r.URL.Query().Get("at") == "main":
+			_, _ = io.WriteString(w, `{"type": "DIRECTORY"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"errors": [{"message": "The path does not exist at revision"}]}`)
+		}
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func TestBitbucketServerListRepos(t *testing.T) {
+	cases := []struct {
+		name, proto   string
+		allBranches   bool
+		expectedRepos []*Repository
+		expectedError string
+	}{
+		{
+			name: "blank protocol",
+			expectedRepos: []*Repository{
+				{Organization: "PROJECT", Repository: "repo-1", URL: "ssh://git@bitbucket.example.com:7999/project/repo-1.git", Branch: "main", Labels: []string{}},
+				{Organization: "PROJECT", Repository: "repo-2", URL: "ssh://git@bitbucket.example.com:7999/project/repo-2.git", Branch: "master", Labels: []string{}},
+			},
+		},
+		{
+			name:  "https protocol",
+			proto: "https",
+			expectedRepos: []*Repository{
+				{Organization: "PROJECT", Repository: "repo-1", URL: "https://bitbucket.example.com/scm/project/repo-1.git", Branch: "main", Labels: []string{}},
+				{Organization: "PROJECT", Repository: "repo-2", URL: "https://bitbucket.example.com/scm/project/repo-2.git", Branch: "master", Labels: []string{}},
+			},
+		},
+		{
+			name:          "other protocol",
+			proto:         "other",
+			expectedError: "unknown clone protocol for Bitbucket Server other",
+		},
+		{
+			name:        "all branches",
+			allBranches: true,
+			expectedRepos: []*Repository{
+				{Organization: "PROJECT", Repository: "repo-1", URL: "ssh://git@bitbucket.example.com:7999/project/repo-1.git", Branch: "main", Labels: []string{}},
+				{Organization: "PROJECT", Repository: "repo-1", URL: "ssh://git@bitbucket.example.com:7999/project/repo-1.git", Branch: "feature/foo", Labels: []string{}},
+				{Organization: "PROJECT", Repository: "repo-2", URL: "ssh://git@bitbucket.example.com:7999/project/repo-2.git", Branch: "master", Labels: []string{}},
+			},
+		},
+	}
+
+	ts := newBitbucketServerMock(t, "Basic dXNlcjpwYXNzd29yZA==")
+	defer ts.Close()
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			provider, err := NewBitbucketServerProviderBasicAuth(context.Background(), "user", "password", ts.URL, "PROJECT", c.allBranches)
+			assert.NoError(t, err)
+
+			repos, err := provider.ListRepos(context.Background(), c.proto)
+			if c.expectedError != "" {
+				assert.EqualError(t, err, c.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, c.expectedRepos, repos)
+			}
+		})
+	}
+}
+
+func TestBitbucketServerAuth(t *testing.T) {
+	ts := newBitbucketServerMock(t, "Bearer my-token")
+	defer ts.Close()
+
+	provider, err := NewBitbucketServerProviderBearerToken(context.Background(), "my-token", ts.URL+"/", "PROJECT", false)
+	assert.NoError(t, err)
+	repos, err := provider.ListRepos(context.Background(), "ssh")
+	assert.NoError(t, err)
+	assert.Len(t, repos, 2)
+
+	provider, err = NewBitbucketServerProviderNoAuth(context.Background(), ts.URL, "PROJECT", false)
+	assert.NoError(t, err)
+	_, err = provider.ListRepos(context.Background(), "ssh")
+	assert.EqualError(t, err, "error listing repositories for PROJECT: GET /rest/api/1.0/projects/PROJECT/repos: 401 Unauthorized: Authentication failed. Please check your credentials and try again.")
+}
+
+func TestBitbucketServerRepoHasPath(t *testing.T) {
+	ts := newBitbucketServerMock(t, "")
+	defer ts.Close()
+
+	provider, err := NewBitbucketServerProviderNoAuth(context.Background(), ts.URL, "PROJECT", false)
+	assert.NoError(t, err)
+	repo := &Repository{Organization: "PROJECT", Repository: "repo-1", Branch: "main"}
+
+	ok, err := provider.RepoHasPath(context.Background(), repo, "kubernetes/kustomization.yaml")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = provider.RepoHasPath(context.Background(), repo, "helm")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = provider.RepoHasPath(context.Background(), repo, "notathing")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = provider.RepoHasPath(context.Background(), &Repository{Organization: "PROJECT", Repository: "repo-1", Branch: "other"}, "helm")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
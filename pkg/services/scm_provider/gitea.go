@@ -0,0 +1,251 @@
+package scm_provider
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// giteaPageSize is the number of items requested per page, which is capped by the MAX_RESPONSE_ITEMS setting of Gitea
+// (50 by default).
+const giteaPageSize = 50
+
+// GiteaProvider lists the repositories of a Gitea organization, using its REST API.
+type GiteaProvider struct {
+	client      *http.Client
+	baseURL     string
+	token       string
+	owner       string
+	allBranches bool
+}
+
+var _ SCMProviderService = &GiteaProvider{}
+
+type giteaRepository struct {
+	Name  string `json:"name"`
+	Owner struct {
+		Login string `json:"login"`
+	} `json:"owner"`
+	CloneURL      string `json:"clone_url"`
+	SSHURL        string `json:"ssh_url"`
+	DefaultBranch string `json:"default_branch"`
+	Empty         bool   `json:"empty"`
+}
+
+type giteaBranch struct {
+	Name string `json:"name"`
+}
+
+type giteaTopics struct {
+	Topics []string `json:"topics"`
+}
+
+type giteaError struct {
+	Message string `json:"message"`
+}
+
+func NewGiteaProvider(ctx context.Context, owner, token, url string, allBranches, insecure bool) (*GiteaProvider, error) {
+	// Undocumented environment variable to set a default token, to be used in testing to dodge anonymous rate limits.
+	if token == "" {
+		token = os.Getenv("GITEA_TOKEN")
+	}
+	if url == "" {
+		return nil, fmt.Errorf("the Gitea URL is required")
+	}
+
+	client := http.DefaultClient
+	if insecure {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+		client = &http.Client{Transport: transport}
+	}
+
+	return &GiteaProvider{
+		client:      client,
+		baseURL:     strings.TrimSuffix(url, "/"),
+		token:       token,
+		owner:       owner,
+		allBranches: allBranches,
+	}, nil
+}
+
+func (g *GiteaProvider) ListRepos(ctx context.Context, cloneProtocol string) ([]*Repository, error) {
+	giteaRepos := []giteaRepository{}
+	err := g.listPages(ctx, "/api/v1/orgs/"+url.PathEscape(g.owner)+"/repos", func(data []byte) (int, error) {
+		page := []giteaRepository{}
+		if err := json.Unmarshal(data, &page); err != nil {
+			return 0, err
+		}
+		giteaRepos = append(giteaRepos, page...)
+		return len(page), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing repositories for %s: %v", g.owner, err)
+	}
+
+	repos := []*Repository{}
+	for _, giteaRepo := range giteaRepos {
+		var url string
+		switch cloneProtocol {
+		// Default to SSH if unspecified (i.e. if "").
+		case "", "ssh":
+			url = giteaRepo.SSHURL
+		case "https":
+			url = giteaRepo.CloneURL
+		default:
+			return nil, fmt.Errorf("unknown clone protocol for Gitea %v", cloneProtocol)
+		}
+
+		// Empty repositories have no branches
+		if giteaRepo.Empty {
+			continue
+		}
+
+		branches, err := g.listBranches(ctx, giteaRepo)
+		if err != nil {
+			return nil, fmt.Errorf("error listing branches for %s/%s: %v", giteaRepo.Owner.Login, giteaRepo.Name, err)
+		}
+
+		topics := giteaTopics{}
+		if _, err := g.get(ctx, g.repoPath(giteaRepo.Owner.Login, giteaRepo.Name, "topics"), nil, &topics); err != nil {
+			return nil, fmt.Errorf("error listing topics for %s/%s: %v", giteaRepo.Owner.Login, giteaRepo.Name, err)
+		}
+		if topics.Topics == nil {
+			topics.Topics = []string{}
+		}
+
+		for _, branch := range branches {
+			repos = append(repos, &Repository{
+				Organization: giteaRepo.Owner.Login,
+				Repository:   giteaRepo.Name,
+				URL:          url,
+				Branch:       branch,
+				Labels:       topics.Topics,
+			})
+		}
+	}
+	return repos, nil
+}
+
+func (g *GiteaProvider) RepoHasPath(ctx context.Context, repo *Repository, path string) (bool, error) {
+	query := url.Values{}
+	query.Set("ref", repo.Branch)
+
+	status, err := g.get(ctx, g.repoPath(repo.Organization, repo.Repository, "contents", path), query, nil)
+	if status == http.StatusNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (g *GiteaProvider) listBranches(ctx context.Context, repo giteaRepository) ([]string, error) {
+	// If we don't specifically want to query for all branches, just use the default branch and call it a day.
+	if !g.allBranches {
+		return []string{repo.DefaultBranch}, nil
+	}
+
+	// Otherwise, scrape the branches API.
+	branches := []string{}
+	err := g.listPages(ctx, g.repoPath(repo.Owner.Login, repo.Name, "branches"), func(data []byte) (int, error) {
+		page := []giteaBranch{}
+		if err := json.Unmarshal(data, &page); err != nil {
+			return 0, err
+		}
+		for _, branch := range page {
+			branches = append(branches, branch.Name)
+		}
+		return len(page), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return branches, nil
+}
+
+// repoPath returns the REST API path of the repository, followed by the escaped elements.
+func (g *GiteaProvider) repoPath(owner, repo string, elements ...string) string {
+	path := "/api/v1/repos/" + url.PathEscape(owner) + "/" + url.PathEscape(repo)
+	for _, element := range elements {
+		for _, segment := range strings.Split(element, "/") {
+			path += "/" + url.PathEscape(segment)
+		}
+	}
+	return path
+}
+
+// listPages calls handlePage with each page of the paged API, until a page is not full. handlePage returns the number
+// of items of the page.
+func (g *GiteaProvider) listPages(ctx context.Context, path string, handlePage func(data []byte) (int, error)) error {
+	query := url.Values{}
+	query.Set("limit", strconv.Itoa(giteaPageSize))
+
+	for page := 1; ; page++ {
+		query.Set("page", strconv.Itoa(page))
+
+		var data json.RawMessage
+		if _, err := g.get(ctx, path, query, &data); err != nil {
+			return err
+		}
+		count, err := handlePage(data)
+		if err != nil {
+			return err
+		}
+		if count < giteaPageSize {
+			return nil
+		}
+	}
+}
+
+// get calls the REST API, and decodes the response into out unless it is nil. The status code of the response is
+// returned along with the error, if any.
+func (g *GiteaProvider) get(ctx context.Context, path string, query url.Values, out interface{}) (int, error) {
+	reqURL := g.baseURL + path
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if g.token != "" {
+		req.Header.Set("Authorization", "token "+g.token)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		giteaErr := giteaError{}
+		if json.Unmarshal(body, &giteaErr) == nil && giteaErr.Message != "" {
+			return resp.StatusCode, fmt.Errorf("GET %s: %s: %s", path, resp.Status, giteaErr.Message)
+		}
+		return resp.StatusCode, fmt.Errorf("GET %s: %s", path, resp.Status)
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(body, out); err != nil {
+			return resp.StatusCode, fmt.Errorf("error decoding the response of GET %s: %v", path, err)
+		}
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,183 @@
+package scm_provider
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func giteaRepoJSON(name string, empty bool) string {
+	return fmt.Sprintf(`{
+		"name": "%[1]s",
+		"owner": {"login": "gitea"},
+		"clone_url": "https://gitea.example.com/gitea/%[1]s.git",
+		"ssh_url": "git@gitea.example.com:gitea/%[1]s.git",
+		"default_branch": "main",
+		"empty": %[2]t
+	}`, name, empty)
+}
+
+// newGiteaMock returns a local stand-in for the Gitea REST API, serving the gitea organization over TLS with a
+// self-signed certificate. It serves pages of one item, to exercise the pagination.
+func newGiteaMock(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+
+	handle := func(path string, handler func(w http.ResponseWriter, r *http.Request)) {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "token my-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = io.WriteString(w, `{"message": "token is required"}`)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			handler(w, r)
+		})
+	}
+
+	handle("/api/v1/orgs/gitea/repos", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "50", r.URL.Query().Get("limit"))
+		switch r.URL.Query().Get("page") {
+		case "1":
+			repos := []string{giteaRepoJSON("go-sdk", false), giteaRepoJSON("empty", true)}
+			for i := 0; i < 48; i++ {
+				repos = append(repos, giteaRepoJSON(fmt.Sprintf("archived-%d", i), true))
+			}
+			_, _ = fmt.Fprintf(w, "[%s]", strings.Join(repos, ","))
+		case "2":
+			_, _ = fmt.Fprintf(w, "[%s]", giteaRepoJSON("tea", false))
+		default:
+			t.Errorf("unexpected page %q", r.URL.Query().Get("page"))
+		}
+	})
+	handle("/api/v1/repos/gitea/go-sdk/topics", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"topics": ["go", "sdk"]}`)
+	})
+	handle("/api/v1/repos/gitea/tea/topics", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"topics": null}`)
+	})
+	handle("/api/v1/repos/gitea/go-sdk/branches", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `[{"name": "main"}, {"name": "release/v0.15"}]`)
+	})
+	handle("/api/v1/repos/gitea/tea/branches", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `[{"name": "main"}]`)
+	})
+	handle("/api/v1/repos/gitea/go-sdk/contents/", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v1/repos/gitea/go-sdk/contents/gitea/client.go" && r.URL.Query().Get("ref") == "main":
+			_, _ = io.WriteString(w, `{"name": "client.go", "path": "gitea/client.go", "type": "file"}`)
+		case r.URL.Path == "/api/v1/repos/gitea/go-sdk/contents/gitea" && r.URL.Query().Get("ref") == "main":
+			_, _ = io.WriteString(w, `[{"name": "client.go", "path": "gitea/client.go", "type": "file"}]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"message": "object does not exist"}`)
+		}
+	})
+
+	return httptest.NewTLSServer(mux)
+}
+
+func TestGiteaListRepos(t *testing.T) {
+	cases := []struct {
+		name, proto   string
+		allBranches   bool
+		expectedRepos []*Repository
+		expectedError string
+	}{
+		{
+			name: "blank protocol",
+			expectedRepos: []*Repository{
+				{Organization: "gitea", Repository: "go-sdk", URL: "git@gitea.example.com:gitea/go-sdk.git", Branch: "main", Labels: []string{"go", "sdk"}},
+				{Organization: "gitea", Repository: "tea", URL: "git@gitea.example.com:gitea/tea.git", Branch: "main", Labels: []string{}},
+			},
+		},
+		{
+			name:  "https protocol",
+			proto: "https",
+			expectedRepos: []*Repository{
+				{Organization: "gitea", Repository: "go-sdk", URL: "https://gitea.example.com/gitea/go-sdk.git", Branch: "main", Labels: []string{"go", "sdk"}},
+				{Organization: "gitea", Repository: "tea", URL: "https://gitea.example.com/gitea/tea.git", Branch: "main", Labels: []string{}},
+			},
+		},
+		{
+			name:          "other protocol",
+			proto:         "other",
+			expectedError: "unknown clone protocol for Gitea other",
+		},
+		{
+			name:        "all branches",
+			allBranches: true,
+			expectedRepos: []*Repository{
+				{Organization: "gitea", Repository: "go-sdk", URL: "git@gitea.example.com:gitea/go-sdk.git", Branch: "main", Labels: []string{"go", "sdk"}},
+				{Organization: "gitea", Repository: "go-sdk", URL: "git@gitea.example.com:gitea/go-sdk.git", Branch: "release/v0.15", Labels: []string{"go", "sdk"}},
+				{Organization: "gitea", Repository: "tea", URL: "git@gitea.example.com:gitea/tea.git", Branch: "main", Labels: []string{}},
+			},
+		},
+	}
+
+	ts := newGiteaMock(t)
+	defer ts.Close()
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			provider, err := NewGiteaProvider(context.Background(), "gitea", "my-token", ts.URL, c.allBranches, true)
+			assert.NoError(t, err)
+
+			repos, err := provider.ListRepos(context.Background(), c.proto)
+			if c.expectedError != "" {
+				assert.EqualError(t, err, c.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, c.expectedRepos, repos)
+			}
+		})
+	}
+}
+
+func TestGiteaInsecure(t *testing.T) {
+	ts := newGiteaMock(t)
+	defer ts.Close()
+
+	// The certificate of the server is self-signed, so it is only accepted by insecure providers
+	provider, err := NewGiteaProvider(context.Background(), "gitea", "my-token", ts.URL, false, false)
+	assert.NoError(t, err)
+	_, err = provider.ListRepos(context.Background(), "ssh")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "certificate")
+	}
+
+	provider, err = NewGiteaProvider(context.Background(), "gitea", "", ts.URL, false, true)
+	assert.NoError(t, err)
+	_, err = provider.ListRepos(context.Background(), "ssh")
+	assert.EqualError(t, err, "error listing repositories for gitea: GET /api/v1/orgs/gitea/repos: 401 Unauthorized: token is required")
+}
+
+func TestGiteaRepoHasPath(t *testing.T) {
+	ts := newGiteaMock(t)
+	defer ts.Close()
+
+	provider, err := NewGiteaProvider(context.Background(), "gitea", "my-token", ts.URL, false, true)
+	assert.NoError(t, err)
+	repo := &Repository{Organization: "gitea", Repository: "go-sdk", Branch: "main"}
+
+	ok, err := provider.RepoHasPath(context.Background(), repo, "gitea/client.go")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = provider.RepoHasPath(context.Background(), repo, "gitea")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = provider.RepoHasPath(context.Background(), repo, "notathing")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = provider.RepoHasPath(context.Background(), &Repository{Organization: "gitea", Repository: "go-sdk", Branch: "other"}, "gitea")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
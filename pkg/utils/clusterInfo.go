@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/argoproj/argo-cd/v2/common"
+	appv1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes"
+)
+
+// serverVersionProbeTimeout is how long a cluster may take to return the version of its API server
+const serverVersionProbeTimeout = 10 * time.Second
+
+// ServerVersionProbe returns the version of the API server of the cluster, as "<major>.<minor>".
+type ServerVersionProbe func(cluster *appv1.Cluster) (string, error)
+
+// ClusterInfoCache fills in the server version and connection state of clusters, which Argo CD does not store in their
+// Secrets, by probing their API servers. The results are cached, so that the clusters are not probed on every
+// reconciliation.
+//
+// A nil cache leaves the clusters as they are.
+type ClusterInfoCache struct {
+	ttl   time.Duration
+	probe ServerVersionProbe
+
+	lock sync.Mutex
+	// infos are the results of the last probes, by server URL
+	infos map[string]clusterInfo
+}
+
+type clusterInfo struct {
+	serverVersion   string
+	connectionState appv1.ConnectionState
+	expiresAt       time.Time
+}
+
+// NewClusterInfoCache returns a ClusterInfoCache probing the clusters with probe, at most once per ttl.
+func NewClusterInfoCache(ttl time.Duration, probe ServerVersionProbe) *ClusterInfoCache {
+	return &ClusterInfoCache{
+		ttl:   ttl,
+		probe: probe,
+		infos: map[string]clusterInfo{},
+	}
+}
+
+// SetClusterInfo sets the server version and connection state of the clusters, including the local cluster. The
+// clusters which were not probed recently are probed concurrently.
+func (c *ClusterInfoCache) SetClusterInfo(clusters []*appv1.Cluster) {
+	if c == nil {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, cluster := range clusters {
+		wg.Add(1)
+		go func(cluster *appv1.Cluster) {
+			defer wg.Done()
+			info := c.get(cluster)
+			cluster.ServerVersion = info.serverVersion
+			cluster.ConnectionState = info.connectionState
+		}(cluster)
+	}
+	wg.Wait()
+}
+
+// RefreshInterval returns how long the info of the clusters is cached, after which they are probed again.
+func (c *ClusterInfoCache) RefreshInterval() time.Duration {
+	return c.ttl
+}
+
+// Evict forgets the info of the cluster of the server, e.g. because the cluster was removed from Argo CD or its
+// credentials changed.
+func (c *ClusterInfoCache) Evict(server string) {
+	if c == nil {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.infos, server)
+}
+
+// get returns the cached info of the cluster, probing it if it expired.
+func (c *ClusterInfoCache) get(cluster *appv1.Cluster) clusterInfo {
+	c.lock.Lock()
+	info, found := c.infos[cluster.Server]
+	c.lock.Unlock()
+	if found && time.Now().Before(info.expiresAt) {
+		return info
+	}
+
+	now := metav1.Now()
+	version, err := c.probe(cluster)
+	if err != nil {
+		info = clusterInfo{
+			connectionState: appv1.ConnectionState{
+				Status:     appv1.ConnectionStatusFailed,
+				Message:    err.Error(),
+				ModifiedAt: &now,
+			},
+		}
+	} else {
+		info = clusterInfo{
+			serverVersion: version,
+			connectionState: appv1.ConnectionState{
+				Status:     appv1.ConnectionStatusSuccessful,
+				ModifiedAt: &now,
+			},
+		}
+	}
+	info.expiresAt = now.Add(c.ttl)
+
+	c.lock.Lock()
+	c.infos[cluster.Server] = info
+	c.lock.Unlock()
+	return info
+}
+
+// NewServerVersionProbe returns the ServerVersionProbe requesting the version of the API servers of the clusters with
+// ProbeServerVersion, except for the local cluster without credentials, which is requested with the clientset of the
+// controller.
+func NewServerVersionProbe(clientset kubernetes.Interface) ServerVersionProbe {
+	return func(cluster *appv1.Cluster) (string, error) {
+		if !isLocalCluster(cluster) {
+			return ProbeServerVersion(cluster)
+		}
+
+		info, err := clientset.Discovery().ServerVersion()
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s.%s", info.Major, info.Minor), nil
+	}
+}
+
+// isLocalCluster returns whether the cluster is the local cluster without credentials, which Argo CD connects to with
+// its in-cluster config.
+func isLocalCluster(cluster *appv1.Cluster) bool {
+	return cluster.Server == common.KubernetesInternalAPIServerAddr &&
+		cluster.Config.Username == "" && cluster.Config.Password == "" && cluster.Config.BearerToken == ""
+}
+
+// ProbeServerVersion is the ServerVersionProbe requesting the version of the API server of the cluster, with the
+// credentials of its Secret.
+func ProbeServerVersion(cluster *appv1.Cluster) (version string, err error) {
+	// RESTConfig panics when the credentials of the cluster are invalid
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	config := cluster.RESTConfig()
+	config.Timeout = serverVersionProbeTimeout
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return "", err
+	}
+	info, err := discoveryClient.ServerVersion()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s.%s", info.Major, info.Minor), nil
+}
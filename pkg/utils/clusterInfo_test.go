@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	argoappv1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestClusterInfoCache(t *testing.T) {
+	var lock sync.Mutex
+	probes := map[string]int{}
+	probe := func(cluster *argoappv1.Cluster) (string, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		probes[cluster.Server]++
+		if cluster.Server == "https://production-01.example.com" {
+			return "", errors.New("connection refused")
+		}
+		return "1.20", nil
+	}
+
+	staging := &argoappv1.Cluster{Name: "staging-01", Server: "https://staging-01.example.com"}
+	production := &argoappv1.Cluster{Name: "production-01", Server: "https://production-01.example.com"}
+	local := getLocalCluster()
+
+	cache := NewClusterInfoCache(time.Hour, probe)
+	cache.SetClusterInfo([]*argoappv1.Cluster{staging, production, local})
+
+	assert.Equal(t, "1.20", staging.ServerVersion)
+	assert.Equal(t, argoappv1.ConnectionStatusSuccessful, staging.ConnectionState.Status)
+	assert.Equal(t, "", production.ServerVersion)
+	assert.Equal(t, argoappv1.ConnectionStatusFailed, production.ConnectionState.Status)
+	assert.Equal(t, "connection refused", production.ConnectionState.Message)
+	assert.Equal(t, "1.20", local.ServerVersion)
+	assert.Equal(t, argoappv1.ConnectionStatusSuccessful, local.ConnectionState.Status)
+
+	// The results are cached until they expire
+	other := &argoappv1.Cluster{Name: "staging-01", Server: "https://staging-01.example.com"}
+	cache.SetClusterInfo([]*argoappv1.Cluster{other})
+	assert.Equal(t, "1.20", other.ServerVersion)
+	assert.Equal(t, map[string]int{"https://staging-01.example.com": 1, "https://production-01.example.com": 1, "https://kubernetes.default.svc": 1}, probes)
+
+	// Expired results are probed again
+	cache.infos["https://staging-01.example.com"] = clusterInfo{expiresAt: time.Now().Add(-time.Minute)}
+	cache.SetClusterInfo([]*argoappv1.Cluster{{Name: "staging-01", Server: "https://staging-01.example.com"}})
+	assert.Equal(t, 2, probes["https://staging-01.example.com"])
+
+	// Evicted results are probed again
+	cache.Evict("https://production-01.example.com")
+	cache.SetClusterInfo([]*argoappv1.Cluster{{Name: "production-01", Server: "https://production-01.example.com"}})
+	assert.Equal(t, 2, probes["https://production-01.example.com"])
+
+	// A nil cache leaves the clusters as they are
+	var nilCache *ClusterInfoCache
+	unknown := &argoappv1.Cluster{Name: "staging-01", Server: "https://staging-01.example.com"}
+	nilCache.SetClusterInfo([]*argoappv1.Cluster{unknown})
+	nilCache.Evict("https://staging-01.example.com")
+	assert.Equal(t, argoappv1.ConnectionState{}, unknown.ConnectionState)
+}
+
+func TestClusterInfoCacheLocalCluster(t *testing.T) {
+	var available int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/version" || atomic.LoadInt32(&available) == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"major": "1", "minor": "21", "gitVersion": "v1.21.2"}`))
+	}))
+	defer ts.Close()
+
+	// The clientset of the controller, which connects to the local cluster
+	clientset, err := kubernetes.NewForConfig(&rest.Config{Host: ts.URL})
+	assert.NoError(t, err)
+	cache := NewClusterInfoCache(time.Hour, NewServerVersionProbe(clientset))
+
+	// The local cluster is unknown until it is probed
+	local := getLocalCluster()
+	assert.Equal(t, argoappv1.ConnectionState{}, local.ConnectionState)
+
+	cache.SetClusterInfo([]*argoappv1.Cluster{local})
+	assert.Equal(t, argoappv1.ConnectionStatusFailed, local.ConnectionState.Status)
+	assert.Equal(t, "", local.ServerVersion)
+
+	// As for the other clusters, the failure is cached until it expires
+	atomic.StoreInt32(&available, 1)
+	local = getLocalCluster()
+	cache.SetClusterInfo([]*argoappv1.Cluster{local})
+	assert.Equal(t, argoappv1.ConnectionStatusFailed, local.ConnectionState.Status)
+
+	cache.infos["https://kubernetes.default.svc"] = clusterInfo{expiresAt: time.Now().Add(-time.Minute)}
+	local = getLocalCluster()
+	cache.SetClusterInfo([]*argoappv1.Cluster{local})
+	assert.Equal(t, argoappv1.ConnectionStatusSuccessful, local.ConnectionState.Status)
+	assert.Equal(t, "1.21", local.ServerVersion)
+
+	// The local cluster with credentials in a Secret is probed with them
+	assert.True(t, isLocalCluster(&argoappv1.Cluster{Server: "https://kubernetes.default.svc"}))
+	assert.False(t, isLocalCluster(&argoappv1.Cluster{Server: "https://kubernetes.default.svc", Config: argoappv1.ClusterConfig{BearerToken: "my-token"}}))
+	assert.False(t, isLocalCluster(&argoappv1.Cluster{Server: "https://staging-01.example.com"}))
+}
+
+func TestProbeServerVersion(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/version" || r.Header.Get("Authorization") != "Bearer my-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"major": "1", "minor": "21+", "gitVersion": "v1.21.2-eks-0389ca3"}`))
+	}))
+	defer ts.Close()
+
+	version, err := ProbeServerVersion(&argoappv1.Cluster{
+		Server: ts.URL,
+		Config: argoappv1.ClusterConfig{BearerToken: "my-token"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "1.21+", version)
+
+	_, err = ProbeServerVersion(&argoappv1.Cluster{
+		Server: ts.URL,
+		Config: argoappv1.ClusterConfig{BearerToken: "other-token"},
+	})
+	assert.Error(t, err)
+}
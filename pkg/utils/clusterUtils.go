@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/argoproj/argo-cd/v2/common"
@@ -31,14 +30,10 @@ import (
 // I hope to upstream this change in some form, so that we do not need to worry about
 // Argo CD changing the logic on us.
 
-var (
-	localCluster = appv1.Cluster{
-		Name:            "in-cluster",
-		Server:          common.KubernetesInternalAPIServerAddr,
-		ConnectionState: appv1.ConnectionState{Status: appv1.ConnectionStatusSuccessful},
-	}
-	initLocalCluster sync.Once
-)
+var localCluster = appv1.Cluster{
+	Name:   "in-cluster",
+	Server: common.KubernetesInternalAPIServerAddr,
+}
 
 const (
 	ArgoCDSecretTypeLabel   = "argocd.argoproj.io/secret-type"
@@ -71,31 +66,15 @@ func ListClusters(ctx context.Context, clientset kubernetes.Interface, namespace
 		}
 	}
 	if !hasInClusterCredentials {
-		localCluster := getLocalCluster(clientset)
-		if localCluster != nil {
-			clusterList.Items = append(clusterList.Items, *localCluster)
-		}
+		clusterList.Items = append(clusterList.Items, *getLocalCluster())
 	}
 	return &clusterList, nil
 }
 
-func getLocalCluster(clientset kubernetes.Interface) *appv1.Cluster {
-	initLocalCluster.Do(func() {
-		info, err := clientset.Discovery().ServerVersion()
-		if err == nil {
-			localCluster.ServerVersion = fmt.Sprintf("%s.%s", info.Major, info.Minor)
-			localCluster.ConnectionState = appv1.ConnectionState{Status: appv1.ConnectionStatusSuccessful}
-		} else {
-			localCluster.ConnectionState = appv1.ConnectionState{
-				Status:  appv1.ConnectionStatusFailed,
-				Message: err.Error(),
-			}
-		}
-	})
-	cluster := localCluster.DeepCopy()
-	now := metav1.Now()
-	cluster.ConnectionState.ModifiedAt = &now
-	return cluster
+// getLocalCluster returns the local cluster, when it has no Secret. As for the other clusters, its server version and
+// connection state are unknown until a ClusterInfoCache probes it.
+func getLocalCluster() *appv1.Cluster {
+	return localCluster.DeepCopy()
 }
 
 // secretToCluster converts a secret into a Cluster object
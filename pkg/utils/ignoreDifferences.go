@@ -0,0 +1,365 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	argov1alpha1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+	"github.com/itchyny/gojq"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	argoprojiov1alpha1 "github.com/argoproj-labs/applicationset/api/v1alpha1"
+)
+
+// jqPathTimeout is how long a jq path expression may be evaluated against an Application
+const jqPathTimeout = time.Second
+
+// jsonPointerUnescaper decodes the escaped '~' and '/' characters of the reference tokens of JSON pointers
+var jsonPointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// applicationType is the type of the Applications whose fields are selected by the ignored differences
+var applicationType = reflect.TypeOf(argov1alpha1.Application{})
+
+// ParseJSONPointer returns the reference tokens of a JSON pointer (RFC 6901), e.g. ["spec", "syncPolicy"] for
+// '/spec/syncPolicy'. The elements of the lists of an Application cannot be matched between the live and generated
+// Applications, so pointers to the elements of a list are rejected.
+func ParseJSONPointer(pointer string) ([]string, error) {
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer '%s': must start with '/'", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = jsonPointerUnescaper.Replace(token)
+	}
+	if depth, isList := listInPath(applicationType, tokens); isList {
+		list := strings.Join(strings.Split(pointer[1:], "/")[:depth], "/")
+		return nil, fmt.Errorf("invalid JSON pointer '%s': '/%s' is a list, whose elements cannot be ignored individually: point to the whole list instead", pointer, list)
+	}
+	return tokens, nil
+}
+
+// listInPath returns the number of reference tokens leading to the first list the path goes through in the type, if
+// any. The fields that are not part of the type are not checked.
+func listInPath(t reflect.Type, tokens []string) (int, bool) {
+	for i, token := range tokens {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		switch t.Kind() {
+		case reflect.Slice, reflect.Array:
+			return i, true
+		case reflect.Map:
+			t = t.Elem()
+		case reflect.Struct:
+			field, found := jsonField(t, token)
+			if !found {
+				return 0, false
+			}
+			t = field.Type
+		default:
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+// jsonField returns the field of the struct type with the JSON name, including the fields of the inlined structs.
+func jsonField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("json"), ",")[0]
+		if tag == "-" {
+			continue
+		}
+		if tag == "" && field.Anonymous {
+			embedded := field.Type
+			for embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if res, found := jsonField(embedded, name); found {
+					return res, true
+				}
+			}
+			continue
+		}
+		if tag == "" {
+			tag = field.Name
+		}
+		if tag == name {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// ParseJQPathExpression compiles a jq path expression, e.g. '.spec.source.targetRevision', into a query returning the
+// paths it selects. The elements of the lists of an Application cannot be matched between the live and generated
+// Applications, so expressions indexing or slicing a list are rejected.
+func ParseJQPathExpression(expression string) (*gojq.Code, error) {
+	parsed, err := gojq.Parse(expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jq path expression '%s': %v", expression, err)
+	}
+	if index := listIndex(parsed); index != "" {
+		return nil, fmt.Errorf("invalid jq path expression '%s': '%s' indexes a list, whose elements cannot be ignored individually: select the whole list instead", expression, index)
+	}
+
+	query, err := gojq.Parse(fmt.Sprintf("path(%s)", expression))
+	if err != nil {
+		return nil, fmt.Errorf("invalid jq path expression '%s': %v", expression, err)
+	}
+	code, err := gojq.Compile(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jq path expression '%s': %v", expression, err)
+	}
+	return code, nil
+}
+
+// listIndex returns the first index or slice of a list in the path selected by the query, if any. The arguments of the
+// functions, e.g. the conditions of select, are not part of the path, so they are not checked.
+func listIndex(query *gojq.Query) string {
+	if query == nil {
+		return ""
+	}
+	if res := listIndex(query.Left); res != "" {
+		return res
+	}
+	if res := listIndex(query.Right); res != "" {
+		return res
+	}
+
+	term := query.Term
+	if term == nil {
+		return ""
+	}
+	if term.Index != nil && isListIndex(term.Index) {
+		return term.Index.String()
+	}
+	if term.Type == gojq.TermTypeQuery {
+		if res := listIndex(term.Query); res != "" {
+			return res
+		}
+	}
+	for _, suffix := range term.SuffixList {
+		if suffix.Index != nil && isListIndex(suffix.Index) {
+			return suffix.String()
+		}
+	}
+	return ""
+}
+
+// isListIndex returns whether the index is a number or a slice, which only apply to lists.
+func isListIndex(index *gojq.Index) bool {
+	if index.IsSlice {
+		return true
+	}
+	return index.Start != nil && index.Start.Term != nil && index.Start.Term.Type == gojq.TermTypeNumber
+}
+
+// IgnoreDifferences are the compiled ignored differences of an ApplicationSet. They are compiled once per
+// reconciliation, and applied to each of the generated Applications.
+type IgnoreDifferences []compiledIgnoreDifference
+
+// compiledIgnoreDifference is a compiled entry of the ignored differences of an ApplicationSet
+type compiledIgnoreDifference struct {
+	name              string
+	paths             [][]interface{}
+	jqPathExpressions []jqPathExpression
+}
+
+// jqPathExpression is a compiled jq path expression, along with its source for the error messages
+type jqPathExpression struct {
+	expression string
+	code       *gojq.Code
+}
+
+// CompileIgnoreDifferences parses the JSON pointers and compiles the jq path expressions of the ignored differences of
+// an ApplicationSet.
+func CompileIgnoreDifferences(ignoreDifferences []argoprojiov1alpha1.ApplicationSetIgnoreDifferences) (IgnoreDifferences, error) {
+	res := make(IgnoreDifferences, 0, len(ignoreDifferences))
+	for _, ignoreDifference := range ignoreDifferences {
+		compiled := compiledIgnoreDifference{name: ignoreDifference.Name}
+
+		for _, pointer := range ignoreDifference.JSONPointers {
+			tokens, err := ParseJSONPointer(pointer)
+			if err != nil {
+				return nil, err
+			}
+			path := make([]interface{}, 0, len(tokens))
+			for _, token := range tokens {
+				path = append(path, token)
+			}
+			compiled.paths = append(compiled.paths, path)
+		}
+
+		for _, expression := range ignoreDifference.JQPathExpressions {
+			code, err := ParseJQPathExpression(expression)
+			if err != nil {
+				return nil, err
+			}
+			compiled.jqPathExpressions = append(compiled.jqPathExpressions, jqPathExpression{expression: expression, code: code})
+		}
+
+		res = append(res, compiled)
+	}
+	return res, nil
+}
+
+// Apply sets the fields of the generated Application selected by the ignored differences to their values in the live
+// Application, or removes them from the generated Application if they are not set in the live one.
+func (d IgnoreDifferences) Apply(live *argov1alpha1.Application, generated *argov1alpha1.Application) error {
+	var relevant []compiledIgnoreDifference
+	for _, ignoreDifference := range d {
+		if ignoreDifference.name == "" || ignoreDifference.name == generated.Name {
+			relevant = append(relevant, ignoreDifference)
+		}
+	}
+	if len(relevant) == 0 {
+		return nil
+	}
+
+	liveObject, err := toJSONValue(live)
+	if err != nil {
+		return err
+	}
+	generatedObject, err := toJSONValue(generated)
+	if err != nil {
+		return err
+	}
+
+	var paths [][]interface{}
+	for _, ignoreDifference := range relevant {
+		paths = append(paths, ignoreDifference.paths...)
+
+		for _, jqPath := range ignoreDifference.jqPathExpressions {
+			// The fields that are only set in the generated Application are selected as well, to be removed
+			for _, object := range []interface{}{liveObject, generatedObject} {
+				selected, err := evaluateJQPaths(jqPath.code, object)
+				if err != nil {
+					return fmt.Errorf("failed to evaluate jq path expression '%s': %v", jqPath.expression, err)
+				}
+				for _, path := range selected {
+					if !isFieldPath(path) {
+						return fmt.Errorf("jq path expression '%s' selects an element of a list, which cannot be ignored individually: select the whole list instead", jqPath.expression)
+					}
+				}
+				paths = append(paths, selected...)
+			}
+		}
+	}
+
+	for _, path := range paths {
+		generatedObject = keepLiveValue(generatedObject, liveObject, path)
+	}
+
+	data, err := json.Marshal(generatedObject)
+	if err != nil {
+		return err
+	}
+	var res argov1alpha1.Application
+	if err := json.Unmarshal(data, &res); err != nil {
+		return fmt.Errorf("failed to keep the live values of Application '%s': %v", generated.Name, err)
+	}
+	*generated = res
+	return nil
+}
+
+// toJSONValue returns the Application as a JSON value, made of maps, lists and scalars.
+func toJSONValue(app *argov1alpha1.Application) (interface{}, error) {
+	data, err := json.Marshal(app)
+	if err != nil {
+		return nil, err
+	}
+	var res interface{}
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// evaluateJQPaths returns the paths selected by the compiled jq path expression in the object. Expressions that cannot
+// be evaluated against the object, e.g. because they iterate over a field that is not set, select no paths.
+func evaluateJQPaths(code *gojq.Code, object interface{}) ([][]interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), jqPathTimeout)
+	defer cancel()
+
+	var res [][]interface{}
+	iter := code.RunWithContext(ctx, object)
+	for {
+		value, ok := iter.Next()
+		if !ok {
+			break
+		}
+		if _, isError := value.(error); isError {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			break
+		}
+		if path, isPath := value.([]interface{}); isPath {
+			res = append(res, path)
+		}
+	}
+	return res, nil
+}
+
+// isFieldPath returns whether the path only selects the fields of objects, and none of the elements of a list.
+func isFieldPath(path []interface{}) bool {
+	for _, segment := range path {
+		if _, isKey := segment.(string); !isKey {
+			return false
+		}
+	}
+	return true
+}
+
+// keepLiveValue returns the generated value, with the value at the path set to the one in the live value, or removed
+// if the live value has none. The objects missing on the way in the generated value are created.
+func keepLiveValue(generated interface{}, live interface{}, path []interface{}) interface{} {
+	if len(path) == 0 {
+		return runtime.DeepCopyJSONValue(live)
+	}
+
+	if liveNode, isMap := live.(map[string]interface{}); isMap {
+		key, isKey := path[0].(string)
+		if liveChild, exists := liveNode[key]; isKey && exists {
+			generatedNode, isMap := generated.(map[string]interface{})
+			if !isMap {
+				generatedNode = map[string]interface{}{}
+			}
+			generatedNode[key] = keepLiveValue(generatedNode[key], liveChild, path[1:])
+			return generatedNode
+		}
+	}
+
+	return removeValue(generated, path)
+}
+
+// removeValue returns the value, without the value at the path.
+func removeValue(value interface{}, path []interface{}) interface{} {
+	node, isMap := value.(map[string]interface{})
+	if !isMap {
+		return value
+	}
+	key, isKey := path[0].(string)
+	if !isKey {
+		return value
+	}
+
+	child, exists := node[key]
+	if !exists {
+		return value
+	}
+	if len(path) == 1 {
+		delete(node, key)
+	} else {
+		node[key] = removeValue(child, path[1:])
+	}
+	return value
+}
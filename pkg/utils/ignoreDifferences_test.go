@@ -0,0 +1,212 @@
+package utils
+
+import (
+	"fmt"
+	"testing"
+
+	argov1alpha1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	argoprojiov1alpha1 "github.com/argoproj-labs/applicationset/api/v1alpha1"
+)
+
+func TestParseJSONPointer(t *testing.T) {
+	tokens, err := ParseJSONPointer("/metadata/annotations/example.com~1owner~0team")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"metadata", "annotations", "example.com/owner~team"}, tokens)
+
+	tokens, err = ParseJSONPointer("/spec/syncPolicy/syncOptions")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"spec", "syncPolicy", "syncOptions"}, tokens)
+
+	_, err = ParseJSONPointer("spec/syncPolicy")
+	assert.EqualError(t, err, "invalid JSON pointer 'spec/syncPolicy': must start with '/'")
+
+	_, err = ParseJSONPointer("/spec/source/helm/parameters/0/value")
+	assert.EqualError(t, err, "invalid JSON pointer '/spec/source/helm/parameters/0/value': '/spec/source/helm/parameters' is a list, whose elements cannot be ignored individually: point to the whole list instead")
+}
+
+func TestParseJQPathExpression(t *testing.T) {
+	for _, expression := range []string{
+		".spec.source.helm.parameters",
+		`.metadata.annotations["example.com/owner"]`,
+		`select(.spec.source.helm.parameters[0].name == "image.tag") | .spec.source.targetRevision`,
+	} {
+		_, err := ParseJQPathExpression(expression)
+		assert.NoError(t, err, expression)
+	}
+
+	for expression, index := range map[string]string{
+		".spec.syncPolicy.syncOptions[0]":     "[0]",
+		".spec.source.helm.parameters[1:]":    "[1:]",
+		".spec.source.helm.parameters | .[0]": ".[0]",
+	} {
+		_, err := ParseJQPathExpression(expression)
+		assert.EqualError(t, err, fmt.Sprintf("invalid jq path expression '%s': '%s' indexes a list, whose elements cannot be ignored individually: select the whole list instead", expression, index))
+	}
+}
+
+func TestIgnoreDifferencesApply(t *testing.T) {
+	liveApp := func() *argov1alpha1.Application {
+		return &argov1alpha1.Application{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "guestbook",
+				Annotations: map[string]string{"owner": "team-b"},
+			},
+			Spec: argov1alpha1.ApplicationSpec{
+				Source: argov1alpha1.ApplicationSource{
+					RepoURL:        "https://github.com/argoproj/argocd-example-apps",
+					Path:           "guestbook",
+					TargetRevision: "debug",
+					Helm: &argov1alpha1.ApplicationSourceHelm{
+						Parameters: []argov1alpha1.HelmParameter{{Name: "image.tag", Value: "debug"}},
+					},
+				},
+				Destination: argov1alpha1.ApplicationDestination{Server: "https://kubernetes.default.svc", Namespace: "guestbook"},
+			},
+		}
+	}
+	generatedApp := func() *argov1alpha1.Application {
+		return &argov1alpha1.Application{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "guestbook",
+				Annotations: map[string]string{"owner": "team-a"},
+			},
+			Spec: argov1alpha1.ApplicationSpec{
+				Source: argov1alpha1.ApplicationSource{
+					RepoURL:        "https://github.com/argoproj/argocd-example-apps",
+					Path:           "guestbook",
+					TargetRevision: "HEAD",
+					Helm: &argov1alpha1.ApplicationSourceHelm{
+						Parameters: []argov1alpha1.HelmParameter{
+							{Name: "replicas", Value: "2"},
+							{Name: "image.tag", Value: "v1.0.0"},
+						},
+					},
+				},
+				Destination: argov1alpha1.ApplicationDestination{Server: "https://kubernetes.default.svc", Namespace: "guestbook"},
+				SyncPolicy: &argov1alpha1.SyncPolicy{
+					Automated:   &argov1alpha1.SyncPolicyAutomated{Prune: true},
+					SyncOptions: argov1alpha1.SyncOptions{"CreateNamespace=true"},
+				},
+			},
+		}
+	}
+
+	for _, c := range []struct {
+		name              string
+		ignoreDifferences []argoprojiov1alpha1.ApplicationSetIgnoreDifferences
+		expected          func(app *argov1alpha1.Application)
+		expectedError     string
+	}{
+		{
+			name:              "no ignored differences",
+			ignoreDifferences: nil,
+			expected:          func(app *argov1alpha1.Application) {},
+		},
+		{
+			name: "JSON pointers",
+			ignoreDifferences: []argoprojiov1alpha1.ApplicationSetIgnoreDifferences{
+				{JSONPointers: []string{"/spec/source/targetRevision", "/metadata/annotations/owner"}},
+			},
+			expected: func(app *argov1alpha1.Application) {
+				app.Spec.Source.TargetRevision = "debug"
+				app.Annotations["owner"] = "team-b"
+			},
+		},
+		{
+			name: "fields that are not set in the live Application are removed",
+			ignoreDifferences: []argoprojiov1alpha1.ApplicationSetIgnoreDifferences{
+				{JSONPointers: []string{"/spec/syncPolicy/automated"}},
+			},
+			expected: func(app *argov1alpha1.Application) {
+				app.Spec.SyncPolicy.Automated = nil
+			},
+		},
+		{
+			name: "jq path expressions",
+			ignoreDifferences: []argoprojiov1alpha1.ApplicationSetIgnoreDifferences{
+				{JQPathExpressions: []string{".spec.source.targetRevision", ".spec.syncPolicy"}},
+			},
+			expected: func(app *argov1alpha1.Application) {
+				app.Spec.Source.TargetRevision = "debug"
+				app.Spec.SyncPolicy = nil
+			},
+		},
+		{
+			name: "whole lists",
+			ignoreDifferences: []argoprojiov1alpha1.ApplicationSetIgnoreDifferences{
+				{JSONPointers: []string{"/spec/source/helm/parameters"}, JQPathExpressions: []string{".spec.syncPolicy.syncOptions"}},
+			},
+			expected: func(app *argov1alpha1.Application) {
+				app.Spec.Source.Helm.Parameters = []argov1alpha1.HelmParameter{{Name: "image.tag", Value: "debug"}}
+				app.Spec.SyncPolicy.SyncOptions = nil
+			},
+		},
+		{
+			name: "jq path expressions iterating over objects",
+			ignoreDifferences: []argoprojiov1alpha1.ApplicationSetIgnoreDifferences{
+				{JQPathExpressions: []string{".metadata.annotations[]"}},
+			},
+			expected: func(app *argov1alpha1.Application) {
+				app.Annotations["owner"] = "team-b"
+			},
+		},
+		{
+			name: "jq path expressions selecting the elements of a list",
+			ignoreDifferences: []argoprojiov1alpha1.ApplicationSetIgnoreDifferences{
+				{JQPathExpressions: []string{`.spec.source.helm.parameters[] | select(.name == "image.tag") | .value`}},
+			},
+			expectedError: `jq path expression '.spec.source.helm.parameters[] | select(.name == "image.tag") | .value' selects an element of a list, which cannot be ignored individually: select the whole list instead`,
+		},
+		{
+			name: "jq path expressions indexing a list",
+			ignoreDifferences: []argoprojiov1alpha1.ApplicationSetIgnoreDifferences{
+				{JQPathExpressions: []string{".spec.syncPolicy.syncOptions[0]"}},
+			},
+			expectedError: "invalid jq path expression '.spec.syncPolicy.syncOptions[0]': '[0]' indexes a list, whose elements cannot be ignored individually: select the whole list instead",
+		},
+		{
+			name: "ignored differences of another Application",
+			ignoreDifferences: []argoprojiov1alpha1.ApplicationSetIgnoreDifferences{
+				{Name: "other", JSONPointers: []string{"/spec/source/targetRevision"}},
+				{Name: "guestbook", JSONPointers: []string{"/metadata/annotations"}},
+			},
+			expected: func(app *argov1alpha1.Application) {
+				app.Annotations["owner"] = "team-b"
+			},
+		},
+		{
+			name: "invalid jq path expression",
+			ignoreDifferences: []argoprojiov1alpha1.ApplicationSetIgnoreDifferences{
+				{JQPathExpressions: []string{".spec["}},
+			},
+			expectedError: `invalid jq path expression '.spec[': unexpected token ")"`,
+		},
+		{
+			name: "invalid JSON pointer",
+			ignoreDifferences: []argoprojiov1alpha1.ApplicationSetIgnoreDifferences{
+				{JSONPointers: []string{"spec/source"}},
+			},
+			expectedError: "invalid JSON pointer 'spec/source': must start with '/'",
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			generated := generatedApp()
+			ignoreDifferences, err := CompileIgnoreDifferences(c.ignoreDifferences)
+			if err == nil {
+				err = ignoreDifferences.Apply(liveApp(), generated)
+			}
+			if c.expectedError != "" {
+				assert.EqualError(t, err, c.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+
+			expected := generatedApp()
+			c.expected(expected)
+			assert.Equal(t, expected, generated)
+		})
+	}
+}
@@ -2,10 +2,12 @@ package utils
 
 import (
 	"fmt"
+	"reflect"
+	"strings"
 )
 
-func CombineStringMaps(a map[string]string, b map[string]string) (map[string]string, error) {
-	res := map[string]string{}
+func CombineMaps(a map[string]interface{}, b map[string]interface{}) (map[string]interface{}, error) {
+	res := map[string]interface{}{}
 
 	for k, v := range a {
 		res[k] = v
@@ -13,11 +15,32 @@ func CombineStringMaps(a map[string]string, b map[string]string) (map[string]str
 
 	for k, v := range b {
 		current, present := res[k]
-		if present && current != v {
-			return nil, fmt.Errorf("found duplicate key %s with different value, a: %s ,b: %s", k, current, v)
+		if present && !reflect.DeepEqual(current, v) {
+			return nil, fmt.Errorf("found duplicate key %s with different value, a: %v ,b: %v", k, current, v)
 		}
 		res[k] = v
 	}
 
 	return res, nil
 }
+
+// GetParamValue returns the value of the param with the given key. A key that contains dots may also address a value
+// nested in a structured param: 'values.env' is either the 'values.env' param, or the 'env' field of the 'values' param.
+func GetParamValue(params map[string]interface{}, key string) (interface{}, bool) {
+	if value, ok := params[key]; ok {
+		return value, true
+	}
+
+	segments := strings.Split(key, ".")
+	for i := len(segments) - 1; i > 0; i-- {
+		nested, ok := params[strings.Join(segments[:i], ".")].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if value, ok := GetParamValue(nested, strings.Join(segments[i:], ".")); ok {
+			return value, true
+		}
+	}
+
+	return nil, false
+}
@@ -7,33 +7,46 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-func TestCombineStringMaps(t *testing.T) {
+func TestCombineMaps(t *testing.T) {
 	testCases := []struct {
 		name        string
-		left        map[string]string
-		right       map[string]string
-		expected    map[string]string
+		left        map[string]interface{}
+		right       map[string]interface{}
+		expected    map[string]interface{}
 		expectedErr error
 	}{
 		{
 			name:        "combines the maps",
-			left:        map[string]string{"foo": "bar"},
-			right:       map[string]string{"a": "b"},
-			expected:    map[string]string{"a": "b", "foo": "bar"},
+			left:        map[string]interface{}{"foo": "bar"},
+			right:       map[string]interface{}{"a": "b"},
+			expected:    map[string]interface{}{"a": "b", "foo": "bar"},
 			expectedErr: nil,
 		},
 		{
 			name:        "fails if keys are the same but value isn't",
-			left:        map[string]string{"foo": "bar", "a": "fail"},
-			right:       map[string]string{"a": "b", "c": "d"},
-			expected:    map[string]string{"a": "b", "foo": "bar"},
+			left:        map[string]interface{}{"foo": "bar", "a": "fail"},
+			right:       map[string]interface{}{"a": "b", "c": "d"},
+			expected:    map[string]interface{}{"a": "b", "foo": "bar"},
 			expectedErr: errors.New("found duplicate key a with different value, a: fail ,b: b"),
 		},
+		{
+			name:        "fails if keys are the same but structured value isn't",
+			left:        map[string]interface{}{"foo": "bar", "a": []interface{}{"b", "c"}},
+			right:       map[string]interface{}{"a": []interface{}{"b"}},
+			expectedErr: errors.New("found duplicate key a with different value, a: [b c] ,b: [b]"),
+		},
+		{
+			name:        "pass if keys & structured values are the same",
+			left:        map[string]interface{}{"foo": "bar", "a": map[string]interface{}{"b": float64(1)}},
+			right:       map[string]interface{}{"a": map[string]interface{}{"b": float64(1)}},
+			expected:    map[string]interface{}{"a": map[string]interface{}{"b": float64(1)}, "foo": "bar"},
+			expectedErr: nil,
+		},
 		{
 			name:        "pass if keys & values are the same",
-			left:        map[string]string{"foo": "bar", "a": "b"},
-			right:       map[string]string{"a": "b", "c": "d"},
-			expected:    map[string]string{"a": "b", "c": "d", "foo": "bar"},
+			left:        map[string]interface{}{"foo": "bar", "a": "b"},
+			right:       map[string]interface{}{"a": "b", "c": "d"},
+			expected:    map[string]interface{}{"a": "b", "c": "d", "foo": "bar"},
 			expectedErr: nil,
 		},
 	}
@@ -42,7 +55,7 @@ func TestCombineStringMaps(t *testing.T) {
 		cc := c
 		t.Run(cc.name, func(t *testing.T) {
 
-			got, err := CombineStringMaps(cc.left, cc.right)
+			got, err := CombineMaps(cc.left, cc.right)
 
 			if cc.expectedErr != nil {
 				assert.EqualError(t, err, cc.expectedErr.Error())
@@ -54,3 +67,37 @@ func TestCombineStringMaps(t *testing.T) {
 		})
 	}
 }
+
+func TestGetParamValue(t *testing.T) {
+	params := map[string]interface{}{
+		"name":         "cluster",
+		"values.env":   "prod",
+		"values":       map[string]interface{}{"replicas": float64(3), "region": map[string]interface{}{"name": "eu"}},
+		"metadata.foo": map[string]interface{}{"bar": true},
+	}
+
+	testCases := []struct {
+		name     string
+		key      string
+		expected interface{}
+		found    bool
+	}{
+		{name: "top level param", key: "name", expected: "cluster", found: true},
+		{name: "flat param with dots", key: "values.env", expected: "prod", found: true},
+		{name: "nested param", key: "values.replicas", expected: float64(3), found: true},
+		{name: "deeply nested param", key: "values.region.name", expected: "eu", found: true},
+		{name: "nested param in a flat param with dots", key: "metadata.foo.bar", expected: true, found: true},
+		{name: "missing param", key: "other", expected: nil, found: false},
+		{name: "missing nested param", key: "values.other", expected: nil, found: false},
+		{name: "not a map", key: "name.other", expected: nil, found: false},
+	}
+
+	for _, c := range testCases {
+		cc := c
+		t.Run(cc.name, func(t *testing.T) {
+			got, found := GetParamValue(params, cc.key)
+			assert.Equal(t, cc.found, found)
+			assert.Equal(t, cc.expected, got)
+		})
+	}
+}
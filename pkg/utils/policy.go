@@ -1,5 +1,7 @@
 package utils
 
+import "fmt"
+
 // Policy allows to apply different rules to a set of changes.
 type Policy interface {
 	Update() bool
@@ -42,3 +44,32 @@ func (p *CreateOnlyPolicy) Update() bool {
 func (p *CreateOnlyPolicy) Delete() bool {
 	return false
 }
+
+// boundedPolicy only allows the changes that are allowed by both its policy and its upper bound.
+type boundedPolicy struct {
+	policy     Policy
+	upperBound Policy
+}
+
+func (p *boundedPolicy) Update() bool {
+	return p.policy.Update() && p.upperBound.Update()
+}
+
+func (p *boundedPolicy) Delete() bool {
+	return p.policy.Delete() && p.upperBound.Delete()
+}
+
+// GetBoundedPolicy returns the policy registered with the given name, restricted to the changes allowed by upperBound.
+// If name is empty, upperBound is returned.
+func GetBoundedPolicy(name string, upperBound Policy) (Policy, error) {
+	if name == "" {
+		return upperBound, nil
+	}
+
+	policy, exists := Policies[name]
+	if !exists {
+		return nil, fmt.Errorf("unknown policy %q, policy value can be: sync, create-only, create-update", name)
+	}
+
+	return &boundedPolicy{policy: policy, upperBound: upperBound}, nil
+}
@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetBoundedPolicy(t *testing.T) {
+	testCases := []struct {
+		name           string
+		policy         string
+		upperBound     Policy
+		expectedUpdate bool
+		expectedDelete bool
+		expectedErr    string
+	}{
+		{
+			name:           "defaults to the upper bound",
+			policy:         "",
+			upperBound:     &CreateUpdatePolicy{},
+			expectedUpdate: true,
+			expectedDelete: false,
+		},
+		{
+			name:           "restricts the upper bound",
+			policy:         "create-only",
+			upperBound:     &SyncPolicy{},
+			expectedUpdate: false,
+			expectedDelete: false,
+		},
+		{
+			name:           "same as the upper bound",
+			policy:         "create-update",
+			upperBound:     &CreateUpdatePolicy{},
+			expectedUpdate: true,
+			expectedDelete: false,
+		},
+		{
+			name:           "cannot exceed the upper bound",
+			policy:         "sync",
+			upperBound:     &CreateOnlyPolicy{},
+			expectedUpdate: false,
+			expectedDelete: false,
+		},
+		{
+			name:           "sync within sync",
+			policy:         "sync",
+			upperBound:     &SyncPolicy{},
+			expectedUpdate: true,
+			expectedDelete: true,
+		},
+		{
+			name:        "unknown policy",
+			policy:      "delete-only",
+			upperBound:  &SyncPolicy{},
+			expectedErr: `unknown policy "delete-only", policy value can be: sync, create-only, create-update`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			policy, err := GetBoundedPolicy(testCase.policy, testCase.upperBound)
+
+			if testCase.expectedErr != "" {
+				assert.EqualError(t, err, testCase.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.expectedUpdate, policy.Update())
+				assert.Equal(t, testCase.expectedDelete, policy.Delete())
+			}
+		})
+	}
+}
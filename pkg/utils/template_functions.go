@@ -0,0 +1,209 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// templateFuncs are the functions available to ApplicationSet templates rendered with goTemplate. They are a subset
+// of the Sprig functions (https://masterminds.github.io/sprig/), with the same names and argument order, limited to
+// functions that have no side effects and that don't access the environment, the file system or the network.
+var templateFuncs = template.FuncMap{
+	// Strings
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+	"title":      title,
+	"trim":       strings.TrimSpace,
+	"trimAll":    func(cutset, s string) string { return strings.Trim(s, cutset) },
+	"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+	"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+	"replace":    func(oldStr, newStr, s string) string { return strings.ReplaceAll(s, oldStr, newStr) },
+	"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+	"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+	"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+	"trunc":      trunc,
+	"quote":      quote,
+	"squote":     squote,
+	"join":       join,
+	"splitList":  func(sep, s string) []string { return strings.Split(s, sep) },
+
+	// Regular expressions
+	"regexMatch":      regexMatch,
+	"regexReplaceAll": regexReplaceAll,
+
+	// Defaults and conditionals
+	"default":  defaultValue,
+	"empty":    empty,
+	"coalesce": coalesce,
+	"ternary":  ternary,
+
+	// Encoding
+	"toJson":    toJSON,
+	"b64enc":    func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+	"b64dec":    b64dec,
+	"sha256sum": sha256sum,
+}
+
+// title converts the first letter of each word to title case, leaving the other letters as they are.
+func title(s string) string {
+	// A Caser is stateful, so it cannot be shared between templates rendered concurrently
+	return cases.Title(language.Und, cases.NoLower).String(s)
+}
+
+// trunc truncates a string to the given number of characters. A negative length keeps the end of the string instead.
+func trunc(length int, s string) string {
+	runes := []rune(s)
+	if length < 0 && len(runes)+length > 0 {
+		return string(runes[len(runes)+length:])
+	}
+	if length >= 0 && len(runes) > length {
+		return string(runes[:length])
+	}
+	return s
+}
+
+func quote(values ...interface{}) string {
+	res := make([]string, 0, len(values))
+	for _, value := range values {
+		if value != nil {
+			res = append(res, fmt.Sprintf("%q", toString(value)))
+		}
+	}
+	return strings.Join(res, " ")
+}
+
+func squote(values ...interface{}) string {
+	res := make([]string, 0, len(values))
+	for _, value := range values {
+		if value != nil {
+			res = append(res, fmt.Sprintf("'%v'", value))
+		}
+	}
+	return strings.Join(res, " ")
+}
+
+func join(sep string, values interface{}) string {
+	return strings.Join(toStrings(values), sep)
+}
+
+func regexMatch(regex, s string) (bool, error) {
+	return regexp.MatchString(regex, s)
+}
+
+func regexReplaceAll(regex, s, repl string) (string, error) {
+	r, err := regexp.Compile(regex)
+	if err != nil {
+		return "", err
+	}
+	return r.ReplaceAllString(s, repl), nil
+}
+
+// defaultValue returns the given value, or the default value if the given value is empty.
+func defaultValue(d interface{}, given ...interface{}) interface{} {
+	if len(given) == 0 || empty(given[0]) {
+		return d
+	}
+	return given[0]
+}
+
+// empty returns true if the value is nil, or the zero value of its type.
+func empty(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	default:
+		return v.IsZero()
+	}
+}
+
+// coalesce returns the first non-empty value.
+func coalesce(values ...interface{}) interface{} {
+	for _, value := range values {
+		if !empty(value) {
+			return value
+		}
+	}
+	return nil
+}
+
+func ternary(trueValue, falseValue interface{}, condition bool) interface{} {
+	if condition {
+		return trueValue
+	}
+	return falseValue
+}
+
+func toJSON(value interface{}) (string, error) {
+	res, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(res), nil
+}
+
+func b64dec(s string) (string, error) {
+	res, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", err
+	}
+	return string(res), nil
+}
+
+func sha256sum(s string) string {
+	hash := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(hash[:])
+}
+
+func toString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+func toStrings(values interface{}) []string {
+	if values == nil {
+		return []string{}
+	}
+	switch v := values.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		res := make([]string, 0, len(v))
+		for _, value := range v {
+			res = append(res, toString(value))
+		}
+		return res
+	}
+	v := reflect.ValueOf(values)
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		res := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			res = append(res, toString(v.Index(i).Interface()))
+		}
+		return res
+	}
+	return []string{toString(values)}
+}
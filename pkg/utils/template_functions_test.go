@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+
+	for _, c := range []struct {
+		name     string
+		template string
+		data     interface{}
+		expected string
+	}{
+		{name: "upper", template: `{{ upper "abc" }}`, expected: "ABC"},
+		{name: "lower", template: `{{ "ABC" | lower }}`, expected: "abc"},
+		{name: "trim", template: `{{ trim "  abc  " }}`, expected: "abc"},
+		{name: "trimAll", template: `{{ trimAll "$" "$5.00$" }}`, expected: "5.00"},
+		{name: "trimPrefix", template: `{{ trimPrefix "refs/heads/" "refs/heads/main" }}`, expected: "main"},
+		{name: "trimSuffix", template: `{{ trimSuffix ".git" "repo.git" }}`, expected: "repo"},
+		{name: "replace", template: `{{ "feature/my-branch" | replace "/" "-" }}`, expected: "feature-my-branch"},
+		{name: "trunc", template: `{{ trunc 3 "abcdef" }}`, expected: "abc"},
+		{name: "trunc negative", template: `{{ trunc -3 "abcdef" }}`, expected: "def"},
+		{name: "trunc longer than string", template: `{{ trunc 63 "abc" }}`, expected: "abc"},
+		{name: "trunc multi-byte characters", template: `{{ trunc 4 "Zürich" }}`, expected: "Züri"},
+		{name: "trunc negative multi-byte characters", template: `{{ trunc -3 "東京都港区" }}`, expected: "都港区"},
+		{name: "title", template: `{{ title "hello wORLD" }}`, expected: "Hello WORLD"},
+		{name: "title unicode words", template: `{{ title "élan vital-ëlan" }}`, expected: "Élan Vital-Ëlan"},
+		{name: "contains", template: `{{ contains "b" "abc" }}`, expected: "true"},
+		{name: "quote", template: `{{ quote "a\"b" }}`, expected: `"a\"b"`},
+		{name: "squote", template: `{{ squote "ab" }}`, expected: `'ab'`},
+		{name: "join", template: `{{ splitList "," "a,b,c" | join "-" }}`, expected: "a-b-c"},
+		{name: "regexReplaceAll", template: `{{ regexReplaceAll "[^a-z0-9-]" "Feature_1" "-" }}`, expected: "-eature-1"},
+		{name: "regexMatch", template: `{{ regexMatch "^v[0-9]+$" "v12" }}`, expected: "true"},
+		{name: "default with empty value", template: `{{ default "foo" .value }}`, data: map[string]interface{}{"value": ""}, expected: "foo"},
+		{name: "default with value", template: `{{ default "foo" .value }}`, data: map[string]interface{}{"value": "bar"}, expected: "bar"},
+		{name: "default with missing value", template: `{{ default "foo" .value }}`, data: map[string]interface{}{}, expected: "foo"},
+		{name: "empty", template: `{{ empty .value }}`, data: map[string]interface{}{"value": ""}, expected: "true"},
+		{name: "coalesce", template: `{{ coalesce .a .b "c" }}`, data: map[string]interface{}{"a": "", "b": "b"}, expected: "b"},
+		{name: "ternary", template: `{{ ternary "yes" "no" true }}`, expected: "yes"},
+		{name: "toJson", template: `{{ toJson .value }}`, data: map[string]interface{}{"value": map[string]interface{}{"a": "b"}}, expected: `{"a":"b"}`},
+		{name: "b64enc", template: `{{ b64enc "abc" }}`, expected: "YWJj"},
+		{name: "b64dec", template: `{{ b64dec "YWJj" }}`, expected: "abc"},
+		{name: "sha256sum", template: `{{ sha256sum "abc" }}`, expected: "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+	} {
+
+		t.Run(c.name, func(t *testing.T) {
+			tmpl, err := template.New("").Funcs(templateFuncs).Parse(c.template)
+			assert.NoError(t, err)
+
+			var res strings.Builder
+			err = tmpl.Execute(&res, c.data)
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, res.String())
+		})
+	}
+}
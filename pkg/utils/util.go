@@ -8,22 +8,26 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"text/template"
 
 	argoprojiov1alpha1 "github.com/argoproj-labs/applicationset/api/v1alpha1"
 	argov1alpha1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/valyala/fasttemplate"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"sigs.k8s.io/yaml"
 )
 
 type Renderer interface {
-	RenderTemplateParams(tmpl *argov1alpha1.Application, syncPolicy *argoprojiov1alpha1.ApplicationSetSyncPolicy, params map[string]string) (*argov1alpha1.Application, error)
+	RenderTemplateParams(tmpl *argov1alpha1.Application, syncPolicy *argoprojiov1alpha1.ApplicationSetSyncPolicy, params map[string]interface{}, useGoTemplate bool, goTemplateOptions []string) (*argov1alpha1.Application, error)
+	RenderTemplatePatch(app *argov1alpha1.Application, templatePatch string, params map[string]interface{}, useGoTemplate bool, goTemplateOptions []string) (*argov1alpha1.Application, error)
 }
 
 type Render struct {
 }
 
-func (r *Render) RenderTemplateParams(tmpl *argov1alpha1.Application, syncPolicy *argoprojiov1alpha1.ApplicationSetSyncPolicy, params map[string]string) (*argov1alpha1.Application, error) {
+func (r *Render) RenderTemplateParams(tmpl *argov1alpha1.Application, syncPolicy *argoprojiov1alpha1.ApplicationSetSyncPolicy, params map[string]interface{}, useGoTemplate bool, goTemplateOptions []string) (*argov1alpha1.Application, error) {
 	if tmpl == nil {
 		return nil, fmt.Errorf("application template is empty ")
 	}
@@ -37,8 +41,13 @@ func (r *Render) RenderTemplateParams(tmpl *argov1alpha1.Application, syncPolicy
 		return nil, err
 	}
 
-	fstTmpl := fasttemplate.New(string(tmplBytes), "{{", "}}")
-	replacedTmplStr, err := r.replace(fstTmpl, params, true)
+	var replacedTmplStr string
+	if useGoTemplate {
+		replacedTmplStr, err = r.renderGoTemplate(tmplBytes, params, goTemplateOptions)
+	} else {
+		fstTmpl := fasttemplate.New(string(tmplBytes), "{{", "}}")
+		replacedTmplStr, err = r.replace(fstTmpl, flattenParams(params), true)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -63,6 +72,99 @@ func (r *Render) RenderTemplateParams(tmpl *argov1alpha1.Application, syncPolicy
 	return &replacedTmpl, nil
 }
 
+// RenderTemplatePatch renders the templatePatch of an ApplicationSet with the params, and applies it to the Application
+// as a strategic merge patch. Unlike the template, the patch is free-form YAML or JSON, so params can be rendered into
+// fields that are not strings, such as 'spec.syncPolicy.automated.prune'.
+func (r *Render) RenderTemplatePatch(app *argov1alpha1.Application, templatePatch string, params map[string]interface{}, useGoTemplate bool, goTemplateOptions []string) (*argov1alpha1.Application, error) {
+	if app == nil {
+		return nil, fmt.Errorf("application is empty")
+	}
+
+	var renderedPatch string
+	var err error
+	if useGoTemplate {
+		if err := validateGoTemplateOptions(goTemplateOptions); err != nil {
+			return nil, err
+		}
+		renderedPatch, err = renderGoTemplateString(templatePatch, paramsToGoTemplateData(params), goTemplateOptions)
+	} else {
+		fstTmpl := fasttemplate.New(templatePatch, "{{", "}}")
+		renderedPatch, err = r.replace(fstTmpl, flattenParams(params), true)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The patch may render to nothing, e.g. when it is wrapped in a condition
+	if strings.TrimSpace(renderedPatch) == "" {
+		return app, nil
+	}
+
+	patchBytes, err := yaml.YAMLToJSON([]byte(renderedPatch))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the rendered templatePatch")
+	}
+
+	appBytes, err := json.Marshal(app)
+	if err != nil {
+		return nil, err
+	}
+
+	patchedBytes, err := strategicpatch.StrategicMergePatch(appBytes, patchBytes, argov1alpha1.Application{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to apply the rendered templatePatch")
+	}
+
+	var patchedApp argov1alpha1.Application
+	err = json.Unmarshal(patchedBytes, &patchedApp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &patchedApp, nil
+}
+
+// flattenParams converts the params into the string values substituted by replace. Structured params are flattened
+// into dot separated keys, e.g. '{"values": {"env": "prod"}}' is available as '{{values.env}}', and the elements of
+// lists by their index, e.g. '{{regions.0}}'.
+func flattenParams(params map[string]interface{}) map[string]string {
+	res := make(map[string]string, len(params))
+	for key, value := range params {
+		flattenParam(res, key, value)
+	}
+	return res
+}
+
+func flattenParam(res map[string]string, key string, value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, nested := range v {
+			flattenParam(res, key+"."+k, nested)
+		}
+	case []interface{}:
+		for i, nested := range v {
+			flattenParam(res, key+"."+strconv.Itoa(i), nested)
+		}
+	default:
+		res[key] = paramToString(v)
+	}
+}
+
+// paramToString formats a scalar param value. Numbers are formatted without exponent, as they are decoded from JSON
+// and YAML as float64.
+func paramToString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
 // Replace executes basic string substitution of a template with replacement values.
 // 'allowUnresolved' indicates whether or not it is acceptable to have unresolved variables
 // remaining in the substituted template.
@@ -94,6 +196,159 @@ func (r *Render) replace(fstTmpl *fasttemplate.Template, replaceMap map[string]s
 	return replacedTmpl, nil
 }
 
+// supportedGoTemplateOptions are the text/template options that may be set with goTemplateOptions.
+var supportedGoTemplateOptions = map[string]bool{
+	"missingkey=default": true,
+	"missingkey=invalid": true,
+	"missingkey=zero":    true,
+	"missingkey=error":   true,
+}
+
+// renderGoTemplate renders every string of the JSON encoded template as a Go text/template, with the params as data.
+// The strings are rendered one by one and the result is encoded again, so that the rendered values are escaped in
+// the same way as the values substituted by replace.
+func (r *Render) renderGoTemplate(tmplBytes []byte, params map[string]interface{}, goTemplateOptions []string) (string, error) {
+	if err := validateGoTemplateOptions(goTemplateOptions); err != nil {
+		return "", err
+	}
+
+	var tmplValue interface{}
+	if err := json.Unmarshal(tmplBytes, &tmplValue); err != nil {
+		return "", err
+	}
+
+	rendered, err := renderGoTemplateValue(tmplValue, paramsToGoTemplateData(params), goTemplateOptions)
+	if err != nil {
+		return "", err
+	}
+
+	renderedBytes, err := json.Marshal(rendered)
+	if err != nil {
+		return "", err
+	}
+
+	return string(renderedBytes), nil
+}
+
+func validateGoTemplateOptions(goTemplateOptions []string) error {
+	for _, option := range goTemplateOptions {
+		if !supportedGoTemplateOptions[option] {
+			return errors.Errorf("unsupported goTemplateOptions value %q", option)
+		}
+	}
+	return nil
+}
+
+// renderGoTemplateValue walks a decoded JSON value, and renders the map keys and strings it contains.
+func renderGoTemplateValue(value interface{}, data map[string]interface{}, goTemplateOptions []string) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for key, fieldValue := range v {
+			renderedKey, err := renderGoTemplateString(key, data, goTemplateOptions)
+			if err != nil {
+				return nil, err
+			}
+			res[renderedKey], err = renderGoTemplateValue(fieldValue, data, goTemplateOptions)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return res, nil
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i, item := range v {
+			var err error
+			res[i], err = renderGoTemplateValue(item, data, goTemplateOptions)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return res, nil
+	case string:
+		return renderGoTemplateString(v, data, goTemplateOptions)
+	default:
+		return v, nil
+	}
+}
+
+func renderGoTemplateString(str string, data map[string]interface{}, goTemplateOptions []string) (string, error) {
+	// Most strings of the template contain no actions, there is no need to parse them
+	if !strings.Contains(str, "{{") {
+		return str, nil
+	}
+
+	tmpl, err := template.New("").Funcs(templateFuncs).Option(goTemplateOptions...).Parse(str)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse template %s", str)
+	}
+
+	var replaced strings.Builder
+	if err := tmpl.Execute(&replaced, data); err != nil {
+		return "", errors.Wrapf(err, "failed to execute template %s", str)
+	}
+
+	return replaced.String(), nil
+}
+
+// paramsToGoTemplateData converts the dot separated param keys (e.g. 'values.env') into nested maps, so that they
+// can be accessed as fields from Go templates (e.g. '{{ .values.env }}'). Structured params are kept as they are, and
+// merged with the params whose keys they prefix. When a key is both a param and the prefix of other params, such as the
+// 'path' and 'path.basename' params of the Git generator, its value is available under the last segment of the key
+// (e.g. '{{ .path.path }}').
+func paramsToGoTemplateData(params map[string]interface{}) map[string]interface{} {
+	data := map[string]interface{}{}
+
+	for key, value := range params {
+		segments := strings.Split(key, ".")
+
+		current := data
+		for _, segment := range segments[:len(segments)-1] {
+			var nested map[string]interface{}
+			switch next := current[segment].(type) {
+			case map[string]interface{}:
+				// The map may belong to the params, copy it rather than modifying it
+				nested = copyParamMap(next)
+			case nil:
+				nested = map[string]interface{}{}
+			default:
+				nested = map[string]interface{}{segment: next}
+			}
+			current[segment] = nested
+			current = nested
+		}
+
+		last := segments[len(segments)-1]
+		if nested, ok := current[last].(map[string]interface{}); ok {
+			if valueMap, ok := value.(map[string]interface{}); ok {
+				nested = copyParamMap(nested)
+				for k, v := range valueMap {
+					if _, exists := nested[k]; !exists {
+						nested[k] = v
+					}
+				}
+				current[last] = nested
+			} else if _, exists := nested[last]; !exists {
+				nested = copyParamMap(nested)
+				nested[last] = value
+				current[last] = nested
+			}
+		} else {
+			current[last] = value
+		}
+	}
+
+	return data
+}
+
+func copyParamMap(m map[string]interface{}) map[string]interface{} {
+	res := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		res[k] = v
+	}
+	return res
+}
+
 // Log a warning if there are unrecognized generators
 func CheckInvalidGenerators(applicationSetInfo *argoprojiov1alpha1.ApplicationSet) {
 	hasInvalidGenerators, invalidGenerators := invalidGenerators(applicationSetInfo)
@@ -113,6 +368,24 @@ func CheckInvalidGenerators(applicationSetInfo *argoprojiov1alpha1.ApplicationSe
 	}
 }
 
+// GeneratorNames returns the JSON names of the generators set in a generator entry, e.g. ["clusters", "list"] for an
+// ApplicationSetGenerator or an ApplicationSetBaseGenerator.
+func GeneratorNames(generator interface{}) []string {
+	var names []string
+
+	v := reflect.Indirect(reflect.ValueOf(generator))
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		if f.Kind() != reflect.Ptr || f.IsNil() {
+			continue
+		}
+		names = append(names, strings.Split(v.Type().Field(i).Tag.Get("json"), ",")[0])
+	}
+
+	sort.Strings(names)
+	return names
+}
+
 // Return true if there are unknown generators specified in the application set.  If we can discover the names
 // of these generators, return the names as the keys in a map
 func invalidGenerators(applicationSetInfo *argoprojiov1alpha1.ApplicationSet) (bool, map[string]bool) {
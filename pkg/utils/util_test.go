@@ -47,14 +47,14 @@ func TestRenderTemplateParams(t *testing.T) {
 	tests := []struct {
 		name        string
 		fieldVal    string
-		params      map[string]string
+		params      map[string]interface{}
 		expectedVal string
 	}{
 		{
 			name:        "simple substitution",
 			fieldVal:    "{{one}}",
 			expectedVal: "two",
-			params: map[string]string{
+			params: map[string]interface{}{
 				"one": "two",
 			},
 		},
@@ -62,7 +62,7 @@ func TestRenderTemplateParams(t *testing.T) {
 			name:        "simple substitution with whitespace",
 			fieldVal:    "{{ one }}",
 			expectedVal: "two",
-			params: map[string]string{
+			params: map[string]interface{}{
 				"one": "two",
 			},
 		},
@@ -71,7 +71,7 @@ func TestRenderTemplateParams(t *testing.T) {
 			name:        "template characters but not in a template",
 			fieldVal:    "}} {{",
 			expectedVal: "}} {{",
-			params: map[string]string{
+			params: map[string]interface{}{
 				"one": "two",
 			},
 		},
@@ -80,7 +80,7 @@ func TestRenderTemplateParams(t *testing.T) {
 			name:        "nested template",
 			fieldVal:    "{{ }}",
 			expectedVal: "{{ }}",
-			params: map[string]string{
+			params: map[string]interface{}{
 				"one": "{{ }}",
 			},
 		},
@@ -88,7 +88,7 @@ func TestRenderTemplateParams(t *testing.T) {
 			name:        "field with whitespace",
 			fieldVal:    "{{ }}",
 			expectedVal: "{{ }}",
-			params: map[string]string{
+			params: map[string]interface{}{
 				" ": "two",
 				"":  "three",
 			},
@@ -98,7 +98,7 @@ func TestRenderTemplateParams(t *testing.T) {
 			name:        "template contains itself, containing itself",
 			fieldVal:    "{{one}}",
 			expectedVal: "{{one}}",
-			params: map[string]string{
+			params: map[string]interface{}{
 				"{{one}}": "{{one}}",
 			},
 		},
@@ -107,7 +107,7 @@ func TestRenderTemplateParams(t *testing.T) {
 			name:        "template contains itself, containing something else",
 			fieldVal:    "{{one}}",
 			expectedVal: "{{one}}",
-			params: map[string]string{
+			params: map[string]interface{}{
 				"{{one}}": "{{two}}",
 			},
 		},
@@ -116,7 +116,7 @@ func TestRenderTemplateParams(t *testing.T) {
 			name:        "templates are case sensitive",
 			fieldVal:    "{{ONE}}",
 			expectedVal: "{{ONE}}",
-			params: map[string]string{
+			params: map[string]interface{}{
 				"{{one}}": "two",
 			},
 		},
@@ -124,7 +124,7 @@ func TestRenderTemplateParams(t *testing.T) {
 			name:        "multiple on a line",
 			fieldVal:    "{{one}}{{one}}",
 			expectedVal: "twotwo",
-			params: map[string]string{
+			params: map[string]interface{}{
 				"one": "two",
 			},
 		},
@@ -132,11 +132,33 @@ func TestRenderTemplateParams(t *testing.T) {
 			name:        "multiple different on a line",
 			fieldVal:    "{{one}}{{three}}",
 			expectedVal: "twofour",
-			params: map[string]string{
+			params: map[string]interface{}{
 				"one":   "two",
 				"three": "four",
 			},
 		},
+		{
+			name:        "structured params are flattened",
+			fieldVal:    "{{cluster.name}}-{{cluster.regions.1}}",
+			expectedVal: "prod-eu",
+			params: map[string]interface{}{
+				"cluster": map[string]interface{}{
+					"name":    "prod",
+					"regions": []interface{}{"us", "eu"},
+				},
+			},
+		},
+		{
+			name:        "numbers and booleans",
+			fieldVal:    "{{replicas}}-{{big}}-{{ratio}}-{{prune}}",
+			expectedVal: "3-1000000-0.5-true",
+			params: map[string]interface{}{
+				"replicas": float64(3),
+				"big":      float64(1000000),
+				"ratio":    0.5,
+				"prune":    true,
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -153,7 +175,7 @@ func TestRenderTemplateParams(t *testing.T) {
 
 				// Render the cloned application, into a new application
 				render := Render{}
-				newApplication, err := render.RenderTemplateParams(application, nil, test.params)
+				newApplication, err := render.RenderTemplateParams(application, nil, test.params, false, nil)
 
 				// Retrieve the value of the target field from the newApplication, then verify that
 				// the target field has been templated into the expected value
@@ -254,14 +276,14 @@ func TestRenderTemplateParamsFinalizers(t *testing.T) {
 			application := emptyApplication.DeepCopy()
 			application.Finalizers = c.existingFinalizers
 
-			params := map[string]string{
+			params := map[string]interface{}{
 				"one": "two",
 			}
 
 			// Render the cloned application, into a new application
 			render := Render{}
 
-			res, err := render.RenderTemplateParams(application, c.syncPolicy, params)
+			res, err := render.RenderTemplateParams(application, c.syncPolicy, params, false, nil)
 			assert.Nil(t, err)
 
 			assert.ElementsMatch(t, res.Finalizers, c.expectedFinalizers)
@@ -272,6 +294,228 @@ func TestRenderTemplateParamsFinalizers(t *testing.T) {
 
 }
 
+func TestRenderTemplateParamsGoTemplate(t *testing.T) {
+
+	for _, c := range []struct {
+		name              string
+		fieldVal          string
+		params            map[string]interface{}
+		goTemplateOptions []string
+		expectedVal       string
+		errorMessage      string
+	}{
+		{
+			name:        "simple substitution",
+			fieldVal:    "{{ .one }}",
+			expectedVal: "two",
+			params: map[string]interface{}{
+				"one": "two",
+			},
+		},
+		{
+			name:        "nested params with functions",
+			fieldVal:    "{{ .values.env | upper }}-{{ .cluster.name | trunc 3 }}",
+			expectedVal: "PROD-eng",
+			params: map[string]interface{}{
+				"values.env":   "prod",
+				"cluster.name": "engineering",
+			},
+		},
+		{
+			name:        "param that is also a prefix of other params",
+			fieldVal:    "{{ .path.path }}/{{ .path.basename }}",
+			expectedVal: "apps/guestbook/guestbook",
+			params: map[string]interface{}{
+				"path":          "apps/guestbook",
+				"path.basename": "guestbook",
+			},
+		},
+		{
+			name:        "conditionals and defaults",
+			fieldVal:    `{{ if eq .env "prod" }}production{{ else }}{{ default "staging" .other }}{{ end }}`,
+			expectedVal: "staging",
+			params: map[string]interface{}{
+				"env": "dev",
+			},
+		},
+		{
+			name:        "special characters are escaped",
+			fieldVal:    "{{ .value }}",
+			expectedVal: "a \"quoted\"\n\tvalue\\",
+			params: map[string]interface{}{
+				"value": "a \"quoted\"\n\tvalue\\",
+			},
+		},
+		{
+			name:        "structured params",
+			fieldVal:    `{{ .values.replicas }}-{{ range $i, $r := .regions }}{{ if $i }},{{ end }}{{ $r }}{{ end }}-{{ .cluster.name }}`,
+			expectedVal: "3-us,eu-prod",
+			params: map[string]interface{}{
+				"values.replicas": float64(3),
+				"regions":         []interface{}{"us", "eu"},
+				"cluster":         map[string]interface{}{"name": "prod"},
+			},
+		},
+		{
+			name:        "structured params merged with dotted params",
+			fieldVal:    "{{ .cluster.name }}-{{ .cluster.env }}",
+			expectedVal: "prod-staging",
+			params: map[string]interface{}{
+				"cluster":     map[string]interface{}{"name": "prod"},
+				"cluster.env": "staging",
+			},
+		},
+		{
+			name:        "missing key is rendered with the default options",
+			fieldVal:    "{{ .missing }}",
+			expectedVal: "<no value>",
+			params: map[string]interface{}{
+				"one": "two",
+			},
+		},
+		{
+			name:              "missing key is an error with missingkey=error",
+			fieldVal:          "{{ .missing }}",
+			goTemplateOptions: []string{"missingkey=error"},
+			params: map[string]interface{}{
+				"one": "two",
+			},
+			errorMessage: `failed to execute template {{ .missing }}: template: :1:3: executing "" at <.missing>: map has no entry for key "missing"`,
+		},
+		{
+			name:              "unsupported option",
+			fieldVal:          "{{ .one }}",
+			goTemplateOptions: []string{"missingkey=panic"},
+			params: map[string]interface{}{
+				"one": "two",
+			},
+			errorMessage: `unsupported goTemplateOptions value "missingkey=panic"`,
+		},
+		{
+			name:     "invalid template",
+			fieldVal: "{{ .one ",
+			params: map[string]interface{}{
+				"one": "two",
+			},
+			errorMessage: "failed to parse template {{ .one : template: :1: unclosed action",
+		},
+	} {
+
+		t.Run(c.name, func(t *testing.T) {
+
+			application := &argov1alpha1.Application{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "{{ .one }}",
+					Labels: map[string]string{"label": c.fieldVal},
+				},
+				Spec: argov1alpha1.ApplicationSpec{
+					Source: argov1alpha1.ApplicationSource{
+						Path: c.fieldVal,
+					},
+				},
+			}
+
+			render := Render{}
+			newApplication, err := render.RenderTemplateParams(application, nil, c.params, true, c.goTemplateOptions)
+
+			if c.errorMessage != "" {
+				assert.EqualError(t, err, c.errorMessage)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, c.expectedVal, newApplication.Spec.Source.Path)
+				assert.Equal(t, c.expectedVal, newApplication.ObjectMeta.Labels["label"])
+			}
+		})
+	}
+}
+
+func TestRenderTemplatePatch(t *testing.T) {
+
+	for _, c := range []struct {
+		name              string
+		templatePatch     string
+		params            map[string]interface{}
+		useGoTemplate     bool
+		goTemplateOptions []string
+		expectedPrune     bool
+		expectedPath      string
+		errorMessage      string
+	}{
+		{
+			name: "renders params into a boolean field",
+			templatePatch: `
+spec:
+  syncPolicy:
+    automated:
+      prune: {{prune}}
+`,
+			params:        map[string]interface{}{"prune": true},
+			expectedPrune: true,
+			expectedPath:  "path",
+		},
+		{
+			name: "go template",
+			templatePatch: `
+spec:
+  source:
+    path: {{ .path | quote }}
+  {{- if .prune }}
+  syncPolicy:
+    automated:
+      prune: true
+  {{- end }}
+`,
+			params:        map[string]interface{}{"prune": true, "path": "apps/guestbook"},
+			useGoTemplate: true,
+			expectedPrune: true,
+			expectedPath:  "apps/guestbook",
+		},
+		{
+			name:              "go template with a missing key",
+			templatePatch:     `{"spec": {"source": {"path": "{{ .missing }}"}}}`,
+			params:            map[string]interface{}{"prune": true},
+			useGoTemplate:     true,
+			goTemplateOptions: []string{"missingkey=error"},
+			errorMessage:      `map has no entry for key "missing"`,
+		},
+		{
+			name:          "invalid patch",
+			templatePatch: `spec: [`,
+			params:        map[string]interface{}{"prune": true},
+			errorMessage:  "failed to parse the rendered templatePatch",
+		},
+	} {
+
+		t.Run(c.name, func(t *testing.T) {
+			application := &argov1alpha1.Application{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "app",
+				},
+				Spec: argov1alpha1.ApplicationSpec{
+					Source: argov1alpha1.ApplicationSource{
+						Path: "path",
+					},
+				},
+			}
+
+			render := Render{}
+			res, err := render.RenderTemplatePatch(application, c.templatePatch, c.params, c.useGoTemplate, c.goTemplateOptions)
+
+			if c.errorMessage != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), c.errorMessage)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "app", res.Name)
+				assert.Equal(t, c.expectedPath, res.Spec.Source.Path)
+				if assert.NotNil(t, res.Spec.SyncPolicy) && assert.NotNil(t, res.Spec.SyncPolicy.Automated) {
+					assert.Equal(t, c.expectedPrune, res.Spec.SyncPolicy.Automated.Prune)
+				}
+			}
+		})
+	}
+}
+
 func TestCheckInvalidGenerators(t *testing.T) {
 
 	scheme := runtime.NewScheme()
@@ -646,3 +890,14 @@ func TestInvalidGenerators(t *testing.T) {
 		assert.Equal(t, c.expectedNames, names, c.testName)
 	}
 }
+
+func TestGeneratorNames(t *testing.T) {
+	assert.Equal(t, []string{"clusters", "scmProvider"}, GeneratorNames(argoprojiov1alpha1.ApplicationSetBaseGenerator{
+		SCMProvider: &argoprojiov1alpha1.SCMProviderGenerator{},
+		Clusters:    &argoprojiov1alpha1.ClusterGenerator{},
+	}))
+	assert.Equal(t, []string{"matrix"}, GeneratorNames(&argoprojiov1alpha1.ApplicationSetGenerator{
+		Matrix: &argoprojiov1alpha1.MatrixGenerator{},
+	}))
+	assert.Empty(t, GeneratorNames(argoprojiov1alpha1.ApplicationSetBaseGenerator{}))
+}
@@ -0,0 +1,270 @@
+package validation
+
+import (
+	pathpkg "path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	argoprojiov1alpha1 "github.com/argoproj-labs/applicationset/api/v1alpha1"
+	"github.com/argoproj-labs/applicationset/pkg/utils"
+)
+
+// ValidateApplicationSet returns the errors in the spec of the ApplicationSet that would otherwise only be found when
+// the ApplicationSet is reconciled, such as generator entries with zero or several generators, invalid regular
+// expressions or invalid combinations of fields.
+func ValidateApplicationSet(appSet *argoprojiov1alpha1.ApplicationSet) field.ErrorList {
+	specPath := field.NewPath("spec")
+
+	var errs field.ErrorList
+	for i, generator := range appSet.Spec.Generators {
+		errs = append(errs, validateGenerator(&generator, specPath.Child("generators").Index(i))...)
+	}
+	errs = append(errs, validateSyncPolicy(appSet.Spec.SyncPolicy, specPath.Child("syncPolicy"))...)
+	errs = append(errs, validateStrategy(&appSet.Spec, specPath)...)
+	errs = append(errs, validateIgnoreApplicationDifferences(appSet.Spec.IgnoreApplicationDifferences, specPath.Child("ignoreApplicationDifferences"))...)
+
+	return errs
+}
+
+func validateGenerator(generator *argoprojiov1alpha1.ApplicationSetGenerator, path *field.Path) field.ErrorList {
+	errs := validateGeneratorCount(generator, path)
+
+	if generator.Matrix != nil {
+		matrixPath := path.Child("matrix")
+		errs = append(errs, validateChildGenerators(generator.Matrix.Generators, matrixPath.Child("generators"))...)
+	}
+
+	if generator.Merge != nil {
+		mergePath := path.Child("merge")
+		errs = append(errs, validateChildGenerators(generator.Merge.Generators, mergePath.Child("generators"))...)
+		if len(generator.Merge.MergeKeys) == 0 {
+			errs = append(errs, field.Required(mergePath.Child("mergeKeys"), "merge requires at least one merge key"))
+		}
+	}
+
+	errs = append(errs, validateClusters(generator.Clusters, path.Child("clusters"))...)
+	errs = append(errs, validateGit(generator.Git, path.Child("git"))...)
+	errs = append(errs, validateSCMProvider(generator.SCMProvider, path.Child("scmProvider"))...)
+	errs = append(errs, validateClusterDecisionResource(generator.ClusterDecisionResource, path.Child("clusterDecisionResource"))...)
+
+	return errs
+}
+
+func validateChildGenerators(children []argoprojiov1alpha1.ApplicationSetBaseGenerator, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	if len(children) < 2 {
+		errs = append(errs, field.Invalid(path, len(children), "at least two child generators are required"))
+	}
+
+	for i, child := range children {
+		childPath := path.Index(i)
+		errs = append(errs, validateGeneratorCount(&child, childPath)...)
+		errs = append(errs, validateClusters(child.Clusters, childPath.Child("clusters"))...)
+		errs = append(errs, validateGit(child.Git, childPath.Child("git"))...)
+		errs = append(errs, validateSCMProvider(child.SCMProvider, childPath.Child("scmProvider"))...)
+		errs = append(errs, validateClusterDecisionResource(child.ClusterDecisionResource, childPath.Child("clusterDecisionResource"))...)
+	}
+
+	return errs
+}
+
+// validateGeneratorCount verifies that a generator entry, which is either an ApplicationSetGenerator or an
+// ApplicationSetBaseGenerator, contains exactly one generator.
+func validateGeneratorCount(generator interface{}, path *field.Path) field.ErrorList {
+	names := utils.GeneratorNames(generator)
+
+	switch len(names) {
+	case 0:
+		return field.ErrorList{field.Required(path, "each generator entry must contain exactly one generator")}
+	case 1:
+		return nil
+	default:
+		return field.ErrorList{field.Invalid(path, strings.Join(names, ", "), "each generator entry must contain exactly one generator")}
+	}
+}
+
+func validateClusters(generator *argoprojiov1alpha1.ClusterGenerator, path *field.Path) field.ErrorList {
+	if generator == nil || generator.ServerVersion == "" {
+		return nil
+	}
+
+	if _, err := semver.NewConstraint(generator.ServerVersion); err != nil {
+		return field.ErrorList{field.Invalid(path.Child("serverVersion"), generator.ServerVersion, err.Error())}
+	}
+
+	return nil
+}
+
+func validateGit(generator *argoprojiov1alpha1.GitGenerator, path *field.Path) field.ErrorList {
+	if generator == nil {
+		return nil
+	}
+
+	var errs field.ErrorList
+	for i, directory := range generator.Directories {
+		if _, err := pathpkg.Match(directory.Path, ""); err != nil {
+			errs = append(errs, field.Invalid(path.Child("directories").Index(i).Child("path"), directory.Path, err.Error()))
+		}
+	}
+
+	for i, markerFile := range generator.MarkerFiles {
+		markerFilePath := path.Child("markerFiles").Index(i)
+		if markerFile == "" || strings.Contains(markerFile, "/") {
+			errs = append(errs, field.Invalid(markerFilePath, markerFile, "marker files must be file names"))
+		} else if _, err := pathpkg.Match(markerFile, ""); err != nil {
+			errs = append(errs, field.Invalid(markerFilePath, markerFile, err.Error()))
+		}
+	}
+
+	if len(generator.Directories) == 0 {
+		if generator.LeafDirectoriesOnly {
+			errs = append(errs, field.Invalid(path.Child("leafDirectoriesOnly"), true, "only applies to the directories of the Git generator"))
+		}
+		if len(generator.MarkerFiles) > 0 {
+			errs = append(errs, field.Invalid(path.Child("markerFiles"), strings.Join(generator.MarkerFiles, ", "), "only applies to the directories of the Git generator"))
+		}
+	}
+
+	return errs
+}
+
+func validateSCMProvider(generator *argoprojiov1alpha1.SCMProviderGenerator, path *field.Path) field.ErrorList {
+	if generator == nil {
+		return nil
+	}
+
+	var errs field.ErrorList
+	for i, filter := range generator.Filters {
+		filterPath := path.Child("filters").Index(i)
+		errs = append(errs, validateRegexp(filter.RepositoryMatch, filterPath.Child("repositoryMatch"))...)
+		errs = append(errs, validateRegexp(filter.LabelMatch, filterPath.Child("labelMatch"))...)
+		errs = append(errs, validateRegexp(filter.BranchMatch, filterPath.Child("branchMatch"))...)
+	}
+
+	return errs
+}
+
+func validateRegexp(expr *string, path *field.Path) field.ErrorList {
+	if expr == nil {
+		return nil
+	}
+
+	if _, err := regexp.Compile(*expr); err != nil {
+		return field.ErrorList{field.Invalid(path, *expr, err.Error())}
+	}
+
+	return nil
+}
+
+func validateClusterDecisionResource(generator *argoprojiov1alpha1.DuckTypeGenerator, path *field.Path) field.ErrorList {
+	if generator == nil {
+		return nil
+	}
+
+	var errs field.ErrorList
+
+	if generator.ConfigMapRef == "" {
+		errs = append(errs, field.Required(path.Child("configMapRef"), "the ConfigMap with the duck type definition must be specified"))
+	}
+
+	hasLabelSelector := len(generator.LabelSelector.MatchLabels) > 0 || len(generator.LabelSelector.MatchExpressions) > 0
+	switch {
+	case generator.Name != "" && hasLabelSelector:
+		errs = append(errs, field.Invalid(path.Child("labelSelector"), metav1.FormatLabelSelector(&generator.LabelSelector), "only one of name or labelSelector may be specified"))
+	case generator.Name == "" && !hasLabelSelector:
+		errs = append(errs, field.Required(path, "one of name or labelSelector must be specified"))
+	case hasLabelSelector:
+		if _, err := metav1.LabelSelectorAsSelector(&generator.LabelSelector); err != nil {
+			errs = append(errs, field.Invalid(path.Child("labelSelector"), metav1.FormatLabelSelector(&generator.LabelSelector), err.Error()))
+		}
+	}
+
+	return errs
+}
+
+func validateSyncPolicy(syncPolicy *argoprojiov1alpha1.ApplicationSetSyncPolicy, path *field.Path) field.ErrorList {
+	if syncPolicy == nil || syncPolicy.Policy == "" {
+		return nil
+	}
+
+	if _, exists := utils.Policies[syncPolicy.Policy]; !exists {
+		return field.ErrorList{field.NotSupported(path.Child("policy"), syncPolicy.Policy, policyNames())}
+	}
+
+	return nil
+}
+
+func policyNames() []string {
+	names := make([]string, 0, len(utils.Policies))
+	for name := range utils.Policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func validateStrategy(spec *argoprojiov1alpha1.ApplicationSetSpec, specPath *field.Path) field.ErrorList {
+	strategy := spec.Strategy
+	if strategy == nil {
+		return nil
+	}
+
+	path := specPath.Child("strategy")
+
+	switch strategy.Type {
+	case "", argoprojiov1alpha1.ApplicationSetStrategyAllAtOnce:
+		return nil
+	case argoprojiov1alpha1.ApplicationSetStrategyRollingSync:
+	default:
+		return field.ErrorList{field.NotSupported(path.Child("type"), strategy.Type, []string{
+			argoprojiov1alpha1.ApplicationSetStrategyAllAtOnce,
+			argoprojiov1alpha1.ApplicationSetStrategyRollingSync,
+		})}
+	}
+
+	stepsPath := path.Child("rollingSync", "steps")
+	if strategy.RollingSync == nil || len(strategy.RollingSync.Steps) == 0 {
+		return field.ErrorList{field.Required(stepsPath, "the RollingSync strategy requires at least one step")}
+	}
+
+	var errs field.ErrorList
+	for i, step := range strategy.RollingSync.Steps {
+		selector := &metav1.LabelSelector{MatchExpressions: step.MatchExpressions}
+		if _, err := metav1.LabelSelectorAsSelector(selector); err != nil {
+			errs = append(errs, field.Invalid(stepsPath.Index(i).Child("matchExpressions"), metav1.FormatLabelSelector(selector), err.Error()))
+		}
+	}
+
+	// The controller does not sync the Applications itself, so a step would never complete without an automated sync
+	// policy. A template patch may set it, which cannot be checked before rendering.
+	syncPolicy := spec.Template.Spec.SyncPolicy
+	if spec.TemplatePatch == nil && (syncPolicy == nil || syncPolicy.Automated == nil) {
+		errs = append(errs, field.Required(specPath.Child("template", "spec", "syncPolicy", "automated"), "the RollingSync strategy requires an automated sync policy, as the Applications of each step must be synced before the next step is rolled out"))
+	}
+
+	return errs
+}
+
+func validateIgnoreApplicationDifferences(ignoreDifferences []argoprojiov1alpha1.ApplicationSetIgnoreDifferences, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	for i, ignoreDifference := range ignoreDifferences {
+		for j, pointer := range ignoreDifference.JSONPointers {
+			if _, err := utils.ParseJSONPointer(pointer); err != nil {
+				errs = append(errs, field.Invalid(path.Index(i).Child("jsonPointers").Index(j), pointer, err.Error()))
+			}
+		}
+		for j, expression := range ignoreDifference.JQPathExpressions {
+			if _, err := utils.ParseJQPathExpression(expression); err != nil {
+				errs = append(errs, field.Invalid(path.Index(i).Child("jqPathExpressions").Index(j), expression, err.Error()))
+			}
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,297 @@
+package validation
+
+import (
+	"testing"
+
+	argov1alpha1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	argoprojiov1alpha1 "github.com/argoproj-labs/applicationset/api/v1alpha1"
+)
+
+func strp(s string) *string {
+	return &s
+}
+
+func listGenerator() *argoprojiov1alpha1.ListGenerator {
+	return &argoprojiov1alpha1.ListGenerator{
+		Elements: []apiextensionsv1.JSON{{Raw: []byte(`{"cluster": "cluster","url": "url"}`)}},
+	}
+}
+
+func TestValidateApplicationSet(t *testing.T) {
+	for _, c := range []struct {
+		name           string
+		spec           argoprojiov1alpha1.ApplicationSetSpec
+		expectedErrors []string
+	}{
+		{
+			name: "valid",
+			spec: argoprojiov1alpha1.ApplicationSetSpec{
+				Generators: []argoprojiov1alpha1.ApplicationSetGenerator{
+					{List: listGenerator()},
+					{
+						Matrix: &argoprojiov1alpha1.MatrixGenerator{
+							Generators: []argoprojiov1alpha1.ApplicationSetBaseGenerator{
+								{List: listGenerator()},
+								{Clusters: &argoprojiov1alpha1.ClusterGenerator{}},
+								{SCMProvider: &argoprojiov1alpha1.SCMProviderGenerator{
+									Filters: []argoprojiov1alpha1.SCMProviderGeneratorFilter{{RepositoryMatch: strp("^myapp"), BranchMatch: strp("main|master")}},
+								}},
+							},
+						},
+					},
+					{
+						ClusterDecisionResource: &argoprojiov1alpha1.DuckTypeGenerator{
+							ConfigMapRef: "my-configmap",
+							LabelSelector: metav1.LabelSelector{
+								MatchLabels: map[string]string{"duck": "spotted"},
+							},
+						},
+					},
+				},
+				SyncPolicy: &argoprojiov1alpha1.ApplicationSetSyncPolicy{Policy: "create-only"},
+				Strategy: &argoprojiov1alpha1.ApplicationSetStrategy{
+					Type: argoprojiov1alpha1.ApplicationSetStrategyRollingSync,
+					RollingSync: &argoprojiov1alpha1.ApplicationSetRolloutStrategy{
+						Steps: []argoprojiov1alpha1.ApplicationSetRolloutStep{
+							{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "env", Operator: metav1.LabelSelectorOpIn, Values: []string{"dev"}}}},
+						},
+					},
+				},
+				Template: argoprojiov1alpha1.ApplicationSetTemplate{
+					Spec: argov1alpha1.ApplicationSpec{
+						SyncPolicy: &argov1alpha1.SyncPolicy{Automated: &argov1alpha1.SyncPolicyAutomated{}},
+					},
+				},
+			},
+		},
+		{
+			name: "generator entries must contain exactly one generator",
+			spec: argoprojiov1alpha1.ApplicationSetSpec{
+				Generators: []argoprojiov1alpha1.ApplicationSetGenerator{
+					{List: listGenerator(), Clusters: &argoprojiov1alpha1.ClusterGenerator{}},
+					{},
+				},
+			},
+			expectedErrors: []string{
+				`spec.generators[0]: Invalid value: "clusters, list": each generator entry must contain exactly one generator`,
+				`spec.generators[1]: Required value: each generator entry must contain exactly one generator`,
+			},
+		},
+		{
+			name: "matrix child generators",
+			spec: argoprojiov1alpha1.ApplicationSetSpec{
+				Generators: []argoprojiov1alpha1.ApplicationSetGenerator{
+					{
+						Matrix: &argoprojiov1alpha1.MatrixGenerator{
+							Generators: []argoprojiov1alpha1.ApplicationSetBaseGenerator{
+								{List: listGenerator(), Git: &argoprojiov1alpha1.GitGenerator{}},
+							},
+						},
+					},
+				},
+			},
+			expectedErrors: []string{
+				`spec.generators[0].matrix.generators: Invalid value: 1: at least two child generators are required`,
+				`spec.generators[0].matrix.generators[0]: Invalid value: "git, list": each generator entry must contain exactly one generator`,
+			},
+		},
+		{
+			name: "merge child generators and merge keys",
+			spec: argoprojiov1alpha1.ApplicationSetSpec{
+				Generators: []argoprojiov1alpha1.ApplicationSetGenerator{
+					{
+						Merge: &argoprojiov1alpha1.MergeGenerator{
+							Generators: []argoprojiov1alpha1.ApplicationSetBaseGenerator{
+								{List: listGenerator()},
+								{},
+							},
+						},
+					},
+				},
+			},
+			expectedErrors: []string{
+				`spec.generators[0].merge.generators[1]: Required value: each generator entry must contain exactly one generator`,
+				`spec.generators[0].merge.mergeKeys: Required value: merge requires at least one merge key`,
+			},
+		},
+		{
+			name: "scm provider filters with invalid regular expressions",
+			spec: argoprojiov1alpha1.ApplicationSetSpec{
+				Generators: []argoprojiov1alpha1.ApplicationSetGenerator{
+					{
+						SCMProvider: &argoprojiov1alpha1.SCMProviderGenerator{
+							Filters: []argoprojiov1alpha1.SCMProviderGeneratorFilter{
+								{RepositoryMatch: strp("^myapp")},
+								{RepositoryMatch: strp("(myapp"), LabelMatch: strp("deploy-*"), BranchMatch: strp("[main")},
+							},
+						},
+					},
+					{
+						Matrix: &argoprojiov1alpha1.MatrixGenerator{
+							Generators: []argoprojiov1alpha1.ApplicationSetBaseGenerator{
+								{List: listGenerator()},
+								{SCMProvider: &argoprojiov1alpha1.SCMProviderGenerator{
+									Filters: []argoprojiov1alpha1.SCMProviderGeneratorFilter{{LabelMatch: strp("*")}},
+								}},
+							},
+						},
+					},
+				},
+			},
+			expectedErrors: []string{
+				"spec.generators[0].scmProvider.filters[1].repositoryMatch: Invalid value: \"(myapp\": error parsing regexp: missing closing ): `(myapp`",
+				"spec.generators[0].scmProvider.filters[1].branchMatch: Invalid value: \"[main\": error parsing regexp: missing closing ]: `[main`",
+				"spec.generators[1].matrix.generators[1].scmProvider.filters[0].labelMatch: Invalid value: \"*\": error parsing regexp: missing argument to repetition operator: `*`",
+			},
+		},
+		{
+			name: "cluster generators with invalid server version constraints",
+			spec: argoprojiov1alpha1.ApplicationSetSpec{
+				Generators: []argoprojiov1alpha1.ApplicationSetGenerator{
+					{Clusters: &argoprojiov1alpha1.ClusterGenerator{ServerVersion: ">=1.19, <1.22"}},
+					{
+						Merge: &argoprojiov1alpha1.MergeGenerator{
+							MergeKeys: []string{"server"},
+							Generators: []argoprojiov1alpha1.ApplicationSetBaseGenerator{
+								{Clusters: &argoprojiov1alpha1.ClusterGenerator{ServerVersion: "latest"}},
+								{List: listGenerator()},
+							},
+						},
+					},
+				},
+			},
+			expectedErrors: []string{
+				`spec.generators[1].merge.generators[0].clusters.serverVersion: Invalid value: "latest": improper constraint: latest`,
+			},
+		},
+		{
+			name: "git generators with invalid patterns and marker files",
+			spec: argoprojiov1alpha1.ApplicationSetSpec{
+				Generators: []argoprojiov1alpha1.ApplicationSetGenerator{
+					{
+						Git: &argoprojiov1alpha1.GitGenerator{
+							Directories: []argoprojiov1alpha1.GitDirectoryGeneratorItem{{Path: "apps/**"}, {Path: "apps/[a", Exclude: true}},
+							MarkerFiles: []string{"Chart.yaml", "base/kustomization.yaml"},
+						},
+					},
+					{
+						Matrix: &argoprojiov1alpha1.MatrixGenerator{
+							Generators: []argoprojiov1alpha1.ApplicationSetBaseGenerator{
+								{List: listGenerator()},
+								{Git: &argoprojiov1alpha1.GitGenerator{
+									Files:               []argoprojiov1alpha1.GitFileGeneratorItem{{Path: "config.json"}},
+									LeafDirectoriesOnly: true,
+								}},
+							},
+						},
+					},
+				},
+			},
+			expectedErrors: []string{
+				`spec.generators[0].git.directories[1].path: Invalid value: "apps/[a": syntax error in pattern`,
+				`spec.generators[0].git.markerFiles[1]: Invalid value: "base/kustomization.yaml": marker files must be file names`,
+				`spec.generators[1].matrix.generators[1].git.leafDirectoriesOnly: Invalid value: true: only applies to the directories of the Git generator`,
+			},
+		},
+		{
+			name: "cluster decision resource with both name and label selector",
+			spec: argoprojiov1alpha1.ApplicationSetSpec{
+				Generators: []argoprojiov1alpha1.ApplicationSetGenerator{
+					{
+						ClusterDecisionResource: &argoprojiov1alpha1.DuckTypeGenerator{
+							ConfigMapRef: "my-configmap",
+							Name:         "quak",
+							LabelSelector: metav1.LabelSelector{
+								MatchLabels: map[string]string{"duck": "spotted"},
+							},
+						},
+					},
+					{
+						ClusterDecisionResource: &argoprojiov1alpha1.DuckTypeGenerator{},
+					},
+				},
+			},
+			expectedErrors: []string{
+				`spec.generators[0].clusterDecisionResource.labelSelector: Invalid value: "duck=spotted": only one of name or labelSelector may be specified`,
+				`spec.generators[1].clusterDecisionResource.configMapRef: Required value: the ConfigMap with the duck type definition must be specified`,
+				`spec.generators[1].clusterDecisionResource: Required value: one of name or labelSelector must be specified`,
+			},
+		},
+		{
+			name: "unknown policy and strategy",
+			spec: argoprojiov1alpha1.ApplicationSetSpec{
+				Generators: []argoprojiov1alpha1.ApplicationSetGenerator{{List: listGenerator()}},
+				SyncPolicy: &argoprojiov1alpha1.ApplicationSetSyncPolicy{Policy: "delete-only"},
+				Strategy:   &argoprojiov1alpha1.ApplicationSetStrategy{Type: "Canary"},
+			},
+			expectedErrors: []string{
+				`spec.syncPolicy.policy: Unsupported value: "delete-only": supported values: "create-only", "create-update", "sync"`,
+				`spec.strategy.type: Unsupported value: "Canary": supported values: "AllAtOnce", "RollingSync"`,
+			},
+		},
+		{
+			name: "rolling sync steps",
+			spec: argoprojiov1alpha1.ApplicationSetSpec{
+				Generators: []argoprojiov1alpha1.ApplicationSetGenerator{{List: listGenerator()}},
+				Strategy: &argoprojiov1alpha1.ApplicationSetStrategy{
+					Type: argoprojiov1alpha1.ApplicationSetStrategyRollingSync,
+				},
+			},
+			expectedErrors: []string{
+				`spec.strategy.rollingSync.steps: Required value: the RollingSync strategy requires at least one step`,
+			},
+		},
+		{
+			name: "rolling sync without an automated sync policy",
+			spec: argoprojiov1alpha1.ApplicationSetSpec{
+				Generators: []argoprojiov1alpha1.ApplicationSetGenerator{{List: listGenerator()}},
+				Strategy: &argoprojiov1alpha1.ApplicationSetStrategy{
+					Type: argoprojiov1alpha1.ApplicationSetStrategyRollingSync,
+					RollingSync: &argoprojiov1alpha1.ApplicationSetRolloutStrategy{
+						Steps: []argoprojiov1alpha1.ApplicationSetRolloutStep{{}},
+					},
+				},
+				Template: argoprojiov1alpha1.ApplicationSetTemplate{
+					Spec: argov1alpha1.ApplicationSpec{
+						SyncPolicy: &argov1alpha1.SyncPolicy{SyncOptions: argov1alpha1.SyncOptions{"CreateNamespace=true"}},
+					},
+				},
+			},
+			expectedErrors: []string{
+				`spec.template.spec.syncPolicy.automated: Required value: the RollingSync strategy requires an automated sync policy, as the Applications of each step must be synced before the next step is rolled out`,
+			},
+		},
+		{
+			name: "ignored application differences",
+			spec: argoprojiov1alpha1.ApplicationSetSpec{
+				Generators: []argoprojiov1alpha1.ApplicationSetGenerator{{List: listGenerator()}},
+				IgnoreApplicationDifferences: []argoprojiov1alpha1.ApplicationSetIgnoreDifferences{
+					{
+						JSONPointers:      []string{"/spec/syncPolicy", "spec/source/targetRevision", "/spec/syncPolicy/syncOptions/0"},
+						JQPathExpressions: []string{".spec.source.targetRevision", ".spec[", ".spec.source.helm.parameters[0].value"},
+					},
+				},
+			},
+			expectedErrors: []string{
+				`spec.ignoreApplicationDifferences[0].jsonPointers[1]: Invalid value: "spec/source/targetRevision": invalid JSON pointer 'spec/source/targetRevision': must start with '/'`,
+				`spec.ignoreApplicationDifferences[0].jsonPointers[2]: Invalid value: "/spec/syncPolicy/syncOptions/0": invalid JSON pointer '/spec/syncPolicy/syncOptions/0': '/spec/syncPolicy/syncOptions' is a list, whose elements cannot be ignored individually: point to the whole list instead`,
+				`spec.ignoreApplicationDifferences[0].jqPathExpressions[1]: Invalid value: ".spec[": invalid jq path expression '.spec[': unexpected token ")"`,
+				`spec.ignoreApplicationDifferences[0].jqPathExpressions[2]: Invalid value: ".spec.source.helm.parameters[0].value": invalid jq path expression '.spec.source.helm.parameters[0].value': '[0]' indexes a list, whose elements cannot be ignored individually: select the whole list instead`,
+			},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			errs := ValidateApplicationSet(&argoprojiov1alpha1.ApplicationSet{Spec: c.spec})
+
+			var messages []string
+			for _, err := range errs {
+				messages = append(messages, err.Error())
+			}
+			assert.Equal(t, c.expectedErrors, messages)
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package validation
+
+import (
+	"context"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	argoprojiov1alpha1 "github.com/argoproj-labs/applicationset/api/v1alpha1"
+)
+
+// WebhookPath is the path the ApplicationSet validating webhook is served on.
+const WebhookPath = "/validate-argoproj-io-v1alpha1-applicationset"
+
+// ApplicationSetValidator is a validating admission webhook, that rejects the ApplicationSets whose spec is invalid.
+type ApplicationSetValidator struct {
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = &ApplicationSetValidator{}
+var _ admission.DecoderInjector = &ApplicationSetValidator{}
+
+// SetupWebhookWithManager registers the ApplicationSet validating webhook with the webhook server of the manager.
+func SetupWebhookWithManager(mgr ctrl.Manager) {
+	mgr.GetWebhookServer().Register(WebhookPath, &webhook.Admission{Handler: &ApplicationSetValidator{}})
+}
+
+// Handle validates the ApplicationSet of a create or update request.
+func (v *ApplicationSetValidator) Handle(_ context.Context, req admission.Request) admission.Response {
+	appSet := &argoprojiov1alpha1.ApplicationSet{}
+	if err := v.decoder.Decode(req, appSet); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	errs := ValidateApplicationSet(appSet)
+	if len(errs) == 0 {
+		return admission.Allowed("")
+	}
+
+	log.WithFields(log.Fields{"namespace": req.Namespace, "name": req.Name}).WithError(errs.ToAggregate()).Info("rejecting invalid ApplicationSet")
+
+	statusErr := apierr.NewInvalid(argoprojiov1alpha1.GroupVersion.WithKind("ApplicationSet").GroupKind(), appSet.Name, errs)
+	res := admission.Denied(statusErr.Error())
+	res.Result = &statusErr.ErrStatus
+	return res
+}
+
+// InjectDecoder injects the decoder of the webhook server.
+func (v *ApplicationSetValidator) InjectDecoder(decoder *admission.Decoder) error {
+	v.decoder = decoder
+	return nil
+}
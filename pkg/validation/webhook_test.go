@@ -0,0 +1,201 @@
+package validation
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	argov1alpha1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	argoprojiov1alpha1 "github.com/argoproj-labs/applicationset/api/v1alpha1"
+)
+
+func testAppSet(name string, generators ...argoprojiov1alpha1.ApplicationSetGenerator) *argoprojiov1alpha1.ApplicationSet {
+	return &argoprojiov1alpha1.ApplicationSet{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: argoprojiov1alpha1.GroupVersion.String(),
+			Kind:       "ApplicationSet",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+		},
+		Spec: argoprojiov1alpha1.ApplicationSetSpec{
+			Generators: generators,
+			Template: argoprojiov1alpha1.ApplicationSetTemplate{
+				ApplicationSetTemplateMeta: argoprojiov1alpha1.ApplicationSetTemplateMeta{
+					Name: "{{cluster}}-guestbook",
+				},
+				Spec: argov1alpha1.ApplicationSpec{
+					Project: "default",
+					Source: argov1alpha1.ApplicationSource{
+						RepoURL:        "https://github.com/argoproj-labs/applicationset.git",
+						TargetRevision: "HEAD",
+						Path:           "examples/list-generator/guestbook/{{cluster}}",
+					},
+					Destination: argov1alpha1.ApplicationDestination{
+						Server:    "{{url}}",
+						Namespace: "guestbook",
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestHandle(t *testing.T) {
+	scheme := runtime.NewScheme()
+	err := argoprojiov1alpha1.AddToScheme(scheme)
+	assert.Nil(t, err)
+
+	decoder, err := admission.NewDecoder(scheme)
+	assert.Nil(t, err)
+
+	validator := &ApplicationSetValidator{}
+	err = validator.InjectDecoder(decoder)
+	assert.Nil(t, err)
+
+	for _, c := range []struct {
+		name            string
+		appSet          *argoprojiov1alpha1.ApplicationSet
+		expectedAllowed bool
+		expectedMessage string
+	}{
+		{
+			name:            "valid",
+			appSet:          testAppSet("valid", argoprojiov1alpha1.ApplicationSetGenerator{List: listGenerator()}),
+			expectedAllowed: true,
+		},
+		{
+			name: "invalid",
+			appSet: testAppSet("invalid", argoprojiov1alpha1.ApplicationSetGenerator{
+				List:     listGenerator(),
+				Clusters: &argoprojiov1alpha1.ClusterGenerator{},
+			}),
+			expectedAllowed: false,
+			expectedMessage: `ApplicationSet.argoproj.io "invalid" is invalid: spec.generators[0]: Invalid value: "clusters, list": each generator entry must contain exactly one generator`,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			raw, err := json.Marshal(c.appSet)
+			assert.Nil(t, err)
+
+			res := validator.Handle(context.Background(), admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Operation: admissionv1.Create,
+					Name:      c.appSet.Name,
+					Namespace: c.appSet.Namespace,
+					Object:    runtime.RawExtension{Raw: raw},
+				},
+			})
+
+			assert.Equal(t, c.expectedAllowed, res.Allowed)
+			if !c.expectedAllowed {
+				assert.Equal(t, int32(http.StatusUnprocessableEntity), res.Result.Code)
+				assert.Equal(t, metav1.StatusReasonInvalid, res.Result.Reason)
+				assert.Equal(t, c.expectedMessage, res.Result.Message)
+			}
+		})
+	}
+
+	res := validator.Handle(context.Background(), admission.Request{
+		AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: admissionv1.Create,
+			Object:    runtime.RawExtension{Raw: []byte("{")},
+		},
+	})
+	assert.False(t, res.Allowed)
+	assert.Equal(t, int32(http.StatusBadRequest), res.Result.Code)
+}
+
+// TestWebhookWithEnvtest runs the webhook against a local API server, as started by envtest. It is skipped unless
+// KUBEBUILDER_ASSETS points to the envtest binaries.
+func TestWebhookWithEnvtest(t *testing.T) {
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		t.Skip("KUBEBUILDER_ASSETS is not set, skipping the envtest webhook test")
+	}
+
+	testEnv := &envtest.Environment{
+		CRDDirectoryPaths: []string{filepath.Join("..", "..", "manifests", "crds")},
+		WebhookInstallOptions: envtest.WebhookInstallOptions{
+			Paths: []string{filepath.Join("..", "..", "manifests", "webhook", "webhook.yaml")},
+		},
+	}
+
+	cfg, err := testEnv.Start()
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer func() {
+		assert.Nil(t, testEnv.Stop())
+	}()
+
+	scheme := runtime.NewScheme()
+	err = corev1.AddToScheme(scheme)
+	assert.Nil(t, err)
+	err = argoprojiov1alpha1.AddToScheme(scheme)
+	assert.Nil(t, err)
+
+	webhookOptions := testEnv.WebhookInstallOptions
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+		Scheme:             scheme,
+		Host:               webhookOptions.LocalServingHost,
+		Port:               webhookOptions.LocalServingPort,
+		CertDir:            webhookOptions.LocalServingCertDir,
+		MetricsBindAddress: "0",
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	SetupWebhookWithManager(mgr)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = mgr.Start(ctx)
+	}()
+
+	// Wait for the webhook server to be ready
+	address := net.JoinHostPort(webhookOptions.LocalServingHost, fmt.Sprint(webhookOptions.LocalServingPort))
+	assert.Eventually(t, func() bool {
+		conn, err := tls.DialWithDialer(&net.Dialer{Timeout: time.Second}, "tcp", address, &tls.Config{InsecureSkipVerify: true})
+		if err != nil {
+			return false
+		}
+		_ = conn.Close()
+		return true
+	}, 10*time.Second, 100*time.Millisecond)
+
+	k8sClient, err := client.New(cfg, client.Options{Scheme: scheme})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	err = k8sClient.Create(ctx, testAppSet("valid", argoprojiov1alpha1.ApplicationSetGenerator{List: listGenerator()}))
+	assert.Nil(t, err)
+
+	err = k8sClient.Create(ctx, testAppSet("invalid", argoprojiov1alpha1.ApplicationSetGenerator{
+		Matrix: &argoprojiov1alpha1.MatrixGenerator{
+			Generators: []argoprojiov1alpha1.ApplicationSetBaseGenerator{{List: listGenerator()}},
+		},
+	}))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `denied the request: ApplicationSet.argoproj.io "invalid" is invalid: spec.generators[0].matrix.generators: Invalid value: 1: at least two child generators are required`)
+	}
+}
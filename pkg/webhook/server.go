@@ -0,0 +1,52 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// Path is the path the Git webhook receiver is served on, matching the one of the API server of Argo CD.
+const Path = "/api/webhook"
+
+// Server serves the webhook handler over HTTP. It is added to the manager, which starts it along with the
+// controller.
+type Server struct {
+	// Addr is the address the server listens on, e.g. ":7000"
+	Addr    string
+	Handler *WebhookHandler
+}
+
+var _ manager.Runnable = &Server{}
+var _ manager.LeaderElectionRunnable = &Server{}
+
+// Start serves the webhook requests until the context is done.
+func (s *Server) Start(ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc(Path, s.Handler.Handler)
+	srv := &http.Server{Addr: s.Addr, Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.WithError(err).Error("unable to shut down the webhook server")
+		}
+	}()
+
+	log.WithField("addr", s.Addr).Info("starting the Git webhook server")
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// NeedLeaderElection returns false, so that every replica of the controller accepts webhook requests: refreshing an
+// ApplicationSet only annotates it, and the elected leader reconciles it.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
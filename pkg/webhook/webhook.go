@@ -0,0 +1,604 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	argosettings "github.com/argoproj/argo-cd/v2/util/settings"
+	log "github.com/sirupsen/logrus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	argoprojiov1alpha1 "github.com/argoproj-labs/applicationset/api/v1alpha1"
+)
+
+// maxPayloadSize is the maximum size of the webhook payloads which are accepted, matching the one of Argo CD.
+const maxPayloadSize = 25 * 1024 * 1024
+
+const (
+	providerGitHub          = "github"
+	providerGitLab          = "gitlab"
+	providerBitbucket       = "bitbucket"
+	providerBitbucketServer = "bitbucketServer"
+)
+
+var (
+	errInvalidSecret   = fmt.Errorf("the webhook secret is invalid")
+	errMissingSecret   = fmt.Errorf("no webhook secret is configured for the Git provider")
+	errUnsupportedHook = fmt.Errorf("unsupported webhook request")
+)
+
+// settingsSource returns the Argo CD settings holding the webhook secrets. It is satisfied by the settings manager of
+// Argo CD.
+type settingsSource interface {
+	GetSettings() (*argosettings.ArgoCDSettings, error)
+}
+
+// WebhookHandler receives the push and pull request events of Git providers, and requests the refresh of the
+// ApplicationSets whose generators are affected by them, instead of waiting for their next periodic reconciliation.
+type WebhookHandler struct {
+	namespace string
+	settings  settingsSource
+	client    client.Client
+}
+
+// webhookEvent is the provider independent description of a push or pull request event.
+type webhookEvent struct {
+	provider string
+	// host is the hostname of the Git provider, e.g. github.com
+	host string
+	// fullName is the path of the repository, including its owner, e.g. argoproj/argo-cd
+	fullName string
+	// projectID is the numeric id of GitLab projects
+	projectID string
+	// repoURLs are the clone and web URLs of the repository, as returned by repoKey
+	repoURLs []string
+	// refs are the refs changed by a push, e.g. refs/heads/main
+	refs []string
+	// defaultBranch is the default branch of the repository, or empty if the payload does not include it
+	defaultBranch string
+	pullRequest   bool
+}
+
+// NewWebhookHandler returns a handler refreshing the ApplicationSets of the namespace, which validates the requests
+// with the webhook secrets of the Argo CD settings.
+func NewWebhookHandler(namespace string, settingsMgr *argosettings.SettingsManager, c client.Client) *WebhookHandler {
+	return &WebhookHandler{
+		namespace: namespace,
+		settings:  settingsMgr,
+		client:    c,
+	}
+}
+
+// Handler serves the webhook requests of GitHub, GitLab, Bitbucket and Bitbucket Server.
+func (h *WebhookHandler) Handler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST requests are supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPayloadSize))
+	if err != nil {
+		log.WithError(err).Error("unable to read the webhook payload")
+		http.Error(w, "unable to read the webhook payload", http.StatusBadRequest)
+		return
+	}
+
+	event, err := h.parse(r, payload)
+	switch {
+	case err == errInvalidSecret:
+		log.WithError(err).Warn("rejecting webhook request")
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	case err == errMissingSecret:
+		log.WithError(err).Warn("rejecting webhook request, set the webhook secret of the Git provider in the argocd-secret Secret to accept it")
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	case err == errUnsupportedHook:
+		log.WithField("headers", r.Header).Debug("ignoring unsupported webhook request")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		log.WithError(err).Error("unable to parse the webhook payload")
+		http.Error(w, fmt.Sprintf("unable to parse the webhook payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// Pings, and the events which are not relevant to ApplicationSets, have no event
+	if event != nil {
+		if err := h.refreshApplicationSets(r.Context(), event); err != nil {
+			log.WithError(err).Error("unable to refresh ApplicationSets")
+			http.Error(w, "unable to refresh ApplicationSets", http.StatusInternalServerError)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// parse validates the secret of the webhook request, and parses its payload. It returns a nil event for pings and
+// the events which are not relevant to ApplicationSets.
+func (h *WebhookHandler) parse(r *http.Request, payload []byte) (*webhookEvent, error) {
+	settings, err := h.settings.GetSettings()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the Argo CD settings: %v", err)
+	}
+
+	switch {
+	case r.Header.Get("X-GitHub-Event") != "":
+		if err := validHMAC(payload, settings.WebhookGitHubSecret, r.Header.Get("X-Hub-Signature-256"), r.Header.Get("X-Hub-Signature")); err != nil {
+			return nil, err
+		}
+		return parseGitHub(r.Header.Get("X-GitHub-Event"), payload)
+	case r.Header.Get("X-Gitlab-Event") != "":
+		if err := validToken(settings.WebhookGitLabSecret, r.Header.Get("X-Gitlab-Token")); err != nil {
+			return nil, err
+		}
+		return parseGitLab(r.Header.Get("X-Gitlab-Event"), payload)
+	case r.Header.Get("X-Event-Key") != "":
+		eventKey := r.Header.Get("X-Event-Key")
+		// Bitbucket Cloud and Bitbucket Server use the same header, but different event keys
+		if eventKey == "repo:push" || strings.HasPrefix(eventKey, "pullrequest:") {
+			if err := validToken(settings.WebhookBitbucketUUID, r.Header.Get("X-Hook-UUID")); err != nil {
+				return nil, err
+			}
+			return parseBitbucket(eventKey, payload)
+		}
+		if err := validHMAC(payload, settings.WebhookBitbucketServerSecret, r.Header.Get("X-Hub-Signature")); err != nil {
+			return nil, err
+		}
+		return parseBitbucketServer(eventKey, payload)
+	}
+	return nil, errUnsupportedHook
+}
+
+// SecretsConfigured returns whether a webhook secret is configured for at least one Git provider in the Argo CD
+// settings. The requests of the providers without a secret are rejected.
+func SecretsConfigured(settings *argosettings.ArgoCDSettings) bool {
+	return settings.WebhookGitHubSecret != "" || settings.WebhookGitLabSecret != "" ||
+		settings.WebhookBitbucketUUID != "" || settings.WebhookBitbucketServerSecret != ""
+}
+
+// validHMAC checks that one of the signatures, formatted as <algorithm>=<hex digest>, is the HMAC of the payload keyed
+// with the secret. Requests are rejected if no secret is configured, as anyone could trigger refreshes otherwise.
+func validHMAC(payload []byte, secret string, signatures ...string) error {
+	if secret == "" {
+		return errMissingSecret
+	}
+	for _, signature := range signatures {
+		parts := strings.SplitN(signature, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		var newHash func() hash.Hash
+		switch parts[0] {
+		case "sha256":
+			newHash = sha256.New
+		case "sha1":
+			newHash = sha1.New
+		default:
+			continue
+		}
+		digest, err := hex.DecodeString(parts[1])
+		if err != nil {
+			continue
+		}
+		mac := hmac.New(newHash, []byte(secret))
+		_, _ = mac.Write(payload)
+		if hmac.Equal(digest, mac.Sum(nil)) {
+			return nil
+		}
+	}
+	return errInvalidSecret
+}
+
+// validToken checks that the token of the request is the configured secret. Requests are rejected if no secret is
+// configured.
+func validToken(secret, token string) error {
+	if secret == "" {
+		return errMissingSecret
+	}
+	if subtle.ConstantTimeCompare([]byte(secret), []byte(token)) != 1 {
+		return errInvalidSecret
+	}
+	return nil
+}
+
+func parseGitHub(eventType string, payload []byte) (*webhookEvent, error) {
+	body := struct {
+		Ref        string `json:"ref"`
+		Repository struct {
+			FullName      string `json:"full_name"`
+			HTMLURL       string `json:"html_url"`
+			CloneURL      string `json:"clone_url"`
+			SSHURL        string `json:"ssh_url"`
+			DefaultBranch string `json:"default_branch"`
+		} `json:"repository"`
+	}{}
+
+	switch eventType {
+	case "push", "pull_request":
+	default:
+		// e.g. ping
+		return nil, nil
+	}
+	if err := json.Unmarshal(payload, &body); err != nil {
+		return nil, err
+	}
+
+	event := &webhookEvent{
+		provider:      providerGitHub,
+		host:          hostname(body.Repository.HTMLURL),
+		fullName:      body.Repository.FullName,
+		repoURLs:      repoKeys(body.Repository.HTMLURL, body.Repository.CloneURL, body.Repository.SSHURL),
+		defaultBranch: body.Repository.DefaultBranch,
+		pullRequest:   eventType == "pull_request",
+	}
+	if !event.pullRequest {
+		event.refs = []string{body.Ref}
+	}
+	return event, nil
+}
+
+func parseGitLab(eventType string, payload []byte) (*webhookEvent, error) {
+	body := struct {
+		Ref     string `json:"ref"`
+		Project struct {
+			ID                int64  `json:"id"`
+			PathWithNamespace string `json:"path_with_namespace"`
+			WebURL            string `json:"web_url"`
+			GitHTTPURL        string `json:"git_http_url"`
+			GitSSHURL         string `json:"git_ssh_url"`
+			DefaultBranch     string `json:"default_branch"`
+		} `json:"project"`
+	}{}
+
+	switch eventType {
+	case "Push Hook", "Tag Push Hook", "Merge Request Hook":
+	default:
+		return nil, nil
+	}
+	if err := json.Unmarshal(payload, &body); err != nil {
+		return nil, err
+	}
+
+	event := &webhookEvent{
+		provider:      providerGitLab,
+		host:          hostname(body.Project.WebURL),
+		fullName:      body.Project.PathWithNamespace,
+		projectID:     strconv.FormatInt(body.Project.ID, 10),
+		repoURLs:      repoKeys(body.Project.WebURL, body.Project.GitHTTPURL, body.Project.GitSSHURL),
+		defaultBranch: body.Project.DefaultBranch,
+		pullRequest:   eventType == "Merge Request Hook",
+	}
+	if !event.pullRequest {
+		event.refs = []string{body.Ref}
+	}
+	return event, nil
+}
+
+func parseBitbucket(eventKey string, payload []byte) (*webhookEvent, error) {
+	body := struct {
+		Push struct {
+			Changes []struct {
+				New *struct {
+					Type string `json:"type"`
+					Name string `json:"name"`
+				} `json:"new"`
+			} `json:"changes"`
+		} `json:"push"`
+		Repository struct {
+			FullName string `json:"full_name"`
+			Links    struct {
+				HTML struct {
+					Href string `json:"href"`
+				} `json:"html"`
+			} `json:"links"`
+		} `json:"repository"`
+	}{}
+
+	if err := json.Unmarshal(payload, &body); err != nil {
+		return nil, err
+	}
+
+	event := &webhookEvent{
+		provider: providerBitbucket,
+		host:     hostname(body.Repository.Links.HTML.Href),
+		fullName: body.Repository.FullName,
+		// The payloads do not include the clone URLs, whose host and path are the ones of the web URL
+		repoURLs:    repoKeys(body.Repository.Links.HTML.Href),
+		pullRequest: strings.HasPrefix(eventKey, "pullrequest:"),
+	}
+	for _, change := range body.Push.Changes {
+		// Deleted refs have no new state
+		if change.New == nil {
+			continue
+		}
+		switch change.New.Type {
+		case "branch":
+			event.refs = append(event.refs, "refs/heads/"+change.New.Name)
+		case "tag":
+			event.refs = append(event.refs, "refs/tags/"+change.New.Name)
+		}
+	}
+	return event, nil
+}
+
+type bitbucketServerRepository struct {
+	Slug    string `json:"slug"`
+	Project struct {
+		Key string `json:"key"`
+	} `json:"project"`
+	Links struct {
+		Clone []struct {
+			Href string `json:"href"`
+		} `json:"clone"`
+	} `json:"links"`
+}
+
+func parseBitbucketServer(eventKey string, payload []byte) (*webhookEvent, error) {
+	body := struct {
+		Repository *bitbucketServerRepository `json:"repository"`
+		Changes    []struct {
+			RefID string `json:"refId"`
+			Type  string `json:"type"`
+		} `json:"changes"`
+		PullRequest *struct {
+			ToRef struct {
+				Repository *bitbucketServerRepository `json:"repository"`
+			} `json:"toRef"`
+		} `json:"pullRequest"`
+	}{}
+
+	pullRequest := strings.HasPrefix(eventKey, "pr:")
+	if eventKey != "repo:refs_changed" && !pullRequest {
+		// e.g. diagnostics:ping
+		return nil, nil
+	}
+	if err := json.Unmarshal(payload, &body); err != nil {
+		return nil, err
+	}
+
+	repo := body.Repository
+	if pullRequest && body.PullRequest != nil {
+		repo = body.PullRequest.ToRef.Repository
+	}
+	if repo == nil {
+		return nil, fmt.Errorf("the payload has no repository")
+	}
+
+	event := &webhookEvent{
+		provider:    providerBitbucketServer,
+		fullName:    repo.Project.Key + "/" + repo.Slug,
+		pullRequest: pullRequest,
+	}
+	for _, link := range repo.Links.Clone {
+		event.repoURLs = append(event.repoURLs, repoKey(link.Href))
+		if event.host == "" && strings.HasPrefix(link.Href, "http") {
+			event.host = hostname(link.Href)
+		}
+	}
+	for _, change := range body.Changes {
+		if change.Type != "DELETE" {
+			event.refs = append(event.refs, change.RefID)
+		}
+	}
+	return event, nil
+}
+
+// refreshApplicationSets requests the refresh of the ApplicationSets whose generators are affected by the event.
+func (h *WebhookHandler) refreshApplicationSets(ctx context.Context, event *webhookEvent) error {
+	appSets := &argoprojiov1alpha1.ApplicationSetList{}
+	if err := h.client.List(ctx, appSets, client.InNamespace(h.namespace)); err != nil {
+		return fmt.Errorf("unable to list ApplicationSets: %v", err)
+	}
+
+	for i := range appSets.Items {
+		appSet := &appSets.Items[i]
+		if !shouldRefresh(appSet, event) {
+			continue
+		}
+		if err := refreshApplicationSet(ctx, h.client, appSet); err != nil {
+			return err
+		}
+		log.WithFields(log.Fields{"appSet": appSet.Name, "repository": event.fullName}).Info("requested ApplicationSet refresh")
+	}
+	return nil
+}
+
+// refreshApplicationSet sets the refresh annotation of the ApplicationSet to the time of the request: as its value
+// changes for each event, the controller does not remove a refresh requested while the ApplicationSet is reconciled.
+func refreshApplicationSet(ctx context.Context, c client.Client, appSet *argoprojiov1alpha1.ApplicationSet) error {
+	patch := client.MergeFrom(appSet.DeepCopy())
+	if appSet.Annotations == nil {
+		appSet.Annotations = map[string]string{}
+	}
+	appSet.Annotations[argoprojiov1alpha1.AnnotationApplicationSetRefresh] = time.Now().UTC().Format(time.RFC3339Nano)
+	if err := c.Patch(ctx, appSet, patch); err != nil {
+		return fmt.Errorf("unable to request the refresh of ApplicationSet %s: %v", appSet.Name, err)
+	}
+	return nil
+}
+
+// shouldRefresh returns whether one of the generators of the ApplicationSet, including the ones nested in Matrix and
+// Merge generators, is affected by the event.
+func shouldRefresh(appSet *argoprojiov1alpha1.ApplicationSet, event *webhookEvent) bool {
+	for _, generator := range appSet.Spec.Generators {
+		if generatorUsesRepo(generator.Git, generator.SCMProvider, generator.PullRequest, event) {
+			return true
+		}
+		var children []argoprojiov1alpha1.ApplicationSetBaseGenerator
+		if generator.Matrix != nil {
+			children = append(children, generator.Matrix.Generators...)
+		}
+		if generator.Merge != nil {
+			children = append(children, generator.Merge.Generators...)
+		}
+		for _, child := range children {
+			if generatorUsesRepo(child.Git, child.SCMProvider, child.PullRequest, event) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func generatorUsesRepo(gitGen *argoprojiov1alpha1.GitGenerator, scmGen *argoprojiov1alpha1.SCMProviderGenerator, prGen *argoprojiov1alpha1.PullRequestGenerator, event *webhookEvent) bool {
+	if event.pullRequest {
+		return prGen != nil && pullRequestGeneratorUsesRepo(prGen, event)
+	}
+	return (gitGen != nil && gitGeneratorUsesRepo(gitGen, event)) ||
+		(scmGen != nil && scmProviderGeneratorUsesRepo(scmGen, event))
+}
+
+func gitGeneratorUsesRepo(gen *argoprojiov1alpha1.GitGenerator, event *webhookEvent) bool {
+	repoURL := repoKey(gen.RepoURL)
+	for _, eventURL := range event.repoURLs {
+		if repoURL == eventURL {
+			return revisionMatches(gen.Revision, event)
+		}
+	}
+	return false
+}
+
+// revisionMatches returns whether the revision, a branch or tag name, a ref or HEAD, was changed by the push.
+func revisionMatches(revision string, event *webhookEvent) bool {
+	for _, ref := range event.refs {
+		branch := strings.TrimPrefix(ref, "refs/heads/")
+		tag := strings.TrimPrefix(ref, "refs/tags/")
+		switch revision {
+		case ref, branch, tag:
+			return true
+		case "", "HEAD":
+			// Without the default branch, any push to a branch may have moved HEAD
+			if branch != ref && (event.defaultBranch == "" || branch == event.defaultBranch) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// branchPushed returns whether the push changed a branch which is listed by SCM provider generators.
+func branchPushed(allBranches bool, event *webhookEvent) bool {
+	for _, ref := range event.refs {
+		if !strings.HasPrefix(ref, "refs/heads/") {
+			continue
+		}
+		if allBranches || event.defaultBranch == "" || strings.TrimPrefix(ref, "refs/heads/") == event.defaultBranch {
+			return true
+		}
+	}
+	return false
+}
+
+var numericID = regexp.MustCompile(`^[0-9]+$`)
+
+func scmProviderGeneratorUsesRepo(gen *argoprojiov1alpha1.SCMProviderGenerator, event *webhookEvent) bool {
+	owner := repoOwner(event.fullName)
+	switch {
+	case gen.Github != nil && event.provider == providerGitHub:
+		return strings.EqualFold(gen.Github.Organization, owner) &&
+			apiHost(gen.Github.API, "github.com") == event.host &&
+			branchPushed(gen.Github.AllBranches, event)
+	case gen.Gitlab != nil && event.provider == providerGitLab:
+		if apiHost(gen.Gitlab.API, "gitlab.com") != event.host {
+			return false
+		}
+		group := strings.ToLower(strings.Trim(gen.Gitlab.Group, "/"))
+		// The path of a group cannot be resolved from its id without calling the API, so any push may affect it
+		groupMatches := numericID.MatchString(group) ||
+			group == strings.ToLower(owner) ||
+			(gen.Gitlab.IncludeSubgroups && strings.HasPrefix(strings.ToLower(owner), group+"/"))
+		return groupMatches && branchPushed(gen.Gitlab.AllBranches, event)
+	case gen.BitbucketServer != nil && event.provider == providerBitbucketServer:
+		return strings.EqualFold(gen.BitbucketServer.Project, owner) &&
+			apiHost(gen.BitbucketServer.API, "") == event.host &&
+			branchPushed(gen.BitbucketServer.AllBranches, event)
+	}
+	return false
+}
+
+func pullRequestGeneratorUsesRepo(gen *argoprojiov1alpha1.PullRequestGenerator, event *webhookEvent) bool {
+	switch {
+	case gen.Github != nil && event.provider == providerGitHub:
+		return strings.EqualFold(gen.Github.Owner+"/"+gen.Github.Repo, event.fullName) &&
+			apiHost(gen.Github.API, "github.com") == event.host
+	case gen.Gitlab != nil && event.provider == providerGitLab:
+		return apiHost(gen.Gitlab.API, "gitlab.com") == event.host &&
+			(gen.Gitlab.Project == event.projectID || strings.EqualFold(strings.Trim(gen.Gitlab.Project, "/"), event.fullName))
+	}
+	return false
+}
+
+// repoOwner returns the owner of the repository, i.e. its full name without its last element.
+func repoOwner(fullName string) string {
+	if i := strings.LastIndex(fullName, "/"); i >= 0 {
+		return fullName[:i]
+	}
+	return ""
+}
+
+// apiHost returns the hostname of the Git provider whose API is served at apiURL, e.g. github.com for
+// https://api.github.com, or defaultHost if apiURL is empty.
+func apiHost(apiURL, defaultHost string) string {
+	if apiURL == "" {
+		return defaultHost
+	}
+	return strings.TrimPrefix(hostname(apiURL), "api.")
+}
+
+func hostname(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// repoKey returns the host and path of a repository URL, e.g. github.com/argoproj/argo-cd for
+// git@github.com:argoproj/argo-cd.git. The scheme, user and port are ignored, so that the clone URLs of a repository
+// match its web URL whatever protocol is used.
+func repoKey(repoURL string) string {
+	repoURL = strings.ToLower(strings.TrimSpace(repoURL))
+	var host, repoPath string
+	if !strings.Contains(repoURL, "://") {
+		// SCP-like syntax of SSH URLs, e.g. git@github.com:argoproj/argo-cd.git
+		parts := strings.SplitN(repoURL, ":", 2)
+		if len(parts) != 2 {
+			return ""
+		}
+		host = parts[0][strings.LastIndex(parts[0], "@")+1:]
+		repoPath = parts[1]
+	} else {
+		u, err := url.Parse(repoURL)
+		if err != nil {
+			return ""
+		}
+		host = u.Hostname()
+		repoPath = u.Path
+	}
+	return host + "/" + strings.TrimSuffix(strings.Trim(repoPath, "/"), ".git")
+}
+
+func repoKeys(urls ...string) []string {
+	keys := []string{}
+	for _, u := range urls {
+		if u != "" {
+			keys = append(keys, repoKey(u))
+		}
+	}
+	return keys
+}
@@ -0,0 +1,465 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	argosettings "github.com/argoproj/argo-cd/v2/util/settings"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	argoprojiov1alpha1 "github.com/argoproj-labs/applicationset/api/v1alpha1"
+)
+
+type fakeSettings struct {
+	settings argosettings.ArgoCDSettings
+}
+
+func (f *fakeSettings) GetSettings() (*argosettings.ArgoCDSettings, error) {
+	return &f.settings, nil
+}
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func newHandler(t *testing.T, settings argosettings.ArgoCDSettings, appSets ...client.Object) *WebhookHandler {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := argoprojiov1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return &WebhookHandler{
+		namespace: "argocd",
+		settings:  &fakeSettings{settings: settings},
+		client:    fake.NewClientBuilder().WithScheme(scheme).WithObjects(appSets...).Build(),
+	}
+}
+
+func hmacSHA256(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookSecrets are the secrets of all the supported Git providers
+var webhookSecrets = argosettings.ArgoCDSettings{
+	WebhookGitHubSecret:          "github-secret",
+	WebhookGitLabSecret:          "gitlab-secret",
+	WebhookBitbucketUUID:         "{b0d8e0a0-2c6a-4bbd-9a5e-2e7e0f3e5a6e}",
+	WebhookBitbucketServerSecret: "bitbucket-server-secret",
+}
+
+// authenticate sets the headers validating the request of the Git provider with webhookSecrets
+func authenticate(req *http.Request, payload []byte) {
+	eventKey := req.Header.Get("X-Event-Key")
+	switch {
+	case req.Header.Get("X-GitHub-Event") != "":
+		req.Header.Set("X-Hub-Signature-256", hmacSHA256(webhookSecrets.WebhookGitHubSecret, payload))
+	case req.Header.Get("X-Gitlab-Event") != "":
+		req.Header.Set("X-Gitlab-Token", webhookSecrets.WebhookGitLabSecret)
+	case eventKey == "repo:push" || strings.HasPrefix(eventKey, "pullrequest:"):
+		req.Header.Set("X-Hook-UUID", webhookSecrets.WebhookBitbucketUUID)
+	case eventKey != "":
+		req.Header.Set("X-Hub-Signature", hmacSHA256(webhookSecrets.WebhookBitbucketServerSecret, payload))
+	}
+}
+
+func TestWebhookSecrets(t *testing.T) {
+	settings := webhookSecrets
+	githubPayload := readFixture(t, "github-push-event.json")
+	gitlabPayload := readFixture(t, "gitlab-push-event.json")
+	bitbucketPayload := readFixture(t, "bitbucket-push-event.json")
+	bitbucketServerPayload := readFixture(t, "bitbucket-server-push-event.json")
+
+	cases := []struct {
+		name           string
+		method         string
+		headers        map[string]string
+		payload        []byte
+		settings       argosettings.ArgoCDSettings
+		expectedStatus int
+	}{
+		{
+			name:           "GitHub valid signature",
+			headers:        map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": hmacSHA256("github-secret", githubPayload)},
+			payload:        githubPayload,
+			settings:       settings,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "GitHub invalid signature",
+			headers:        map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": hmacSHA256("other-secret", githubPayload)},
+			payload:        githubPayload,
+			settings:       settings,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "GitHub missing signature",
+			headers:        map[string]string{"X-GitHub-Event": "push"},
+			payload:        githubPayload,
+			settings:       settings,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "GitHub no secret configured",
+			headers:        map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": hmacSHA256("", githubPayload)},
+			payload:        githubPayload,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "GitLab no secret configured",
+			headers:        map[string]string{"X-Gitlab-Event": "Push Hook"},
+			payload:        gitlabPayload,
+			settings:       argosettings.ArgoCDSettings{WebhookGitHubSecret: "github-secret"},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "GitHub ping",
+			headers:        map[string]string{"X-GitHub-Event": "ping", "X-Hub-Signature-256": hmacSHA256("github-secret", []byte(`{"zen": "Keep it logically awesome."}`))},
+			payload:        []byte(`{"zen": "Keep it logically awesome."}`),
+			settings:       settings,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "GitLab valid token",
+			headers:        map[string]string{"X-Gitlab-Event": "Push Hook", "X-Gitlab-Token": "gitlab-secret"},
+			payload:        gitlabPayload,
+			settings:       settings,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "GitLab invalid token",
+			headers:        map[string]string{"X-Gitlab-Event": "Push Hook", "X-Gitlab-Token": "other-secret"},
+			payload:        gitlabPayload,
+			settings:       settings,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Bitbucket valid UUID",
+			headers:        map[string]string{"X-Event-Key": "repo:push", "X-Hook-UUID": "{b0d8e0a0-2c6a-4bbd-9a5e-2e7e0f3e5a6e}"},
+			payload:        bitbucketPayload,
+			settings:       settings,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Bitbucket invalid UUID",
+			headers:        map[string]string{"X-Event-Key": "repo:push", "X-Hook-UUID": "{00000000-0000-0000-0000-000000000000}"},
+			payload:        bitbucketPayload,
+			settings:       settings,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Bitbucket Server valid signature",
+			headers:        map[string]string{"X-Event-Key": "repo:refs_changed", "X-Hub-Signature": hmacSHA256("bitbucket-server-secret", bitbucketServerPayload)},
+			payload:        bitbucketServerPayload,
+			settings:       settings,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Bitbucket Server invalid signature",
+			headers:        map[string]string{"X-Event-Key": "repo:refs_changed", "X-Hub-Signature": "sha256=not-hex"},
+			payload:        bitbucketServerPayload,
+			settings:       settings,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Unsupported provider",
+			payload:        githubPayload,
+			settings:       settings,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Malformed payload",
+			headers:        map[string]string{"X-Gitlab-Event": "Push Hook", "X-Gitlab-Token": "gitlab-secret"},
+			payload:        []byte("{"),
+			settings:       settings,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "GET request",
+			method:         http.MethodGet,
+			settings:       settings,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			handler := newHandler(t, c.settings)
+
+			method := c.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			req := httptest.NewRequest(method, Path, bytes.NewReader(c.payload))
+			for header, value := range c.headers {
+				req.Header.Set(header, value)
+			}
+			w := httptest.NewRecorder()
+			handler.Handler(w, req)
+
+			assert.Equal(t, c.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestSecretsConfigured(t *testing.T) {
+	assert.False(t, SecretsConfigured(&argosettings.ArgoCDSettings{}))
+	assert.True(t, SecretsConfigured(&argosettings.ArgoCDSettings{WebhookBitbucketUUID: "{b0d8e0a0-2c6a-4bbd-9a5e-2e7e0f3e5a6e}"}))
+	assert.True(t, SecretsConfigured(&webhookSecrets))
+}
+
+func appSetWithGenerators(name string, generators ...argoprojiov1alpha1.ApplicationSetGenerator) *argoprojiov1alpha1.ApplicationSet {
+	return &argoprojiov1alpha1.ApplicationSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "argocd",
+		},
+		Spec: argoprojiov1alpha1.ApplicationSetSpec{
+			Generators: generators,
+		},
+	}
+}
+
+func gitGenerator(repoURL, revision string) argoprojiov1alpha1.ApplicationSetGenerator {
+	return argoprojiov1alpha1.ApplicationSetGenerator{
+		Git: &argoprojiov1alpha1.GitGenerator{
+			RepoURL:     repoURL,
+			Revision:    revision,
+			Directories: []argoprojiov1alpha1.GitDirectoryGeneratorItem{{Path: "*"}},
+		},
+	}
+}
+
+func TestWebhookRefresh(t *testing.T) {
+	cases := []struct {
+		name            string
+		headers         map[string]string
+		payload         string
+		appSet          *argoprojiov1alpha1.ApplicationSet
+		expectedRefresh bool
+	}{
+		{
+			name:            "GitHub push, Git generator with matching HTTPS URL and branch",
+			headers:         map[string]string{"X-GitHub-Event": "push"},
+			payload:         "github-push-event.json",
+			appSet:          appSetWithGenerators("appset", gitGenerator("https://github.com/argoproj-labs/applicationset.git", "master")),
+			expectedRefresh: true,
+		},
+		{
+			name:            "GitHub push, Git generator with matching SSH URL and HEAD revision",
+			headers:         map[string]string{"X-GitHub-Event": "push"},
+			payload:         "github-push-event.json",
+			appSet:          appSetWithGenerators("appset", gitGenerator("git@github.com:argoproj-labs/ApplicationSet.git", "HEAD")),
+			expectedRefresh: true,
+		},
+		{
+			name:            "GitHub push, Git generator with matching URL and ref revision",
+			headers:         map[string]string{"X-GitHub-Event": "push"},
+			payload:         "github-push-event.json",
+			appSet:          appSetWithGenerators("appset", gitGenerator("https://github.com/argoproj-labs/applicationset", "refs/heads/master")),
+			expectedRefresh: true,
+		},
+		{
+			name:            "GitHub push, Git generator with other branch",
+			headers:         map[string]string{"X-GitHub-Event": "push"},
+			payload:         "github-push-event.json",
+			appSet:          appSetWithGenerators("appset", gitGenerator("https://github.com/argoproj-labs/applicationset.git", "release-0.2")),
+			expectedRefresh: false,
+		},
+		{
+			name:            "GitHub push, Git generator with other repository",
+			headers:         map[string]string{"X-GitHub-Event": "push"},
+			payload:         "github-push-event.json",
+			appSet:          appSetWithGenerators("appset", gitGenerator("https://github.com/argoproj/argo-cd.git", "master")),
+			expectedRefresh: false,
+		},
+		{
+			name:    "GitHub push, Git generator nested in a Matrix generator",
+			headers: map[string]string{"X-GitHub-Event": "push"},
+			payload: "github-push-event.json",
+			appSet: appSetWithGenerators("appset", argoprojiov1alpha1.ApplicationSetGenerator{
+				Matrix: &argoprojiov1alpha1.MatrixGenerator{
+					Generators: []argoprojiov1alpha1.ApplicationSetBaseGenerator{
+						{Clusters: &argoprojiov1alpha1.ClusterGenerator{}},
+						{Git: gitGenerator("https://github.com/argoproj-labs/applicationset.git", "HEAD").Git},
+					},
+				},
+			}),
+			expectedRefresh: true,
+		},
+		{
+			name:    "GitHub push, SCM provider generator of the organization",
+			headers: map[string]string{"X-GitHub-Event": "push"},
+			payload: "github-push-event.json",
+			appSet: appSetWithGenerators("appset", argoprojiov1alpha1.ApplicationSetGenerator{
+				SCMProvider: &argoprojiov1alpha1.SCMProviderGenerator{
+					Github: &argoprojiov1alpha1.SCMProviderGeneratorGithub{Organization: "argoproj-labs"},
+				},
+			}),
+			expectedRefresh: true,
+		},
+		{
+			name:    "GitHub push, SCM provider generator of a GitHub Enterprise organization",
+			headers: map[string]string{"X-GitHub-Event": "push"},
+			payload: "github-push-event.json",
+			appSet: appSetWithGenerators("appset", argoprojiov1alpha1.ApplicationSetGenerator{
+				SCMProvider: &argoprojiov1alpha1.SCMProviderGenerator{
+					Github: &argoprojiov1alpha1.SCMProviderGeneratorGithub{Organization: "argoproj-labs", API: "https://github.example.com/api/v3"},
+				},
+			}),
+			expectedRefresh: false,
+		},
+		{
+			name:    "GitHub pull request, pull request generator of the repository",
+			headers: map[string]string{"X-GitHub-Event": "pull_request"},
+			payload: "github-pull-request-event.json",
+			appSet: appSetWithGenerators("appset", argoprojiov1alpha1.ApplicationSetGenerator{
+				PullRequest: &argoprojiov1alpha1.PullRequestGenerator{
+					Github: &argoprojiov1alpha1.PullRequestGeneratorGithub{Owner: "argoproj-labs", Repo: "applicationset"},
+				},
+			}),
+			expectedRefresh: true,
+		},
+		{
+			name:            "GitHub pull request, Git generator of the repository",
+			headers:         map[string]string{"X-GitHub-Event": "pull_request"},
+			payload:         "github-pull-request-event.json",
+			appSet:          appSetWithGenerators("appset", gitGenerator("https://github.com/argoproj-labs/applicationset.git", "master")),
+			expectedRefresh: false,
+		},
+		{
+			name:            "GitLab push, Git generator with matching URL",
+			headers:         map[string]string{"X-Gitlab-Event": "Push Hook"},
+			payload:         "gitlab-push-event.json",
+			appSet:          appSetWithGenerators("appset", gitGenerator("https://gitlab.example.com/platform/apps/guestbook.git", "main")),
+			expectedRefresh: true,
+		},
+		{
+			name:    "GitLab push, SCM provider generator of a parent group including subgroups",
+			headers: map[string]string{"X-Gitlab-Event": "Push Hook"},
+			payload: "gitlab-push-event.json",
+			appSet: appSetWithGenerators("appset", argoprojiov1alpha1.ApplicationSetGenerator{
+				SCMProvider: &argoprojiov1alpha1.SCMProviderGenerator{
+					Gitlab: &argoprojiov1alpha1.SCMProviderGeneratorGitlab{Group: "platform", IncludeSubgroups: true, API: "https://gitlab.example.com"},
+				},
+			}),
+			expectedRefresh: true,
+		},
+		{
+			name:    "GitLab push, SCM provider generator of a parent group excluding subgroups",
+			headers: map[string]string{"X-Gitlab-Event": "Push Hook"},
+			payload: "gitlab-push-event.json",
+			appSet: appSetWithGenerators("appset", argoprojiov1alpha1.ApplicationSetGenerator{
+				SCMProvider: &argoprojiov1alpha1.SCMProviderGenerator{
+					Gitlab: &argoprojiov1alpha1.SCMProviderGeneratorGitlab{Group: "platform", API: "https://gitlab.example.com"},
+				},
+			}),
+			expectedRefresh: false,
+		},
+		{
+			name:    "GitLab merge request, pull request generator of the project id",
+			headers: map[string]string{"X-Gitlab-Event": "Merge Request Hook"},
+			payload: "gitlab-merge-request-event.json",
+			appSet: appSetWithGenerators("appset", argoprojiov1alpha1.ApplicationSetGenerator{
+				PullRequest: &argoprojiov1alpha1.PullRequestGenerator{
+					Gitlab: &argoprojiov1alpha1.PullRequestGeneratorGitlab{Project: "15", API: "https://gitlab.example.com"},
+				},
+			}),
+			expectedRefresh: true,
+		},
+		{
+			name:            "Bitbucket push, Git generator with matching SSH URL",
+			headers:         map[string]string{"X-Event-Key": "repo:push"},
+			payload:         "bitbucket-push-event.json",
+			appSet:          appSetWithGenerators("appset", gitGenerator("git@bitbucket.org:my-team/guestbook.git", "master")),
+			expectedRefresh: true,
+		},
+		{
+			name:            "Bitbucket push, Git generator of a deleted branch",
+			headers:         map[string]string{"X-Event-Key": "repo:push"},
+			payload:         "bitbucket-push-event.json",
+			appSet:          appSetWithGenerators("appset", gitGenerator("https://bitbucket.org/my-team/guestbook.git", "obsolete")),
+			expectedRefresh: false,
+		},
+		{
+			name:            "Bitbucket Server push, Git generator with matching SSH URL",
+			headers:         map[string]string{"X-Event-Key": "repo:refs_changed"},
+			payload:         "bitbucket-server-push-event.json",
+			appSet:          appSetWithGenerators("appset", gitGenerator("ssh://git@bitbucket.example.com:7999/project/guestbook.git", "HEAD")),
+			expectedRefresh: true,
+		},
+		{
+			name:    "Bitbucket Server push, SCM provider generator of the project",
+			headers: map[string]string{"X-Event-Key": "repo:refs_changed"},
+			payload: "bitbucket-server-push-event.json",
+			appSet: appSetWithGenerators("appset", argoprojiov1alpha1.ApplicationSetGenerator{
+				Merge: &argoprojiov1alpha1.MergeGenerator{
+					Generators: []argoprojiov1alpha1.ApplicationSetBaseGenerator{
+						{SCMProvider: &argoprojiov1alpha1.SCMProviderGenerator{
+							BitbucketServer: &argoprojiov1alpha1.SCMProviderGeneratorBitbucketServer{Project: "project", API: "https://bitbucket.example.com"},
+						}},
+					},
+				},
+			}),
+			expectedRefresh: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			handler := newHandler(t, webhookSecrets, c.appSet)
+
+			payload := readFixture(t, c.payload)
+			req := httptest.NewRequest(http.MethodPost, Path, bytes.NewReader(payload))
+			for header, value := range c.headers {
+				req.Header.Set(header, value)
+			}
+			authenticate(req, payload)
+			w := httptest.NewRecorder()
+			handler.Handler(w, req)
+			assert.Equal(t, http.StatusOK, w.Code)
+
+			appSet := &argoprojiov1alpha1.ApplicationSet{}
+			err := handler.client.Get(context.Background(), client.ObjectKeyFromObject(c.appSet), appSet)
+			assert.NoError(t, err)
+			assert.Equal(t, c.expectedRefresh, appSet.RefreshRequired())
+			if c.expectedRefresh {
+				_, err := time.Parse(time.RFC3339Nano, appSet.Annotations[argoprojiov1alpha1.AnnotationApplicationSetRefresh])
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRepoKey(t *testing.T) {
+	for _, repoURL := range []string{
+		"https://github.com/argoproj/argo-cd",
+		"https://github.com/argoproj/argo-cd.git",
+		"https://user@github.com/argoproj/argo-cd.git",
+		"http://github.com:80/argoproj/argo-cd/",
+		"git@github.com:argoproj/argo-cd.git",
+		"ssh://git@github.com:22/Argoproj/Argo-CD.git",
+	} {
+		assert.Equal(t, "github.com/argoproj/argo-cd", repoKey(repoURL), repoURL)
+	}
+}